@@ -14,6 +14,7 @@ import (
 	"strings"
 
 	"github.com/caarlos0/env/v11"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/glamour/styles"
 	"github.com/charmbracelet/glow/v2/ui"
@@ -43,6 +44,11 @@ var (
 	showLineNumbers  bool
 	preserveNewLines bool
 	mouse            bool
+	resume           bool
+	dumpOnExit       bool
+	styleGiven       bool   // --style was passed, rather than picked for stdout
+	widthGiven       bool   // and --width
+	anchor           string // heading to open the document at, as in README.md#usage
 
 	rootCmd = &cobra.Command{
 		Use:   "glow [SOURCE|DIR]",
@@ -70,6 +76,19 @@ type source struct {
 	URL    string
 }
 
+// splitAnchor splits the anchor of a heading off an argument, as in
+// README.md#installation. Files with a # in their name are left alone.
+func splitAnchor(arg string) (string, string) {
+	i := strings.LastIndex(arg, "#")
+	if i < 0 {
+		return arg, ""
+	}
+	if _, err := os.Stat(arg); err == nil {
+		return arg, ""
+	}
+	return arg[:i], arg[i+1:]
+}
+
 // sourceFromArg parses an argument and creates a readable source for it.
 func sourceFromArg(arg string) (*source, error) {
 	// from stdin
@@ -163,6 +182,25 @@ func validateStyle(style string) error {
 	return nil
 }
 
+// loadStyle reads the glamour style from the configuration file afresh.
+// As on startup, a valid style in GLAMOUR_STYLE takes precedence.
+func loadStyle() (string, error) {
+	if err := viper.ReadInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if !errors.As(err, &notFound) {
+			return "", fmt.Errorf("unable to read configuration: %w", err)
+		}
+	}
+	if s := os.Getenv("GLAMOUR_STYLE"); validateStyle(s) == nil {
+		return s, nil
+	}
+	s := viper.GetString("style")
+	if err := validateStyle(s); err != nil {
+		return "", err
+	}
+	return s, nil
+}
+
 func validateOptions(cmd *cobra.Command) error {
 	// grab config values from Viper
 	width = viper.GetUint("width")
@@ -173,6 +211,8 @@ func validateOptions(cmd *cobra.Command) error {
 	showAllFiles = viper.GetBool("all")
 	preserveNewLines = viper.GetBool("preserveNewLines")
 	showLineNumbers = viper.GetBool("showLineNumbers")
+	resume = viper.GetBool("resume")
+	dumpOnExit = viper.GetBool("dumpOnExit")
 
 	if pager && tui {
 		return errors.New("cannot use both pager and tui")
@@ -192,15 +232,17 @@ func validateOptions(cmd *cobra.Command) error {
 		return err
 	}
 
+	styleGiven = cmd.Flags().Changed("style")
+	widthGiven = cmd.Flags().Changed("width")
 	isTerminal := term.IsTerminal(int(os.Stdout.Fd()))
 	// We want to use a special no-TTY style, when stdout is not a terminal
 	// and there was no specific style passed by arg
-	if !isTerminal && !cmd.Flags().Changed("style") {
+	if !isTerminal && !styleGiven {
 		style = "notty"
 	}
 
 	// Detect terminal width
-	if !cmd.Flags().Changed("width") { //nolint:nestif
+	if !widthGiven { //nolint:nestif
 		if isTerminal && width == 0 {
 			w, _, err := term.GetSize(int(os.Stdout.Fd()))
 			if err == nil {
@@ -241,8 +283,12 @@ func execute(cmd *cobra.Command, args []string) error {
 	}
 
 	switch len(args) {
-	// TUI running on cwd
+	// TUI running on cwd, or on the document last viewed
 	case 0:
+		if doc, ok := resumeDocument(); ok {
+			tui = true
+			return executeArg(cmd, doc, os.Stdout)
+		}
 		return runTUI("", "")
 
 	// TUI with possible dir argument
@@ -270,8 +316,27 @@ func execute(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// resumeDocument returns the document viewed most recently, for --resume to
+// reopen. A document that's gone missing is reported, and not reopened.
+func resumeDocument() (string, bool) {
+	if !resume {
+		return "", false
+	}
+	doc := ui.MostRecentDocument()
+	if doc == "" {
+		fmt.Fprintln(os.Stderr, "No document to resume yet, showing the file listing.")
+		return "", false
+	}
+	if _, err := os.Stat(doc); err != nil {
+		fmt.Fprintf(os.Stderr, "%s can't be resumed, as it's no longer there. Showing the file listing instead.\n", doc)
+		return "", false
+	}
+	return doc, true
+}
+
 func executeArg(cmd *cobra.Command, arg string, w io.Writer) error {
 	// create an io.Reader from the markdown source in cli-args
+	arg, anchor = splitAnchor(arg)
 	src, err := sourceFromArg(arg)
 	if err != nil {
 		return err
@@ -285,6 +350,11 @@ func executeCLI(cmd *cobra.Command, src *source, w io.Writer) error {
 	if err != nil {
 		return fmt.Errorf("unable to read from reader: %w", err)
 	}
+	if utils.IsCompressed(src.URL) {
+		if b, err = utils.Decompress(b); err != nil {
+			return fmt.Errorf("unable to read %s: %w", src.URL, err)
+		}
+	}
 
 	b = utils.RemoveFrontmatter(b)
 
@@ -311,7 +381,7 @@ func executeCLI(cmd *cobra.Command, src *source, w io.Writer) error {
 	}
 
 	content := string(b)
-	ext := filepath.Ext(src.URL)
+	ext := filepath.Ext(utils.UncompressedName(src.URL))
 	if isCode {
 		content = utils.WrapCodeBlock(string(b), ext)
 	}
@@ -364,21 +434,124 @@ func runTUI(path string, content string) error {
 	}
 
 	cfg.Path = path
+	cfg.Anchor = anchor
 	cfg.ShowAllFiles = showAllFiles
 	cfg.ShowLineNumbers = showLineNumbers
+	cfg.ShowLineNumbersCode = viper.GetBool("showLineNumbersCode")
+	cfg.ShowLineNumbersProse = viper.GetBool("showLineNumbersProse")
 	cfg.GlamourMaxWidth = width
+	cfg.OverflowWidth = viper.GetBool("overflowWidth")
 	cfg.EnableMouse = mouse
 	cfg.PreserveNewLines = preserveNewLines
+	cfg.ReflowHardWraps = viper.GetBool("reflowHardWraps")
 	cfg.PresentationMode = presentation
+	cfg.LineNumberOffset = viper.GetInt("lineOffset")
+	cfg.SlideTransitionFlash = viper.GetBool("slideTransitionFlash")
+	cfg.SlideAlign = viper.GetString("slideAlign")
+	cfg.PaginateSlides = viper.GetBool("paginateSlides")
+	cfg.SlideAutoAdvance = viper.GetDuration("slideAutoAdvance")
+	cfg.SlideLoop = viper.GetBool("slideLoop")
+	cfg.SlideIndicatorStyle = viper.GetString("slideIndicatorStyle")
+	cfg.SlideIndicatorDotsMax = viper.GetInt("slideIndicatorDotsMax")
+	cfg.SingleSlide = viper.GetString("singleSlide")
+	cfg.SlideProgressBar = viper.GetBool("slideProgressBar")
+	cfg.SlideSeparator = viper.GetString("slideSeparator")
+	cfg.SlideHeaderPattern = viper.GetString("slideHeaderPattern")
+	cfg.SlideImagePath = viper.GetString("slideImagePath")
+	cfg.SlideImageWidth = viper.GetInt("slideImageWidth")
+	cfg.SlideMirrorPath = viper.GetString("slideMirrorPath")
+	cfg.SearchExportPath = viper.GetString("searchExportPath")
+	cfg.SearchExportContext = viper.GetInt("searchExportContext")
+	cfg.SnippetContext = viper.GetInt("snippetContext")
+	cfg.InlineImages = viper.GetBool("inlineImages")
+	if err := viper.UnmarshalKey("chromeColors", &cfg.ChromeColors); err != nil {
+		log.Warn("Could not parse chrome colors, using the defaults", "err", err)
+	}
+	cfg.ControlSocket = viper.GetString("controlSocket")
+	cfg.HeadingHangingIndent = viper.GetBool("headingHangingIndent")
+	cfg.ListStyling = viper.GetBool("listStyling")
+	cfg.CopyFallbackFile = viper.GetBool("copyFallbackFile")
+	cfg.SlowRenderThreshold = viper.GetDuration("slowRenderThreshold")
+	cfg.StreamRenderThreshold = viper.GetInt("streamRenderThreshold")
+	cfg.QuitKeyBehavior = viper.GetString("quitKeyBehavior")
+	cfg.EmojiWidth = viper.GetString("emojiWidth")
+	cfg.MaxLineLength = viper.GetInt("maxLineLength")
+	cfg.ResolveRelativeLinks = viper.GetBool("resolveRelativeLinks")
+	cfg.MouseScrollLines = viper.GetInt("mouseScrollLines")
+	cfg.RecentDocuments = viper.GetInt("recentDocuments")
+	cfg.RememberPosition = viper.GetBool("rememberPosition") || resume
+	cfg.HorizontalScrollStep = viper.GetInt("horizontalScrollStep")
+	cfg.PlainCodeExtensions = viper.GetStringSlice("plainCodeExtensions")
+	cfg.ViewPadding = viper.GetIntSlice("viewPadding")
+	cfg.OpenTOCOnLoad = viper.GetBool("openTOCOnLoad")
+	cfg.FrontmatterTitle = viper.GetBool("frontmatterTitle")
+	cfg.PollInterval = viper.GetDuration("pollInterval")
+	cfg.ReloadDebounce = viper.GetDuration("reloadDebounce")
+	cfg.AutoDetectCodeLanguage = viper.GetBool("autoDetectCodeLanguage")
+	cfg.RenderMermaid = viper.GetBool("renderMermaid")
+	cfg.MermaidCommand = viper.GetString("mermaidCommand")
+	cfg.StatusBarNote = viper.GetString("statusBarNote")
+	cfg.StatusBarSegments = viper.GetStringSlice("statusBarSegments")
+	cfg.ReadingWPM = viper.GetInt("readingWPM")
+	cfg.FigureStyling = viper.GetBool("figureStyling")
+	cfg.ValidateConfigBlocks = viper.GetBool("validateConfigBlocks")
+	cfg.InlineCodeForeground = viper.GetString("inlineCodeForeground")
+	cfg.InlineCodeBackground = viper.GetString("inlineCodeBackground")
+	cfg.CodeStyle = viper.GetString("codeStyle")
+	if cfg.CodeStyle != "" && !utils.CodeThemeExists(cfg.CodeStyle) {
+		log.Warn("Unknown code style, using the document style's", "codeStyle", cfg.CodeStyle)
+		cfg.CodeStyle = ""
+	}
+	cfg.KeyBindings = viper.GetStringMapStringSlice("keys")
+	if err := ui.CheckKeyBindings(cfg.KeyBindings); err != nil {
+		return err
+	}
+	cfg.LoadStyle = loadStyle
 
 	// Run Bubble Tea program
-	if _, err := ui.NewProgram(cfg, content).Run(); err != nil {
+	var opts []tea.ProgramOption
+	if tty := dumpTerminal(&cfg); tty != nil {
+		defer tty.Close() //nolint:errcheck
+		opts = append(opts, tea.WithOutput(tty))
+	}
+	p, stop := ui.NewProgram(cfg, content, opts...)
+	defer stop()
+	final, err := p.Run()
+	if err != nil {
 		return fmt.Errorf("unable to run tui program: %w", err)
 	}
 
+	// Leave the document last read behind, for piping into less -R or a
+	// file
+	if dumpOnExit {
+		fmt.Print(ui.RenderedDocument(final))
+	}
+
 	return nil
 }
 
+// dumpTerminal returns the terminal to draw the TUI on when stdout is
+// redirected for --dump-on-exit, and sets the style and width up for it
+// rather than for stdout. It returns nil otherwise.
+func dumpTerminal(cfg *ui.Config) *os.File {
+	if !dumpOnExit || term.IsTerminal(int(os.Stdout.Fd())) {
+		return nil
+	}
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		log.Warn("Could not open the terminal, drawing on stdout", "err", err)
+		return nil
+	}
+
+	if !styleGiven && cfg.GlamourStyle == "notty" {
+		cfg.GlamourStyle = styles.AutoStyle
+	}
+	if w, _, err := term.GetSize(int(tty.Fd())); err == nil && !widthGiven {
+		cfg.GlamourMaxWidth = uint(min(w, 120)) //nolint:gosec
+	}
+	return tty
+}
+
 func main() {
 	closer, err := setupLog()
 	if err != nil {
@@ -413,9 +586,12 @@ func init() {
 	rootCmd.Flags().UintVarP(&width, "width", "w", 0, "word-wrap at width (set to 0 to disable)")
 	rootCmd.Flags().BoolVarP(&showAllFiles, "all", "a", false, "show system files and directories (TUI-mode only)")
 	rootCmd.Flags().BoolVarP(&showLineNumbers, "line-numbers", "l", false, "show line numbers (TUI-mode only)")
+	rootCmd.Flags().Int("line-offset", 0, "number the first line as line-offset+1 (TUI-mode only)")
 	rootCmd.Flags().BoolVarP(&preserveNewLines, "preserve-new-lines", "n", false, "preserve newlines in the output")
 	rootCmd.Flags().BoolVarP(&mouse, "mouse", "m", false, "enable mouse wheel (TUI-mode only)")
 	_ = rootCmd.Flags().MarkHidden("mouse")
+	rootCmd.Flags().BoolVar(&resume, "resume", false, "reopen the last viewed document where you left off (TUI-mode only)")
+	rootCmd.Flags().BoolVar(&dumpOnExit, "dump-on-exit", false, "print the last viewed document to stdout on quit (TUI-mode only)")
 
 	// Config bindings
 	_ = viper.BindPFlag("pager", rootCmd.Flags().Lookup("pager"))
@@ -428,10 +604,33 @@ func init() {
 	_ = viper.BindPFlag("preserveNewLines", rootCmd.Flags().Lookup("preserve-new-lines"))
 	_ = viper.BindPFlag("showLineNumbers", rootCmd.Flags().Lookup("line-numbers"))
 	_ = viper.BindPFlag("all", rootCmd.Flags().Lookup("all"))
+	_ = viper.BindPFlag("lineOffset", rootCmd.Flags().Lookup("line-offset"))
+	_ = viper.BindPFlag("resume", rootCmd.Flags().Lookup("resume"))
+	_ = viper.BindPFlag("dumpOnExit", rootCmd.Flags().Lookup("dump-on-exit"))
 
 	viper.SetDefault("style", styles.AutoStyle)
 	viper.SetDefault("width", 0)
 	viper.SetDefault("all", true)
+	viper.SetDefault("slideAlign", "left")
+	viper.SetDefault("slowRenderThreshold", "500ms")
+	viper.SetDefault("reloadDebounce", "150ms")
+	viper.SetDefault("streamRenderThreshold", 512*1024)
+	viper.SetDefault("quitKeyBehavior", "auto")
+	viper.SetDefault("showLineNumbersCode", true)
+	viper.SetDefault("emojiWidth", "auto")
+	viper.SetDefault("maxLineLength", 10000)
+	viper.SetDefault("mouseScrollLines", 3)
+	viper.SetDefault("recentDocuments", 20)
+	viper.SetDefault("horizontalScrollStep", 4)
+	viper.SetDefault("slideIndicatorStyle", "text")
+	viper.SetDefault("slideIndicatorDotsMax", 20)
+	viper.SetDefault("singleSlide", "hide")
+	viper.SetDefault("slideSeparator", "numbered-h1")
+	viper.SetDefault("slideImageWidth", 80)
+	viper.SetDefault("searchExportContext", 2)
+	viper.SetDefault("snippetContext", 3)
+	viper.SetDefault("statusBarNote", "left")
+	viper.SetDefault("readingWPM", 200)
 
 	rootCmd.AddCommand(configCmd, manCmd)
 }
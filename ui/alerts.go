@@ -0,0 +1,122 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Bar glamour draws in front of block quotes.
+const blockQuoteBar = "│"
+
+// alertKind is a kind of GitHub alert, like > [!NOTE].
+type alertKind struct {
+	label string
+	icon  string
+	style lipgloss.Style
+}
+
+var (
+	// Alert markers, at any depth of block quotes and lists.
+	alertPattern = regexp.MustCompile(`(?i)^((?:\s*(?:[-*+]|\d+[.)])?\s*>)+\s*)\[!(NOTE|TIP|IMPORTANT|WARNING|CAUTION)\]\s*$`)
+
+	listMarkerPattern = regexp.MustCompile(`[-*+]|\d+[.)]`)
+
+	alertKinds = map[string]alertKind{
+		"NOTE":      {"Note", "ℹ", lipgloss.NewStyle().Foreground(lipgloss.Color("#4493F8"))},
+		"TIP":       {"Tip", "✓", lipgloss.NewStyle().Foreground(lipgloss.Color("#3FB950"))},
+		"IMPORTANT": {"Important", "❢", lipgloss.NewStyle().Foreground(lipgloss.Color("#AB7DF8"))},
+		"WARNING":   {"Warning", "⚠", lipgloss.NewStyle().Foreground(lipgloss.Color("#D29922"))},
+		"CAUTION":   {"Caution", "⊘", lipgloss.NewStyle().Foreground(lipgloss.Color("#F85149"))},
+	}
+)
+
+// title returns the title the alert is rendered with.
+func (k alertKind) title() string {
+	return k.icon + " " + k.label
+}
+
+// rewriteAlerts replaces GitHub alert markers in the given markdown with
+// their titles, on a line of their own, wherever the alerts are nested. It
+// returns the rewritten markdown along with the kinds of the alerts found,
+// in document order, for styleAlerts to color them once rendered.
+func rewriteAlerts(md string) (string, []alertKind) {
+	if !strings.Contains(md, "[!") {
+		return md, nil
+	}
+
+	var (
+		out     []string
+		kinds   []alertKind
+		inFence bool
+	)
+	for _, line := range strings.Split(md, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+		if inFence || strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			out = append(out, line)
+			continue
+		}
+
+		m := alertPattern.FindStringSubmatch(line)
+		if m == nil {
+			out = append(out, line)
+			continue
+		}
+		kind := alertKinds[strings.ToUpper(m[2])]
+		kinds = append(kinds, kind)
+
+		// Glamour doesn't render hard line breaks, so the title gets a
+		// paragraph of its own, within the same block quote
+		quote := listMarkerPattern.ReplaceAllStringFunc(m[1], func(marker string) string {
+			return strings.Repeat(" ", len(marker))
+		})
+		out = append(out, m[1]+"**"+kind.title()+"**", strings.TrimRight(quote, " "))
+	}
+
+	return strings.Join(out, "\n"), kinds
+}
+
+// styleAlerts colors the titles of the given alerts in rendered output,
+// along with the block quote bar running down their side.
+func styleAlerts(kinds []alertKind, lines []string) []string {
+	titles := make([]string, len(kinds))
+	for i, k := range kinds {
+		titles[i] = k.title()
+	}
+
+	for i, start := range findTextLines(titles, lines) {
+		if start < 0 {
+			continue
+		}
+		plain := ansi.Strip(lines[start])
+		idx := strings.Index(plain, titles[i])
+		bar := strings.LastIndex(plain[:idx], blockQuoteBar)
+		if bar < 0 {
+			continue
+		}
+		col := ansi.StringWidth(plain[:bar])
+		titleCol := ansi.StringWidth(plain[:idx])
+		titleWidth := ansi.StringWidth(titles[i])
+
+		lines[start] = ansi.Truncate(lines[start], titleCol, "") +
+			kinds[i].style.Bold(true).Render(titles[i]) +
+			ansi.TruncateLeft(lines[start], titleCol+titleWidth, "")
+
+		// The bar continues for as long as the alert's block quote does
+		for j := start; j < len(lines); j++ {
+			if ansi.Strip(ansi.Cut(lines[j], col, col+1)) != blockQuoteBar {
+				break
+			}
+			lines[j] = ansi.Truncate(lines[j], col, "") +
+				kinds[i].style.Render(blockQuoteBar) +
+				ansi.TruncateLeft(lines[j], col+1, "")
+		}
+	}
+
+	return lines
+}
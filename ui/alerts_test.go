@@ -0,0 +1,104 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestRewriteAlerts(t *testing.T) {
+	tests := []struct {
+		name string
+		md   string
+		want string
+	}{
+		{
+			name: "top level",
+			md:   "> [!NOTE]\n> Text",
+			want: "> **ℹ Note**\n>\n> Text",
+		},
+		{
+			name: "in a list item",
+			md:   "- Item\n  > [!warning]\n  > Text",
+			want: "- Item\n  > **⚠ Warning**\n  >\n  > Text",
+		},
+		{
+			name: "in a nested list item",
+			md:   "1. Item\n   - Nested\n     > [!TIP]\n     > Text",
+			want: "1. Item\n   - Nested\n     > **✓ Tip**\n     >\n     > Text",
+		},
+		{
+			name: "on a list marker",
+			md:   "- > [!CAUTION]\n  > Text",
+			want: "- > **⊘ Caution**\n  >\n  > Text",
+		},
+		{
+			name: "in a block quote",
+			md:   "> Quote\n>\n> > [!IMPORTANT]\n> > Text",
+			want: "> Quote\n>\n> > **❢ Important**\n> >\n> > Text",
+		},
+		{
+			name: "in a code block",
+			md:   "```\n> [!NOTE]\n```",
+			want: "```\n> [!NOTE]\n```",
+		},
+		{
+			name: "not an alert",
+			md:   "> [!NOTE] with text\n> [!UNKNOWN]",
+			want: "> [!NOTE] with text\n> [!UNKNOWN]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := rewriteAlerts(tt.md)
+			if got != tt.want {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestStyleAlerts(t *testing.T) {
+	const md = "- Item\n  - Nested\n    > [!TIP]\n    > Text\n\n> > [!NOTE]\n> > Deep\n"
+
+	rewritten, kinds := rewriteAlerts(md)
+	if len(kinds) != 2 {
+		t.Fatalf("expected 2 alerts, got %d", len(kinds))
+	}
+
+	r, err := glamour.NewTermRenderer(glamour.WithStandardStyle("dark"), glamour.WithWordWrap(80))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := r.Render(rewritten)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(out, "\n")
+	before := make([]string, len(lines))
+	copy(before, lines)
+	lines = styleAlerts(kinds, lines)
+
+	for _, k := range kinds {
+		styled := false
+		for i, l := range lines {
+			plain := ansi.Strip(l)
+			if !strings.Contains(plain, k.title()) {
+				continue
+			}
+			if plain != ansi.Strip(before[i]) {
+				t.Errorf("expected styling to leave the text alone\nwant: %q\ngot:  %q", ansi.Strip(before[i]), plain)
+			}
+			if !strings.Contains(plain, blockQuoteBar+" "+k.title()) {
+				t.Errorf("expected %q to keep its block quote bar, got %q", k.title(), plain)
+			}
+			styled = l != before[i]
+		}
+		if !styled {
+			t.Errorf("expected %q to be styled", k.title())
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// autoAdvanceMsg moves on to the next slide once Config.SlideAutoAdvance
+// has passed on the current one.
+type autoAdvanceMsg struct{ gen int }
+
+// autoAdvances returns whether slides move on by themselves, paused or not.
+func (m pagerModel) autoAdvances() bool {
+	return m.common.cfg.SlideAutoAdvance > 0 && m.slideMode
+}
+
+// scheduleAutoAdvance starts counting down to the next slide, from the top,
+// dropping any countdown already underway.
+func (m *pagerModel) scheduleAutoAdvance() tea.Cmd {
+	m.autoAdvanceGen++
+	if !m.autoAdvances() || m.autoAdvancePaused {
+		return nil
+	}
+	gen := m.autoAdvanceGen
+	return tea.Tick(m.common.cfg.SlideAutoAdvance, func(time.Time) tea.Msg {
+		return autoAdvanceMsg{gen}
+	})
+}
+
+// handleAutoAdvance moves on to the next slide or page, or back to the
+// first slide after the last with Config.SlideLoop.
+func (m *pagerModel) handleAutoAdvance(msg autoAdvanceMsg) tea.Cmd {
+	if msg.gen != m.autoAdvanceGen || !m.autoAdvances() || m.autoAdvancePaused {
+		return nil // paused, or navigated since
+	}
+
+	page, pages := m.slidePage()
+	if m.currentSlide < len(m.slides)-1 || page < pages {
+		return tea.Batch(m.nextPage(), m.scheduleAutoAdvance())
+	}
+	if m.common.cfg.SlideLoop && len(m.slides) > 1 {
+		return tea.Batch(m.gotoSlide(0), m.scheduleAutoAdvance())
+	}
+	return nil
+}
+
+// toggleAutoAdvance pauses or resumes moving on through the slides.
+func (m *pagerModel) toggleAutoAdvance() tea.Cmd {
+	if !m.autoAdvances() {
+		return m.showStatusMessage(pagerStatusMessage{"Auto-advance is off for this document", true})
+	}
+	m.autoAdvancePaused = !m.autoAdvancePaused
+	message := "Auto-advance: resumed"
+	if m.autoAdvancePaused {
+		message = "Auto-advance: paused"
+	}
+	return tea.Batch(m.scheduleAutoAdvance(), m.showStatusMessage(pagerStatusMessage{message: message}))
+}
+
+// autoAdvanceView renders whether slides are moving on by themselves, for
+// the status bar.
+func (m pagerModel) autoAdvanceView() string {
+	if m.autoAdvancePaused {
+		return "[auto: paused]"
+	}
+	return "[auto]"
+}
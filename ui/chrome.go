@@ -0,0 +1,122 @@
+package ui
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+)
+
+// Key of Config.ChromeColors applying to every glamour style.
+const chromeColorsDefault = "default"
+
+var hexColorPattern = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}){1,2}$`)
+
+// ChromeColors are the colors of the pager's line numbers and status bar, as
+// hex or ANSI 256 color codes. Empty colors are left as they are.
+type ChromeColors struct {
+	LineNumber         string
+	StatusBar          string
+	StatusBarBg        string
+	StatusBarMessage   string
+	StatusBarMessageBg string
+}
+
+// merge returns the colors, with those set in other taking precedence.
+func (c ChromeColors) merge(other ChromeColors) ChromeColors {
+	for _, f := range []struct{ dst, src *string }{
+		{&c.LineNumber, &other.LineNumber},
+		{&c.StatusBar, &other.StatusBar},
+		{&c.StatusBarBg, &other.StatusBarBg},
+		{&c.StatusBarMessage, &other.StatusBarMessage},
+		{&c.StatusBarMessageBg, &other.StatusBarMessageBg},
+	} {
+		if *f.src != "" {
+			*f.dst = *f.src
+		}
+	}
+	return c
+}
+
+// applyChromeColors restyles the line numbers and status bar with the colors
+// configured for the given glamour style, on top of those configured for
+// every style. Colors that aren't valid are reported, and the defaults used
+// instead.
+func applyChromeColors(colors map[string]ChromeColors, style string) {
+	var c ChromeColors
+	for _, key := range []string{chromeColorsDefault, style} {
+		// Config keys are case-insensitive
+		for k, colors := range colors {
+			if strings.EqualFold(k, key) {
+				c = c.merge(colors)
+			}
+		}
+	}
+
+	c = c.validate()
+
+	lineNumberStyle = lipgloss.NewStyle().
+		Foreground(chromeColor(c.LineNumber, lineNumberFg)).
+		Render
+
+	fg := chromeColor(c.StatusBar, statusBarNoteFg)
+	bg := chromeColor(c.StatusBarBg, statusBarBg)
+	statusBarNoteStyle = lipgloss.NewStyle().Foreground(fg).Background(bg).Render
+	statusBarScrollPosStyle = lipgloss.NewStyle().
+		Foreground(chromeColor(c.StatusBar, statusBarScrollPosFg)).
+		Background(bg).
+		Render
+	statusBarHelpStyle = lipgloss.NewStyle().
+		Foreground(fg).
+		Background(chromeColor(c.StatusBarBg, statusBarHelpBg)).
+		Render
+
+	messageFg := chromeColor(c.StatusBarMessage, mintGreen)
+	messageBg := chromeColor(c.StatusBarMessageBg, darkGreen)
+	statusBarMessageStyle = lipgloss.NewStyle().Foreground(messageFg).Background(messageBg).Render
+	statusBarMessageScrollPosStyle = statusBarMessageStyle
+	statusBarMessageHelpStyle = lipgloss.NewStyle().
+		Foreground(chromeColor(c.StatusBarMessage, lipgloss.Color("#B6FFE4"))).
+		Background(chromeColor(c.StatusBarMessageBg, green)).
+		Render
+}
+
+// validate reports colors that aren't valid and leaves them out.
+func (c ChromeColors) validate() ChromeColors {
+	for _, f := range []struct {
+		name  string
+		color *string
+	}{
+		{"lineNumber", &c.LineNumber},
+		{"statusBar", &c.StatusBar},
+		{"statusBarBg", &c.StatusBarBg},
+		{"statusBarMessage", &c.StatusBarMessage},
+		{"statusBarMessageBg", &c.StatusBarMessageBg},
+	} {
+		if *f.color == "" || isValidColor(*f.color) {
+			continue
+		}
+		log.Warn("invalid chrome color, using the default", "color", f.name, "value", *f.color)
+		*f.color = ""
+	}
+	return c
+}
+
+// isValidColor returns whether the given color is a hex or ANSI 256 color
+// code.
+func isValidColor(color string) bool {
+	if n, err := strconv.Atoi(color); err == nil {
+		return n >= 0 && n <= 255
+	}
+	return hexColorPattern.MatchString(color)
+}
+
+// chromeColor returns the given color, or the fallback if there's none.
+func chromeColor(color string, fallback lipgloss.TerminalColor) lipgloss.TerminalColor {
+	if color == "" {
+		return fallback
+	}
+	return lipgloss.Color(color)
+}
@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+	"github.com/muesli/termenv"
+)
+
+// copyToClipboard copies text to the clipboard, both through OSC 52 and the
+// native system clipboard, and reports the result in the status bar.
+//
+// Neither method is guaranteed to work: terminal multiplexers often swallow
+// OSC 52 sequences and headless hosts have no native clipboard. When both are
+// likely to have failed and the fallback is enabled, the text is written to a
+// temporary file instead so it can still be retrieved.
+func (m *pagerModel) copyToClipboard(text, message string) tea.Cmd {
+	// Copy using OSC 52
+	termenv.Copy(text)
+	// Copy using native system clipboard
+	err := clipboard.WriteAll(text)
+	if err == nil || !m.common.cfg.CopyFallbackFile || !osc52Unreliable() {
+		return m.showStatusMessage(pagerStatusMessage{message, false})
+	}
+
+	log.Debug("clipboard unavailable, falling back to a file", "error", err)
+	path, err := writeCopyFallback(text)
+	if err != nil {
+		log.Error("unable to write copy fallback", "error", err)
+		return m.showStatusMessage(pagerStatusMessage{"Couldn't copy to clipboard", true})
+	}
+	return m.showStatusMessage(pagerStatusMessage{"Clipboard unavailable, saved to " + path, false})
+}
+
+// osc52Unreliable reports whether OSC 52 copies are likely to be dropped,
+// which is the case inside terminal multiplexers unless they are configured
+// to pass them through.
+func osc52Unreliable() bool {
+	if os.Getenv("TMUX") != "" || os.Getenv("STY") != "" {
+		return true
+	}
+	term := os.Getenv("TERM")
+	return term == "" || term == "dumb" || term == "linux" ||
+		strings.HasPrefix(term, "screen") || strings.HasPrefix(term, "tmux")
+}
+
+// writeCopyFallback writes text to a new temporary file, returning its path.
+func writeCopyFallback(text string) (string, error) {
+	f, err := os.CreateTemp("", "glow-copy-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("unable to create copy file: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	if _, err := f.WriteString(text); err != nil {
+		return "", fmt.Errorf("unable to write copy file: %w", err)
+	}
+	return f.Name(), nil
+}
@@ -0,0 +1,103 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+// codeBlock is a fenced code block.
+type codeBlock struct {
+	info    string   // info string after the opening fence
+	lang    string   // first word of the info string, if any
+	code    []string // contents, without the fences
+	first   string   // first non-blank line, to find the block by
+	skipped int      // blank lines before it
+	closed  bool     // whether it has a closing fence
+}
+
+// parseCodeBlocks returns the fenced code blocks in the given markdown. An
+// unclosed fence runs to the end of the document.
+func parseCodeBlocks(md string) []codeBlock {
+	var (
+		blocks []codeBlock
+		lines  = strings.Split(md, "\n")
+	)
+	for i := 0; i < len(lines); i++ {
+		m := fenceOpenPattern.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+		end := closingFence(lines, i, m[2])
+		b := codeBlock{info: m[3], closed: end >= 0}
+		if end < 0 {
+			end = len(lines)
+		}
+
+		// Contents are indented as much as the fence
+		if fields := strings.Fields(m[3]); len(fields) > 0 {
+			b.lang = fields[0]
+		}
+		for _, l := range lines[i+1 : end] {
+			b.code = append(b.code, strings.TrimPrefix(l, m[1]))
+			if b.first == "" {
+				if strings.TrimSpace(l) == "" {
+					b.skipped++
+				} else {
+					b.first = strings.TrimSpace(l)
+				}
+			}
+		}
+		blocks = append(blocks, b)
+		i = end
+	}
+	return blocks
+}
+
+// copyCodeBlock copies the contents of the code block at the top of the
+// viewport or, when the top line isn't code, the first block below it that's
+// in view.
+func (m *pagerModel) copyCodeBlock() tea.Cmd {
+	if !utils.IsMarkdownFile(m.currentDocument.Note) {
+		return m.showStatusMessage(pagerStatusMessage{"Only markdown files have code blocks to copy", true})
+	}
+
+	blocks := parseCodeBlocks(m.currentMarkdown())
+	firsts := make([]string, len(blocks))
+	for i, b := range blocks {
+		firsts[i] = b.first
+	}
+
+	// Code isn't wrapped, so blocks take up as many lines as they have
+	top, bottom := m.viewport.YOffset, m.viewport.YOffset+m.viewport.Height
+	current := -1
+	for i, start := range findTextLines(firsts, m.renderedLines()) {
+		if start < 0 {
+			continue
+		}
+		start -= blocks[i].skipped
+		if start >= bottom {
+			break
+		}
+		if start+len(blocks[i].code) > top {
+			current = i
+			break
+		}
+	}
+	if current < 0 {
+		return m.showStatusMessage(pagerStatusMessage{"No code block to copy here", true})
+	}
+
+	b := blocks[current]
+	lines := fmt.Sprintf("%d lines", len(b.code))
+	if len(b.code) == 1 {
+		lines = "1 line"
+	}
+	message := fmt.Sprintf("Copied code block (%s)", lines)
+	if b.lang != "" {
+		message = fmt.Sprintf("Copied code block (%s, %s)", b.lang, lines)
+	}
+	return m.copyToClipboard(strings.Join(b.code, "\n")+"\n", message)
+}
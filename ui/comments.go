@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// commentMark surrounds revealed comments in the markdown given to glamour,
+// to find them by in rendered output. It's an invisible separator, which
+// takes up no room.
+const commentMark = "⁣"
+
+var (
+	commentPattern = regexp.MustCompile(`(?s)<!--(.*?)-->`)
+	commentStyle   = lipgloss.NewStyle().Foreground(gray).Italic(true)
+)
+
+// revealComments rewrites the HTML comments in the given markdown as text,
+// which glamour renders rather than leaving out, surrounded by commentMarks
+// for styleComments to dim them. Comments in code and slide settings
+// comments are left alone. It returns whether there were any comments.
+func revealComments(md string) (string, bool) {
+	if !strings.Contains(md, "<!--") {
+		return md, false
+	}
+
+	var (
+		out     []string
+		prose   []string // lines since the last code block
+		found   bool
+		inFence bool
+	)
+	flush := func() {
+		if len(prose) == 0 {
+			return
+		}
+		out = append(out, replaceComments(strings.Join(prose, "\n"), &found))
+		prose = nil
+	}
+	for _, line := range strings.Split(md, "\n") {
+		trimmed := strings.TrimSpace(line)
+		fence := strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")
+		if fence && !inFence {
+			flush()
+		}
+		if fence {
+			inFence = !inFence
+			out = append(out, line)
+			continue
+		}
+		if inFence {
+			out = append(out, line)
+			continue
+		}
+		prose = append(prose, line)
+	}
+	flush()
+
+	return strings.Join(out, "\n"), found
+}
+
+// replaceComments replaces the comments in prose with their text, between
+// commentMarks, noting whether there were any. Comments within inline code
+// are left alone.
+func replaceComments(text string, found *bool) string {
+	var (
+		b    strings.Builder
+		last int
+	)
+	for _, loc := range commentPattern.FindAllStringSubmatchIndex(text, -1) {
+		start, end := loc[0], loc[1]
+		comment := text[loc[2]:loc[3]]
+
+		lineStart := strings.LastIndex(text[:start], "\n") + 1
+		inCode := strings.Count(text[lineStart:start], "`")%2 == 1
+		content := strings.Join(strings.Fields(comment), " ")
+		if inCode || content == "" || slideMetaPattern.MatchString(text[start:end]) {
+			continue
+		}
+
+		*found = true
+		b.WriteString(text[last:start])
+		b.WriteString(commentMark + "✎ " + strings.ReplaceAll(content, "<", "&lt;") + commentMark)
+		last = end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// styleComments dims comments revealed by revealComments in rendered
+// output, which may wrap over several lines, and takes out their marks.
+func styleComments(lines []string) []string {
+	inside := false
+	for i, line := range lines {
+		if !inside && !strings.Contains(line, commentMark) {
+			continue
+		}
+		var b strings.Builder
+		for j, part := range strings.Split(line, commentMark) {
+			if j > 0 {
+				inside = !inside
+			}
+			if inside && part != "" {
+				part = commentStyle.Render(ansi.Strip(part))
+			}
+			b.WriteString(part)
+		}
+		lines[i] = b.String()
+	}
+	return lines
+}
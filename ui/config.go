@@ -1,9 +1,19 @@
 package ui
 
+import "time"
+
 // Config contains TUI-specific configuration.
 type Config struct {
-	ShowAllFiles     bool
-	ShowLineNumbers  bool
+	ShowAllFiles bool
+
+	// Deprecated: use ShowLineNumbersCode and ShowLineNumbersProse, which
+	// this turns on both of.
+	ShowLineNumbers bool
+
+	// Whether to show line numbers for code files and for markdown
+	ShowLineNumbersCode  bool
+	ShowLineNumbersProse bool
+
 	Gopath           string `env:"GOPATH"`
 	HomeDir          string `env:"HOME"`
 	GlamourMaxWidth  uint
@@ -12,9 +22,218 @@ type Config struct {
 	PreserveNewLines bool
 	PresentationMode bool
 
+	// Render at GlamourMaxWidth even when the viewport is narrower, scrolling
+	// horizontally instead of clamping to the viewport width
+	OverflowWidth bool
+
+	// Join hard-wrapped lines within paragraphs so they're wrapped to the
+	// viewport width instead, the inverse of PreserveNewLines
+	ReflowHardWraps bool
+
+	// Number added to displayed line numbers of the document glow was
+	// launched with, e.g. when it's a fragment of a larger file
+	LineNumberOffset int
+
+	// Give each level of nested lists its own bullet and marker color
+	ListStyling bool
+
+	// Indent wrapped heading lines to align with the heading text
+	HeadingHangingIndent bool
+
+	// Briefly show the slide number in the status bar when changing slides
+	SlideTransitionFlash bool
+
+	// Horizontal alignment of slide content, "left" or "center"
+	SlideAlign string
+
+	// How to show the current slide in the status bar: "text" for
+	// [Slide 3/10] or "dots" for ○○●○○○○○○○. Decks with more slides than
+	// SlideIndicatorDotsMax always use text.
+	SlideIndicatorStyle   string
+	SlideIndicatorDotsMax int
+
+	// What to do with decks of a single slide: "show" them like any other,
+	// "hide" the slide indicator or show them as a "document" instead
+	SingleSlide string
+
+	// Show how far into the deck the current slide is in a bar above the
+	// status bar
+	SlideProgressBar bool
+
+	// Split slides too tall for the viewport into pages, which are shown
+	// one after the other before moving on to the next slide
+	PaginateSlides bool
+
+	// Move on to the next slide after this long, for unattended
+	// presentations, and back to the first after the last with SlideLoop.
+	// Disabled if zero.
+	SlideAutoAdvance time.Duration
+	SlideLoop        bool
+
+	// What slides are split at: "numbered-h1" headings starting with a
+	// number, any "h1" heading or "hr" horizontal rules (---)
+	SlideSeparator string
+
+	// Regular expression the text of numbered-h1 slide headings matches,
+	// by default ^[0-9] for those starting with a digit
+	SlideHeaderPattern string
+
+	// Where X saves the current slide as a PNG image: a file, with {n} for
+	// the slide number, or a directory. Next to the document if empty.
+	SlideImagePath string
+
+	// Width of slide images, in columns
+	SlideImageWidth int
+
+	// File or named pipe the current slide's markdown is written to as
+	// slides change, for showing on a second screen
+	SlideMirrorPath string
+
+	// Show images in terminals supporting the Kitty graphics protocol,
+	// rather than their text
+	InlineImages bool
+
+	// Lines of context around the current line that E copies along
+	SnippetContext int
+
+	// Where ctrl+e exports search matches to, or the clipboard if empty,
+	// and the number of lines of context around each
+	SearchExportPath    string
+	SearchExportContext int
+
+	// Address for remote control of the pager, either a localhost TCP
+	// address or a unix socket path prefixed with "unix:". Disabled if empty.
+	ControlSocket string
+
+	// Write copied text to a temporary file when the clipboard is likely
+	// unavailable, e.g. over SSH inside a terminal multiplexer
+	CopyFallbackFile bool
+
+	// Let the user know when rendering a document takes longer than this.
+	// Disabled if zero.
+	SlowRenderThreshold time.Duration
+
+	// Render markdown documents of at least this many bytes in parts,
+	// showing the top of the document while the rest renders. Disabled if
+	// zero.
+	StreamRenderThreshold int
+
+	// What esc does in the pager: "quit" quits, "back" returns to the file
+	// listing and "auto" quits only when launched with a single document.
+	QuitKeyBehavior string
+
+	// What to do with the room a short status bar note leaves: "left" to
+	// leave it empty, "center" to center the note or "breadcrumb" to show
+	// the headings of the section being read
+	StatusBarNote string
+
+	// Segments of the status bar, in order: "logo", "note", "percent",
+	// "help", "clock" and "filename". The note takes up the room the others
+	// leave.
+	StatusBarSegments []string
+
+	// Reading speed the reading time is estimated at, in words per minute
+	ReadingWPM int
+
+	// How wide emoji are drawn by the terminal: "narrow", "wide" or "auto"
+	// to go by their Unicode width. Only the status bar, help, prompts and
+	// the like are laid out by it, not the rendered markdown.
+	EmojiWidth string
+
+	// Lines of code files longer than this many characters are cut short
+	// until asked to render them in full, so that minified files don't
+	// bring the pager to a crawl. Disabled if zero.
+	MaxLineLength int
+
+	// Resolve relative links to absolute paths when copying them
+	ResolveRelativeLinks bool
+
+	// Number of recently opened documents to remember, for O to offer.
+	// Disabled if 0, though the document opened last is still remembered
+	// for --resume.
+	RecentDocuments int
+
+	// Pick up reading documents where they were left, keeping reading
+	// positions in the user's cache dir
+	RememberPosition bool
+
+	// Lines scrolled per mouse wheel step
+	MouseScrollLines int
+
+	// Columns h and l scroll sideways by. Shift with the arrow keys
+	// scrolls by half the viewport instead.
+	HorizontalScrollStep int
+
+	// How long changes to the document have to settle before it's
+	// reloaded, as editors can save a file in several writes. Changes are
+	// reloaded right away if zero.
+	ReloadDebounce time.Duration
+
+	// How often to check the document for changes when the file can't be
+	// watched, as can be the case on network filesystems. Disabled if zero.
+	PollInterval time.Duration
+
+	// Colors of inline code spans, as hex or ANSI 256 color codes, to set
+	// them apart from prose. Empty to use the style's colors.
+	InlineCodeForeground string
+	InlineCodeBackground string
+
+	// Chroma theme code is highlighted in, like "monokai", regardless of
+	// the glamour style. Empty to use the style's code colors.
+	CodeStyle string
+
+	// Open the table of contents once a markdown document with headings
+	// has loaded
+	OpenTOCOnLoad bool
+
+	// Show the title from a document's front matter as a heading atop it,
+	// unless the document already starts with that heading
+	FrontmatterTitle bool
+
+	// Style images alone on their line followed by an emphasized caption
+	// as numbered figures
+	FigureStyling bool
+
+	// Badge YAML, TOML and JSON code blocks with whether they parse
+	ValidateConfigBlocks bool
+
+	// Highlight fenced code blocks without a language label in the
+	// language detected from their contents, when it's clear enough
+	AutoDetectCodeLanguage bool
+
+	// Draw mermaid diagrams as text, by MermaidCommand if set, which reads
+	// a diagram on stdin and writes it out drawn, or else by glow itself,
+	// which draws simple flowcharts and sequence diagrams
+	RenderMermaid  bool
+	MermaidCommand string
+
+	// Extensions of code files to show without syntax highlighting
+	PlainCodeExtensions []string
+
+	// Padding around the pager, in cells, given like in CSS: for all sides,
+	// vertical and horizontal, top, horizontal and bottom, or top, right,
+	// bottom and left
+	ViewPadding []int
+
+	// Colors of the line numbers and status bar by glamour style, with
+	// "default" applying to every style
+	ChromeColors map[string]ChromeColors
+
+	// Keys of the pager's actions by action name, like "pageDown", in place
+	// of their defaults
+	KeyBindings map[string][]string
+
+	// Loads the configured glamour style as it is now, for R to pick up
+	// changes made to the configuration since starting
+	LoadStyle func() (string, error)
+
 	// Working directory or file path
 	Path string
 
+	// Anchor of the heading to open the document at, like "installation"
+	// for README.md#installation
+	Anchor string
+
 	// For debugging the UI
 	HighPerformancePager bool `env:"GLOW_HIGH_PERFORMANCE_PAGER" envDefault:"true"`
 	GlamourEnabled       bool `env:"GLOW_ENABLE_GLAMOUR"         envDefault:"true"`
@@ -0,0 +1,139 @@
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"go.yaml.in/yaml/v3"
+)
+
+// configBlock is a fenced code block of configuration, like YAML.
+type configBlock struct {
+	lang    string // as labeled: yaml, toml or json
+	err     error  // why the contents don't parse, nil if they do
+	first   string // first non-blank line, to find the block by
+	skipped int    // blank lines before it
+}
+
+// badge returns the validity indicator shown on the block, cut short to
+// fit the given width.
+func (b configBlock) badge(width int) string {
+	if b.err == nil {
+		return dimGreenFg(truncateWidth(b.lang+" ✓", width, ellipsis))
+	}
+	return redFg(truncateWidth(b.lang+" ✗ "+b.err.Error(), width, ellipsis))
+}
+
+// validateConfig parses configuration in the given language, returning
+// why it's invalid, if it is. Unknown languages are never invalid.
+func validateConfig(lang, code string) error {
+	switch lang {
+	case "yaml":
+		// A block can hold several documents
+		d := yaml.NewDecoder(strings.NewReader(code))
+		for {
+			var v any
+			err := d.Decode(&v)
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return errors.New(strings.TrimPrefix(err.Error(), "yaml: "))
+			}
+		}
+	case "toml":
+		var (
+			v    map[string]any
+			derr *toml.DecodeError
+		)
+		err := toml.Unmarshal([]byte(code), &v)
+		if errors.As(err, &derr) {
+			row, _ := derr.Position()
+			return fmt.Errorf("line %d: %s", row, strings.TrimPrefix(derr.Error(), "toml: "))
+		}
+		if err != nil {
+			return errors.New(strings.TrimPrefix(err.Error(), "toml: "))
+		}
+	case "json":
+		var v any
+		if err := json.Unmarshal([]byte(code), &v); err != nil {
+			return errors.New(strings.TrimPrefix(err.Error(), "json: "))
+		}
+	}
+	return nil
+}
+
+// configLanguage returns the configuration language a code block's info
+// string labels it with, if any.
+func configLanguage(info string) string {
+	fields := strings.Fields(info)
+	if len(fields) == 0 {
+		return ""
+	}
+	switch lang := strings.ToLower(fields[0]); lang {
+	case "yaml", "yml":
+		return "yaml"
+	case "toml", "json":
+		return lang
+	}
+	return ""
+}
+
+// parseConfigBlocks returns the YAML, TOML and JSON code blocks in the
+// given markdown, validated.
+func parseConfigBlocks(md string) []configBlock {
+	var blocks []configBlock
+	for _, c := range parseCodeBlocks(md) {
+		lang := configLanguage(c.info)
+		if !c.closed || lang == "" || c.first == "" {
+			continue
+		}
+		blocks = append(blocks, configBlock{
+			lang:    lang,
+			err:     validateConfig(lang, strings.Join(c.code, "\n")),
+			first:   c.first,
+			skipped: c.skipped,
+		})
+	}
+	return blocks
+}
+
+// markConfigBlocks badges YAML, TOML and JSON code blocks in rendered
+// output with whether they parse. The badge goes at the end of the block's
+// first line, in the padding to its right, so the code itself is left as
+// it is. Blocks without room for it go without.
+func markConfigBlocks(md string, lines []string) []string {
+	blocks := parseConfigBlocks(md)
+	if len(blocks) == 0 {
+		return lines
+	}
+
+	firsts := make([]string, len(blocks))
+	for i, b := range blocks {
+		firsts[i] = b.first
+	}
+	for i, start := range findTextLines(firsts, lines) {
+		if start < 0 {
+			continue
+		}
+		start -= blocks[i].skipped
+		if start < 0 {
+			continue
+		}
+		line := lines[start]
+		code := trimRightANSI(line)
+		width, used := stringWidth(line), stringWidth(code)
+
+		room := width - used - 2
+		if room < len(blocks[i].lang)+2 {
+			continue
+		}
+		badge := blocks[i].badge(room)
+		lines[start] = code + strings.Repeat(" ", width-used-stringWidth(badge)) + badge
+	}
+	return lines
+}
@@ -0,0 +1,163 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+)
+
+// Messages sent by the control server to drive the pager remotely.
+type (
+	nextSlideMsg struct{}
+	prevSlideMsg struct{}
+	gotoSlideMsg int // 0-based slide index
+)
+
+// listenForControl starts an HTTP server on the given address which lets
+// other programs, such as presentation remotes, control the pager. The
+// address is either a loopback TCP address like "localhost:7777" or, prefixed
+// with "unix:", the path of a unix socket.
+//
+// The server supports the following commands:
+//
+//	POST /next         go to the next slide
+//	POST /prev         go to the previous slide
+//	POST /goto/{slide} go to the given slide (1-based)
+//	POST /reload       reload the document
+//
+// It returns a function that stops the server and removes its socket, to be
+// called once the program is done. The terminal hanging up stops it too,
+// since unlike for interrupts, Bubble Tea doesn't quit for that itself.
+func listenForControl(addr string, p *tea.Program) (func(), error) {
+	l, err := controlListener(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	srv := &http.Server{
+		Handler:           controlHandler(p, l.Addr()),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := srv.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("control server stopped", "error", err)
+		}
+	}()
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	done := make(chan struct{})
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			signal.Stop(hangup)
+			close(done)
+			_ = srv.Close()
+			_ = l.Close()
+			if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+				_ = os.Remove(path)
+			}
+		})
+	}
+	go func() {
+		select {
+		case <-hangup:
+			stop()
+			p.Kill()
+		case <-done:
+		}
+	}()
+
+	log.Info("control server listening", "addr", l.Addr())
+	return stop, nil
+}
+
+// controlHandler handles the commands of the control server listening on the
+// given address. Web pages can reach loopback addresses too, so requests
+// from browsers, which carry an Origin header, are refused, and so are TCP
+// requests for any other host, as DNS rebinding would make them.
+func controlHandler(p *tea.Program, addr net.Addr) http.Handler {
+	send := func(msg tea.Msg) http.HandlerFunc {
+		return func(w http.ResponseWriter, _ *http.Request) {
+			p.Send(msg)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /next", send(nextSlideMsg{}))
+	mux.Handle("POST /prev", send(prevSlideMsg{}))
+	mux.Handle("POST /reload", send(reloadMsg{}))
+	mux.HandleFunc("POST /goto/{slide}", func(w http.ResponseWriter, r *http.Request) {
+		n, err := strconv.Atoi(r.PathValue("slide"))
+		if err != nil || n < 1 {
+			http.Error(w, "invalid slide number", http.StatusBadRequest)
+			return
+		}
+		p.Send(gotoSlideMsg(n - 1))
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	var hosts []string
+	if tcp, ok := addr.(*net.TCPAddr); ok {
+		port := strconv.Itoa(tcp.Port)
+		for _, host := range []string{"localhost", "127.0.0.1", "::1"} {
+			hosts = append(hosts, net.JoinHostPort(host, port))
+		}
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Origin") != "" || (hosts != nil && !slices.Contains(hosts, r.Host)) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// controlListener creates a listener for the control server, making sure it
+// can only be reached from the local machine.
+func controlListener(addr string) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		// Clean up a socket left behind by a previous run
+		if info, err := os.Stat(path); err == nil && info.Mode()&fs.ModeSocket != 0 {
+			_ = os.Remove(path)
+		}
+
+		l, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to listen on control socket: %w", err)
+		}
+		if err := os.Chmod(path, 0o600); err != nil {
+			_ = l.Close()
+			return nil, fmt.Errorf("unable to secure control socket: %w", err)
+		}
+		return l, nil
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid control address: %w", err)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return nil, fmt.Errorf("control address must be on localhost, got %q", host)
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("unable to listen on control address: %w", err)
+	}
+	return l, nil
+}
@@ -0,0 +1,94 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+)
+
+// renderDebugInfo keeps track of rendering performance for the debug
+// overlay.
+type renderDebugInfo struct {
+	renders int
+	last    time.Duration
+	slowest time.Duration
+	total   time.Duration
+}
+
+func (d *renderDebugInfo) record(duration time.Duration) {
+	d.renders++
+	d.last = duration
+	d.slowest = max(d.slowest, duration)
+	d.total += duration
+}
+
+// warnAboutSlowRender lets the user know, once per document, when rendering
+// took long enough to be noticeable, so that lag isn't mistaken for a hang.
+func (m *pagerModel) warnAboutSlowRender(duration time.Duration) tea.Cmd {
+	threshold := m.common.cfg.SlowRenderThreshold
+	if threshold <= 0 || duration < threshold || m.slowRenderHit {
+		return nil
+	}
+	m.slowRenderHit = true
+
+	log.Info("slow render", "duration", duration, "threshold", threshold)
+	return m.showStatusMessage(pagerStatusMessage{
+		message: fmt.Sprintf("Slow render (%s): large or complex document, a simpler style may help",
+			duration.Round(time.Millisecond)),
+	})
+}
+
+// toggleHighPerformance switches the viewport between high performance and
+// standard rendering, to help tell which one causes glitches on a terminal.
+func (m *pagerModel) toggleHighPerformance() tea.Cmd {
+	m.viewport.HighPerformanceRendering = !m.viewport.HighPerformanceRendering
+	log.Info("switched rendering mode", "mode", m.renderingMode())
+
+	// Standard rendering draws over whatever high performance rendering
+	// left in the scroll area, so clear it first
+	redraw := tea.ClearScrollArea //nolint:staticcheck
+	if m.viewport.HighPerformanceRendering {
+		redraw = viewport.Sync(m.viewport)
+	}
+	return tea.Batch(redraw, m.showStatusMessage(pagerStatusMessage{
+		message: "Switched to " + m.renderingMode() + " rendering",
+	}))
+}
+
+// renderingMode describes how the viewport is rendered.
+func (m pagerModel) renderingMode() string {
+	if m.viewport.HighPerformanceRendering {
+		return "high performance"
+	}
+	return "standard"
+}
+
+func (m pagerModel) debugView() string {
+	var average time.Duration
+	if m.debug.renders > 0 {
+		average = m.debug.total / time.Duration(m.debug.renders)
+	}
+
+	rows := [][2]string{
+		{"Renders", fmt.Sprint(m.debug.renders)},
+		{"Last render", m.debug.last.Round(time.Microsecond).String()},
+		{"Average render", average.Round(time.Microsecond).String()},
+		{"Slowest render", m.debug.slowest.Round(time.Microsecond).String()},
+		{"Rendered lines", fmt.Sprint(m.viewport.TotalLineCount())},
+		{"Viewport", fmt.Sprintf("%dx%d", m.viewport.Width, m.viewport.Height)},
+		{"Offset", fmt.Sprintf("%d, %d", m.viewport.YOffset, m.xOffset)},
+		{"Rendering", m.renderingMode()},
+	}
+
+	var b strings.Builder
+	b.WriteString(fuchsiaFg("Debug") + "\n")
+	for _, r := range rows {
+		fmt.Fprintf(&b, "\n%s %s", grayFg(fmt.Sprintf("%-16s", r[0])), r[1])
+	}
+
+	return statsViewStyle.Render(b.String())
+}
@@ -0,0 +1,174 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+// Markers shown in front of the summary of collapsible sections.
+const (
+	detailsCollapsedMarker = "▶"
+	detailsExpandedMarker  = "▼"
+)
+
+var (
+	detailsOpenPattern    = regexp.MustCompile(`(?i)^<details(\s[^>]*)?>`)
+	detailsOpenAttr       = regexp.MustCompile(`(?i)\sopen(\s|=|>|$)`)
+	detailsClosePattern   = regexp.MustCompile(`(?i)</details>\s*$`)
+	detailsSummaryPattern = regexp.MustCompile(`(?i)<summary>(.*?)</summary>`)
+)
+
+// detailsSection is a collapsible section that's visible in the transformed
+// markdown.
+type detailsSection struct {
+	id      int    // index of the <details> tag in the document, counting hidden ones
+	summary string // summary text, without the marker
+	open    bool
+}
+
+// collapseDetails rewrites GitHub-style <details> sections in the given
+// markdown. Collapsed sections are reduced to their summary, while expanded
+// ones show their summary followed by their contents. Sections are expanded
+// if their id is set in expanded or, failing that, if they carry the open
+// attribute.
+//
+// It returns the rewritten markdown along with the sections left visible, in
+// document order. Sections nested in collapsed ones are hidden, but still
+// numbered, so ids don't change when their parents are toggled.
+func collapseDetails(md string, expanded map[int]bool) (string, []detailsSection) {
+	if !strings.Contains(md, "<details") {
+		return md, nil
+	}
+
+	var (
+		out      []string
+		visible  []detailsSection
+		inFence  bool
+		depth    int
+		hiddenAt int // depth of the collapsed section we're in, 0 if none
+		next     int
+		pending  = -1 // index of a marker line still waiting for its summary
+	)
+
+	for _, line := range strings.Split(md, "\n") {
+		trimmed := strings.TrimSpace(line)
+		isFence := strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")
+		if isFence {
+			inFence = !inFence
+		}
+		if isFence || inFence {
+			if hiddenAt == 0 {
+				out = append(out, line)
+			}
+			continue
+		}
+
+		if open := detailsOpenPattern.FindString(trimmed); open != "" {
+			id := next
+			next++
+			depth++
+			if hiddenAt > 0 {
+				continue
+			}
+
+			isOpen, ok := expanded[id]
+			if !ok {
+				isOpen = detailsOpenAttr.MatchString(open)
+			}
+
+			summary := "Details"
+			pending = len(out) + 1
+			if m := detailsSummaryPattern.FindStringSubmatch(trimmed); m != nil {
+				summary = strings.TrimSpace(m[1])
+				pending = -1
+			}
+			visible = append(visible, detailsSection{id: id, summary: summary, open: isOpen})
+
+			marker := detailsCollapsedMarker
+			if isOpen {
+				marker = detailsExpandedMarker
+			} else {
+				hiddenAt = depth
+			}
+			out = append(out, "", marker+" "+summary, "")
+
+			// Sections can fit on a single line
+			if detailsClosePattern.MatchString(trimmed) {
+				if hiddenAt == depth {
+					hiddenAt = 0
+				}
+				depth--
+				pending = -1
+			}
+			continue
+		}
+
+		if detailsClosePattern.MatchString(trimmed) && depth > 0 {
+			if hiddenAt == depth {
+				hiddenAt = 0
+			}
+			depth--
+			if hiddenAt == 0 {
+				out = append(out, "")
+			}
+			continue
+		}
+
+		// The summary usually follows on its own line
+		if pending >= 0 && trimmed != "" {
+			if m := detailsSummaryPattern.FindStringSubmatch(trimmed); m != nil {
+				visible[len(visible)-1].summary = strings.TrimSpace(m[1])
+				out[pending] = strings.SplitN(out[pending], " ", 2)[0] + " " + visible[len(visible)-1].summary
+				pending = -1
+				continue
+			}
+			pending = -1
+		}
+
+		if hiddenAt > 0 {
+			continue
+		}
+
+		out = append(out, line)
+	}
+
+	return strings.Join(out, "\n"), visible
+}
+
+// toggleDetails expands or collapses the first <details> section whose
+// summary is on screen.
+func (m *pagerModel) toggleDetails() tea.Cmd {
+	if !utils.IsMarkdownFile(m.currentDocument.Note) {
+		return nil
+	}
+
+	md := m.currentMarkdown()
+	_, sections := collapseDetails(foldSections(md, m.folded), m.detailsExpanded)
+	if len(sections) == 0 {
+		return nil
+	}
+
+	markers := make([]string, len(sections))
+	for i, s := range sections {
+		markers[i] = s.summary
+	}
+	lines := findTextLines(markers, m.renderedLines())
+
+	top, bottom := m.viewport.YOffset, m.viewport.YOffset+m.viewport.Height
+	for i, line := range lines {
+		if line < top || line >= bottom {
+			continue
+		}
+
+		if m.detailsExpanded == nil {
+			m.detailsExpanded = make(map[int]bool)
+		}
+		m.detailsExpanded[sections[i].id] = !sections[i].open
+		return renderWithGlamour(*m, md)
+	}
+
+	return nil
+}
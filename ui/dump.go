@@ -0,0 +1,29 @@
+package ui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// RenderedDocument returns the document last rendered by the program's
+// pager, at the width and in the style it was last shown with, but without
+// the status bar and the rest around it. It ends by resetting the style, so
+// that the terminal isn't left colored. It returns "" if no document was
+// opened. The pager's own additions, the line number gutter and the
+// placeholders of inline images, are left out.
+func RenderedDocument(m tea.Model) string {
+	mm, ok := m.(model)
+	if !ok || mm.pager.renderedContent == "" {
+		return ""
+	}
+	lines := mm.pager.renderedLines()
+	doc := make([]string, 0, len(lines))
+	for _, l := range lines {
+		if !strings.Contains(l, kittyPlaceholder) {
+			doc = append(doc, l)
+		}
+	}
+	return strings.TrimRight(strings.Join(doc, "\n"), "\n") + ansi.ResetStyle + "\n"
+}
@@ -1,19 +1,114 @@
 package ui
 
 import (
+	"fmt"
+	"strings"
+	"unicode"
+
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/charmbracelet/x/editor"
 )
 
 type editorFinishedMsg struct{ err error }
 
-func openEditor(path string, lineno int) tea.Cmd {
+// openEditor opens the file in $EDITOR at the given line and column, both
+// 1-based.
+func openEditor(path string, line, col int) tea.Cmd {
 	cb := func(err error) tea.Msg {
 		return editorFinishedMsg{err}
 	}
-	cmd, err := editor.Cmd("Glow", path, editor.LineNumber(uint(lineno))) //nolint:gosec
+	cmd, err := editor.Cmd("Glow", path, lineColumn(line, col))
 	if err != nil {
 		return func() tea.Msg { return cb(err) }
 	}
 	return tea.ExecProcess(cmd, cb)
 }
+
+// lineColumn opens the file at the given line and column in editors that
+// support it. Editors that only support lines ignore the column.
+func lineColumn(line, col int) editor.Option {
+	lineOnly := editor.LineNumber(uint(line)) //nolint:gosec
+	return func(name, path string) ([]string, bool) {
+		switch name {
+		case "vim", "nvim":
+			return []string{fmt.Sprintf("+call cursor(%d, %d)", line, col)}, false
+		case "nano":
+			return []string{fmt.Sprintf("+%d,%d", line, col)}, false
+		case "emacs", "kak", "gedit", "micro":
+			return []string{fmt.Sprintf("+%d:%d", line, col)}, false
+		case "code", "codium", "cursor":
+			return []string{"--goto", fmt.Sprintf("%s:%d:%d", path, line, col)}, true
+		case "hx", "helix", "subl":
+			return []string{fmt.Sprintf("%s:%d:%d", path, line, col)}, true
+		}
+		return lineOnly(name, path)
+	}
+}
+
+// sourcePosition returns the line and column of the document's file, both
+// 1-based, that's being read: the start of the text at the top of the
+// viewport or, if it's in view, the current search match.
+func (m pagerModel) sourcePosition() (line, col int) {
+	md := m.currentMarkdown()
+	rendered := m.renderedLines()
+	if len(rendered) == 0 {
+		return 1, 1
+	}
+
+	sources := m.renderedLineMap(md, rendered)
+
+	top := min(m.viewport.YOffset, len(sources)-1)
+	mdLine := sources[top]
+	mdLines := strings.Split(md, "\n")
+
+	// The search match is more precise, when there is one to go by
+	if m.searchActive() && m.searchIndex >= 0 && m.searchIndex < len(m.searchMatches) {
+		match := m.searchMatches[m.searchIndex]
+		if match.line >= m.viewport.YOffset && match.line < m.viewport.YOffset+m.viewport.Height && match.source <= len(md) {
+			lineStart := strings.LastIndex(md[:match.source], "\n") + 1
+			return fileLine(m.currentDocument.source, md, match.sourceLine), ansi.StringWidth(md[lineStart:match.source]) + 1
+		}
+	}
+
+	col = 1
+	if mdLine < len(mdLines) {
+		col += ansi.StringWidth(mdLines[mdLine]) - ansi.StringWidth(strings.TrimLeftFunc(mdLines[mdLine], unicode.IsSpace))
+	}
+	return fileLine(m.currentDocument.source, md, mdLine), col
+}
+
+// codeLineMap maps each rendered line of a code file to the 0-based line of
+// the code it was rendered from.
+func codeLineMap(code string, rendered []string) []int {
+	lines := strings.Split(code, "\n")
+	for i, l := range lines {
+		if l = strings.TrimSpace(l); len(l) >= minSourceText {
+			lines[i] = l
+		} else {
+			lines[i] = ""
+		}
+	}
+	return fillLineMap(findTextLines(lines, rendered), len(rendered))
+}
+
+// fileLine returns the 1-based line of the source file that the given
+// 0-based line of markdown is. The markdown can be a part of the source,
+// like a slide, or the source without its front matter.
+func fileLine(source, md string, line int) int {
+	if i := strings.Index(source, md); i >= 0 {
+		return strings.Count(source[:i], "\n") + line + 1
+	}
+
+	// The markdown was changed, e.g. by adding a title, so go by the line
+	// itself
+	lines := strings.Split(md, "\n")
+	if line < len(lines) && strings.TrimSpace(lines[line]) != "" {
+		for i, l := range strings.Split(source, "\n") {
+			if l == lines[line] {
+				return i + 1
+			}
+		}
+	}
+	return line + 1
+}
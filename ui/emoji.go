@@ -0,0 +1,123 @@
+package ui
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Values of Config.EmojiWidth.
+const (
+	emojiWidthAuto   = "auto"
+	emojiWidthNarrow = "narrow"
+	emojiWidthWide   = "wide"
+)
+
+// emojiWidth is the number of cells emoji are taken to occupy, or 0 to use
+// their Unicode width.
+var emojiWidth int
+
+// Ranges of pictographs, which are always drawn as emoji by terminals that
+// support them.
+var emojiRanges = []struct{ first, last rune }{
+	{0x1F000, 0x1F2FF}, // game pieces and enclosed characters, including flags
+	{0x1F300, 0x1FAFF}, // pictographs, emoticons and symbols
+}
+
+// setEmojiWidth configures how wide emoji are when measuring text with
+// stringWidth and truncateWidth, which lay out what the pager draws itself,
+// like the status bar and help. Markdown is wrapped by glamour, which goes
+// by the Unicode width of emoji regardless.
+func setEmojiWidth(setting string) {
+	switch setting {
+	case emojiWidthNarrow:
+		emojiWidth = 1
+	case emojiWidthWide:
+		emojiWidth = 2
+	default:
+		emojiWidth = 0
+	}
+}
+
+// isEmoji returns whether a grapheme cluster of the given Unicode width is
+// an emoji. Symbols such as ✔ are only emoji if they're wide, or asked to be
+// shown as emoji with a variation selector.
+func isEmoji(cluster string, width int) bool {
+	if strings.ContainsAny(cluster, "\u200d\ufe0f") {
+		return true // joined sequences and emoji presentation
+	}
+	r, _ := utf8.DecodeRuneInString(cluster)
+	if r >= 0x2600 && r <= 0x2BFF {
+		return width == 2 // symbols, dingbats and shapes such as ⭐
+	}
+	for _, rng := range emojiRanges {
+		if r >= rng.first && r <= rng.last {
+			return true
+		}
+	}
+	return false
+}
+
+// graphemeWidth returns the width of a grapheme cluster, given its Unicode
+// width.
+func graphemeWidth(cluster string, width int) int {
+	if emojiWidth == 0 || width == 0 || !isEmoji(cluster, width) {
+		return width
+	}
+	return emojiWidth
+}
+
+// stringWidth returns the printable width of s, ignoring ANSI sequences and
+// taking the EmojiWidth setting into account.
+func stringWidth(s string) int {
+	var (
+		width int
+		state byte
+	)
+	for len(s) > 0 {
+		seq, w, n, newState := ansi.DecodeSequence(s, state, nil)
+		width += graphemeWidth(seq, w)
+		state = newState
+		s = s[n:]
+	}
+	return width
+}
+
+// truncateWidth truncates s to the given printable width, measured like
+// stringWidth, adding tail if anything was cut off. ANSI sequences are kept.
+func truncateWidth(s string, width int, tail string) string {
+	if stringWidth(s) <= width {
+		return s
+	}
+	width -= stringWidth(tail)
+
+	var (
+		b     strings.Builder
+		state byte
+		cur   int
+		cut   bool
+	)
+	for len(s) > 0 {
+		seq, w, n, newState := ansi.DecodeSequence(s, state, nil)
+		state = newState
+		s = s[n:]
+
+		if w == 0 {
+			b.WriteString(seq) // escape sequences and zero-width characters
+			continue
+		}
+		if cut {
+			continue
+		}
+		w = graphemeWidth(seq, w)
+		if cur+w > width {
+			b.WriteString(tail)
+			cut = true
+			continue
+		}
+		b.WriteString(seq)
+		cur += w
+	}
+	return b.String()
+}
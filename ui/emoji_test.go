@@ -0,0 +1,110 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEmojiWidth(t *testing.T) {
+	tests := []struct {
+		s                  string
+		auto, narrow, wide int
+	}{
+		{s: "plain text", auto: 10, narrow: 10, wide: 10},
+		{s: "😀", auto: 2, narrow: 1, wide: 2},
+		{s: "❤️", auto: 2, narrow: 1, wide: 2},
+		{s: "✔ done", auto: 6, narrow: 6, wide: 6},
+		{s: "⭐ star", auto: 7, narrow: 6, wide: 7},
+		{s: "family 👨‍👩‍👧", auto: 9, narrow: 8, wide: 9},
+		{s: "\x1b[1m🎉 party\x1b[0m", auto: 8, narrow: 7, wide: 8},
+	}
+
+	for _, setting := range []string{emojiWidthAuto, emojiWidthNarrow, emojiWidthWide} {
+		t.Run(setting, func(t *testing.T) {
+			setEmojiWidth(setting)
+			t.Cleanup(func() { setEmojiWidth(emojiWidthAuto) })
+
+			for _, tt := range tests {
+				want := map[string]int{
+					emojiWidthAuto:   tt.auto,
+					emojiWidthNarrow: tt.narrow,
+					emojiWidthWide:   tt.wide,
+				}[setting]
+				if got := stringWidth(tt.s); got != want {
+					t.Errorf("stringWidth(%q) = %d, want %d", tt.s, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTruncateWidthWithEmoji(t *testing.T) {
+	tests := []struct {
+		setting string
+		want    string
+	}{
+		{setting: emojiWidthAuto, want: "a😀b😀…"},
+		{setting: emojiWidthNarrow, want: "a😀b😀c😀…"},
+		{setting: emojiWidthWide, want: "a😀b😀…"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.setting, func(t *testing.T) {
+			setEmojiWidth(tt.setting)
+			t.Cleanup(func() { setEmojiWidth(emojiWidthAuto) })
+
+			if got := truncateWidth("a😀b😀c😀d😀", 7, "…"); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestStatusBarWidthWithEmoji(t *testing.T) {
+	for _, setting := range []string{emojiWidthAuto, emojiWidthNarrow, emojiWidthWide} {
+		t.Run(setting, func(t *testing.T) {
+			m := newModel(Config{EmojiWidth: setting}, "# 🎉 Party\n").(model)
+			t.Cleanup(func() { setEmojiWidth(emojiWidthAuto) })
+			m.pager.setSize(40, 10)
+			m.common.width = 40
+
+			for _, note := range []string{"🎉 party.md", strings.Repeat("❤️", 40)} {
+				m.pager.currentDocument.Note = note
+				var b strings.Builder
+				m.pager.statusBarView(&b)
+				if got := stringWidth(b.String()); got != 40 {
+					t.Errorf("expected status bar for %q to fill 40 cells, got %d", note, got)
+				}
+			}
+		})
+	}
+}
+
+func TestEmojiWidthLeavesMarkdownAlone(t *testing.T) {
+	const md = "# 🎉 Party\n\nWe're 😀 about it, ❤️ and all. " + "Lorem ipsum dolor sit amet. "
+
+	cfg := Config{GlamourEnabled: true, GlamourStyle: "dark", GlamourMaxWidth: 30}
+	config = cfg
+	t.Cleanup(func() { config = Config{} })
+
+	var want string
+	for _, setting := range []string{emojiWidthAuto, emojiWidthNarrow, emojiWidthWide} {
+		t.Run(setting, func(t *testing.T) {
+			setEmojiWidth(setting)
+			t.Cleanup(func() { setEmojiWidth(emojiWidthAuto) })
+
+			m := newPagerModel(&commonModel{cfg: cfg})
+			m.setSize(40, 10)
+			m.currentDocument.Note = "party.md"
+			got, err := glamourRender(m, md)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want == "" {
+				want = got
+			} else if got != want {
+				t.Errorf("expected the markdown to be wrapped the same regardless, got\n%s", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package ui
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/charmbracelet/log"
+)
+
+// Lowest score, out of 1, at which a language detected by chroma's
+// analysers is trusted.
+const minLanguageConfidence = 0.5
+
+var (
+	fenceOpenPattern = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})\\s*(.*)$")
+	shebangPattern   = regexp.MustCompile(`^#!\s*(?:\S*/)?([\w.-]+)(?:\s+(?:-\S+\s+)*([\w.-]+))?`)
+)
+
+// detectCodeLanguage returns the language the given code is written in, or
+// an empty string if it's not clear enough.
+func detectCodeLanguage(code string) string {
+	trimmed := strings.TrimSpace(code)
+
+	// Scripts say what they're written in
+	if m := shebangPattern.FindStringSubmatch(trimmed); m != nil {
+		interpreter := m[1]
+		if interpreter == "env" {
+			interpreter = m[2]
+		}
+		// Versions like python3.12 would otherwise be taken for extensions
+		interpreter = strings.TrimRight(interpreter, "0123456789.")
+		if lexer := lexers.Get(interpreter); interpreter != "" && lexer != nil {
+			return lexerLanguage(lexer)
+		}
+	}
+
+	// Chroma can't tell JSON apart from other languages
+	if (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) && json.Valid([]byte(trimmed)) {
+		return "json"
+	}
+
+	var (
+		picked  chroma.Lexer
+		highest float32
+	)
+	for _, lexer := range lexers.GlobalLexerRegistry.Lexers {
+		if analyser, ok := lexer.(chroma.Analyser); ok {
+			if score := analyser.AnalyseText(code); score > highest {
+				picked, highest = lexer, score
+			}
+		}
+	}
+	if picked == nil || highest < minLanguageConfidence {
+		return ""
+	}
+
+	return lexerLanguage(picked)
+}
+
+// lexerLanguage returns the name to label code fences highlighted by the
+// given lexer with.
+func lexerLanguage(lexer chroma.Lexer) string {
+	config := lexer.Config()
+	if len(config.Aliases) > 0 {
+		return config.Aliases[0]
+	}
+	return strings.ToLower(config.Name)
+}
+
+// labelCodeFences labels fenced code blocks that don't say what language
+// they're in with the one detected from their contents, if any, so that
+// they're highlighted. Explicit labels are left alone. An unclosed fence
+// runs to the end of the document.
+func labelCodeFences(md string) string {
+	lines := strings.Split(md, "\n")
+
+	for i := 0; i < len(lines); i++ {
+		m := fenceOpenPattern.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+		fence := m[2]
+		end := closingFence(lines, i, fence)
+		if end < 0 {
+			end = len(lines)
+		}
+
+		if m[3] == "" {
+			code := strings.Join(lines[i+1:end], "\n")
+			if lang := detectCodeLanguage(code); lang != "" {
+				log.Debug("detected code language", "line", i+1, "language", lang)
+				lines[i] = m[1] + fence + lang
+			}
+		}
+		i = end
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,195 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+var (
+	imagePattern       = regexp.MustCompile(`!\[([^\]]*)\]\(\s*<?([^)\s>]*)>?(?:\s+["'(][^)]*)?\)`)
+	imageLinePattern   = regexp.MustCompile(`^\s*` + imagePattern.String() + `\s*$`)
+	captionLinePattern = regexp.MustCompile(`^\s*(?:\*([^*\s][^*]*)\*|_([^_\s][^_]*)_)\s*$`)
+
+	figureCaptionStyle = lipgloss.NewStyle().Foreground(brightGray).Italic(true)
+)
+
+// image is an image in a markdown document. Images alone on their line,
+// followed by an emphasized line of text, are figures with a caption.
+type image struct {
+	alt     string
+	src     string
+	caption string // plain text of the caption, if it's a figure
+	line    int    // 0-based line in the source document
+}
+
+// text returns the text glamour renders for the image.
+func (img image) text() string {
+	if img.alt != "" {
+		return img.alt
+	}
+	return img.src
+}
+
+// parseImages returns the images in the given markdown, in document order,
+// skipping anything inside fenced code blocks.
+func parseImages(md string) []image {
+	var (
+		images  []image
+		inFence bool
+	)
+
+	lines := strings.Split(md, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		for _, m := range imagePattern.FindAllStringSubmatch(line, -1) {
+			images = append(images, image{alt: m[1], src: m[2], line: i})
+		}
+		if !imageLinePattern.MatchString(line) {
+			continue
+		}
+
+		// The caption goes right below the image, or after a blank line
+		for j := i + 1; j < min(i+3, len(lines)); j++ {
+			if m := captionLinePattern.FindStringSubmatch(lines[j]); m != nil {
+				caption := strings.Join(strings.Fields(inlineMarkupReplacer.Replace(m[1]+m[2])), " ")
+				images[len(images)-1].caption = caption
+				break
+			}
+			if strings.TrimSpace(lines[j]) != "" {
+				break
+			}
+		}
+	}
+
+	return images
+}
+
+// separateFigureCaptions puts the captions of figures in paragraphs of their
+// own, rather than on the same line as their image, so that they can be
+// styled as such once rendered.
+func separateFigureCaptions(md string) string {
+	lines := strings.Split(md, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if i+1 < len(lines) && imageLinePattern.MatchString(lines[i]) && captionLinePattern.MatchString(lines[i+1]) {
+			lines = append(lines[:i+1], append([]string{""}, lines[i+1:]...)...)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// styleFigures styles the captions of the figures in the given markdown in
+// rendered output, numbering them after the given number of figures before
+// it and tucking them right under their image. Captions are wrapped to the
+// given width, if any. It returns the number of figures numbered, too.
+func styleFigures(md string, lines []string, width, first int) ([]string, int) {
+	var (
+		figures []image
+		texts   []string
+	)
+	for _, img := range parseImages(md) {
+		if img.caption != "" {
+			figures = append(figures, img)
+			texts = append(texts, img.text(), img.caption)
+		}
+	}
+	offsets := findTextLines(texts, lines)
+
+	// Work backwards so that changing line counts don't invalidate the
+	// offsets we have yet to process.
+	for i := len(figures) - 1; i >= 0; i-- {
+		imageLine, start := offsets[2*i], offsets[2*i+1]
+		if imageLine < 0 || start < 0 {
+			continue
+		}
+
+		// Gather the lines the caption was wrapped onto
+		plain := ansi.Strip(lines[start])
+		margin := len(plain) - len(strings.TrimLeft(plain, " "))
+		end := start + 1
+		for end < len(lines) && strings.TrimSpace(ansi.Strip(lines[end])) != "" {
+			end++
+		}
+
+		caption := fmt.Sprintf("Figure %d: %s", first+i+1, figures[i].caption)
+		if width > margin {
+			caption = ansi.Wordwrap(caption, width-margin, "")
+		}
+		styled := strings.Split(caption, "\n")
+		for j, l := range styled {
+			styled[j] = strings.Repeat(" ", margin) + figureCaptionStyle.Render(l)
+		}
+
+		// Drop the blank lines between the image and its caption
+		top := start
+		for top > imageLine+1 && strings.TrimSpace(ansi.Strip(lines[top-1])) == "" {
+			top--
+		}
+		lines = append(lines[:top], append(styled, lines[end:]...)...)
+	}
+
+	return lines, len(figures)
+}
+
+// nextImage scrolls to the next image, figures included, below the top of
+// the viewport or, going backward, the previous one above it.
+func (m *pagerModel) nextImage(backward bool) tea.Cmd {
+	images := parseImages(m.currentMarkdown())
+	if len(images) == 0 {
+		return m.showStatusMessage(pagerStatusMessage{"No images", true})
+	}
+	texts := make([]string, len(images))
+	for i, img := range images {
+		texts[i] = img.text()
+	}
+	offsets := findTextLines(texts, m.renderedLines())
+
+	target := -1
+	for i, line := range offsets {
+		if line < 0 {
+			continue
+		}
+		if backward && line < m.viewport.YOffset {
+			target = i
+		}
+		if !backward && line > m.viewport.YOffset {
+			target = i
+			break
+		}
+	}
+	if target < 0 || !backward && m.viewport.AtBottom() {
+		message := "Last image"
+		if backward {
+			message = "First image"
+		}
+		return m.showStatusMessage(pagerStatusMessage{message: message})
+	}
+
+	var figure int
+	for _, img := range images[:target+1] {
+		if img.caption != "" {
+			figure++
+		}
+	}
+	message := fmt.Sprintf("Image %d/%d: %s", target+1, len(images), images[target].text())
+	if img := images[target]; img.caption != "" {
+		message = fmt.Sprintf("Figure %d: %s", figure, img.caption)
+	}
+
+	return tea.Batch(
+		m.scrollTo(offsets[target]),
+		m.showStatusMessage(pagerStatusMessage{message: message}),
+	)
+}
@@ -0,0 +1,140 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+// Marker shown in front of the headings of folded sections.
+const foldedMarker = "▸"
+
+// collapseSections folds the sections and collapses the <details> sections of
+// the given markdown, as the pager shows them. Both are keyed by where they
+// are in the whole document.
+func (m pagerModel) collapseSections(md string) string {
+	md, _ = collapseDetails(foldSections(md, m.folded), m.detailsExpanded)
+	return md
+}
+
+// foldSections hides the contents of the sections under the headings on
+// the given source lines, down to the next heading of the same or a higher
+// level, replacing them with a note of how many lines are hidden. Folds
+// inside folded sections are hidden along with them.
+func foldSections(md string, folded map[int]bool) string {
+	if len(folded) == 0 {
+		return md
+	}
+
+	var (
+		lines    = strings.Split(md, "\n")
+		headings = parseHeadings(md)
+		out      = make([]string, 0, len(lines))
+		next     int // first line not yet copied
+	)
+	for i, h := range headings {
+		if !folded[h.line] || h.line < next {
+			continue
+		}
+		end := len(lines)
+		for _, after := range headings[i+1:] {
+			if after.level <= h.level {
+				end = after.line
+				break
+			}
+		}
+
+		// The contents start below the heading, which Setext headings
+		// underline, and blank lines around them don't count
+		start := h.line + 1
+		if !atxHeadingPattern.MatchString(lines[h.line]) {
+			start++
+		}
+		for start < end && strings.TrimSpace(lines[start]) == "" {
+			start++
+		}
+		hidden := 0
+		for j := start; j < end; j++ {
+			if strings.TrimSpace(lines[j]) != "" {
+				hidden = j - start + 1
+			}
+		}
+
+		out = append(out, lines[next:h.line]...)
+		out = append(out,
+			fmt.Sprintf("%s %s %s (%d lines hidden)", strings.Repeat("#", h.level), foldedMarker, h.text, hidden),
+			"",
+		)
+		next = end
+	}
+	out = append(out, lines[next:]...)
+	return strings.Join(out, "\n")
+}
+
+// foldTarget returns the heading to fold or unfold, along with the rendered
+// line it's on: the first heading in view or, if there's none, the heading
+// of the section being read.
+func (m pagerModel) foldTarget(headings []heading) (heading, int, bool) {
+	var (
+		target heading
+		at     = -1
+	)
+	for i, line := range findHeadingLines(headings, m.renderedLines()) {
+		if line < 0 {
+			continue
+		}
+		if line >= m.viewport.YOffset+m.viewport.Height {
+			break
+		}
+		target, at = headings[i], line
+		if line >= m.viewport.YOffset {
+			break
+		}
+	}
+	return target, at, at >= 0
+}
+
+// toggleFold folds or unfolds the section under the heading in view.
+// Slides are left alone.
+func (m *pagerModel) toggleFold() tea.Cmd {
+	if m.slideMode || !utils.IsMarkdownFile(m.currentDocument.Note) {
+		return nil
+	}
+	h, line, ok := m.foldTarget(parseHeadings(m.currentMarkdown()))
+	if !ok {
+		return m.showStatusMessage(pagerStatusMessage{"No heading to fold", true})
+	}
+
+	if m.folded == nil {
+		m.folded = map[int]bool{}
+	}
+	if m.folded[h.line] {
+		delete(m.folded, h.line)
+	} else {
+		m.folded[h.line] = true
+	}
+	// Folding the section being read leaves its heading on top
+	if line < m.viewport.YOffset {
+		m.pendingYOffset = &line
+	}
+	return renderWithGlamour(*m, m.currentMarkdown())
+}
+
+// foldAll folds every section or, with unfold, none.
+func (m *pagerModel) foldAll(unfold bool) tea.Cmd {
+	if m.slideMode || !utils.IsMarkdownFile(m.currentDocument.Note) {
+		return nil
+	}
+	m.folded = nil
+	if !unfold {
+		m.folded = map[int]bool{}
+		for _, h := range parseHeadings(m.currentMarkdown()) {
+			m.folded[h.line] = true
+		}
+	}
+	offset := 0
+	m.pendingYOffset = &offset
+	return renderWithGlamour(*m, m.currentMarkdown())
+}
@@ -0,0 +1,89 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/x/ansi"
+)
+
+var (
+	// footnotePattern matches footnote references like [^1] and, followed
+	// by a colon, the start of their definitions.
+	footnotePattern = regexp.MustCompile(`\[\^([^\]\s]+)\](:)?`)
+
+	footnoteDefinitionPattern = regexp.MustCompile(`^ {0,3}\[\^([^\]\s]+)\]:`)
+)
+
+// footnoteJump is a jump from a footnote reference to its definition, for
+// going back to the reference.
+type footnoteJump struct {
+	from, to int // rendered lines, scrolled to the top
+}
+
+// parseFootnoteDefinitions returns the labels of the footnotes defined in
+// the given markdown. Definitions in code blocks don't count.
+func parseFootnoteDefinitions(md string) map[string]bool {
+	defs := map[string]bool{}
+	lines := strings.Split(md, "\n")
+	for i := 0; i < len(lines); i++ {
+		if m := fenceOpenPattern.FindStringSubmatch(lines[i]); m != nil {
+			if end := closingFence(lines, i, m[2]); end >= 0 {
+				i = end
+				continue
+			}
+			break
+		}
+		if m := footnoteDefinitionPattern.FindStringSubmatch(lines[i]); m != nil {
+			defs[m[1]] = true
+		}
+	}
+	return defs
+}
+
+// footnoteLine returns the rendered line the footnote with the given label
+// is defined on.
+func footnoteLine(lines []string, label string) (int, bool) {
+	marker := "[^" + label + "]:"
+	for i, line := range lines {
+		if strings.Contains(ansi.Strip(line), marker) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// toggleFootnote jumps from the first footnote reference in view to its
+// definition or, having just jumped to one, back to where the reference
+// was read. Jumps from one footnote to another stack up.
+func (m *pagerModel) toggleFootnote() tea.Cmd {
+	if !utils.IsMarkdownFile(m.currentDocument.Note) {
+		return nil
+	}
+	if n := len(m.footnoteJumps); n > 0 && m.footnoteJumps[n-1].to == m.viewport.YOffset {
+		from := m.footnoteJumps[n-1].from
+		m.footnoteJumps = m.footnoteJumps[:n-1]
+		return m.scrollTo(from)
+	}
+
+	lines := m.renderedLines()
+	defs := parseFootnoteDefinitions(m.currentMarkdown())
+	for i := m.viewport.YOffset; i < min(len(lines), m.viewport.YOffset+m.viewport.Height); i++ {
+		for _, ref := range footnotePattern.FindAllStringSubmatch(ansi.Strip(lines[i]), -1) {
+			if ref[2] != "" {
+				continue // a definition
+			}
+			line, ok := footnoteLine(lines, ref[1])
+			if !defs[ref[1]] || !ok {
+				return m.showStatusMessage(pagerStatusMessage{"No definition for footnote " + ref[0], true})
+			}
+			from := m.viewport.YOffset
+			cmd := m.jumpTo(line)
+			m.footnoteJumps = append(m.footnoteJumps, footnoteJump{from: from, to: m.viewport.YOffset})
+			return cmd
+		}
+	}
+	return m.showStatusMessage(pagerStatusMessage{message: "No footnote references in view"})
+}
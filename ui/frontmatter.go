@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/log"
+	"go.yaml.in/yaml/v3"
+)
+
+// documentMeta holds the front matter fields that affect how a document is
+// displayed.
+type documentMeta struct {
+	// Line number of the document's first line, minus one, for documents
+	// extracted from a larger file.
+	LineOffset int `yaml:"line_offset"`
+
+	// Title of the document, shown as a heading with FrontmatterTitle.
+	Title string `yaml:"title"`
+}
+
+// parseDocumentMeta reads the front matter of a markdown document. Missing or
+// malformed front matter results in empty metadata.
+func parseDocumentMeta(source string) documentMeta {
+	var meta documentMeta
+	fm := utils.Frontmatter([]byte(source))
+	if fm == nil {
+		return meta
+	}
+	if err := yaml.Unmarshal(fm, &meta); err != nil {
+		log.Debug("unable to parse front matter", "error", err)
+	}
+	return meta
+}
+
+// withFrontmatterTitle returns the body of a markdown document with the
+// title from its front matter prepended as an H1 heading. Bodies of
+// documents without a title, or that already start with a heading of
+// their title, are returned as they are.
+func withFrontmatterTitle(source, body string) string {
+	title := strings.Join(strings.Fields(parseDocumentMeta(source).Title), " ")
+	if title == "" {
+		return body
+	}
+
+	first := 0
+	for _, line := range strings.Split(body, "\n") {
+		if strings.TrimSpace(line) != "" {
+			break
+		}
+		first++
+	}
+	if headings := parseHeadings(body); len(headings) > 0 && headings[0].line == first &&
+		headings[0].level == 1 && strings.EqualFold(headings[0].plainText(), title) {
+		return body
+	}
+
+	return "# " + title + "\n\n" + body
+}
+
+// documentBody returns the body of the current document as it's rendered:
+// without front matter, but with its title when FrontmatterTitle is on.
+func (m pagerModel) documentBody() string {
+	source := m.currentDocument.source
+	if !utils.IsMarkdownFile(m.currentDocument.localPath) {
+		return source
+	}
+	body := string(utils.RemoveFrontmatter([]byte(source)))
+	if m.frontmatterTitle {
+		body = withFrontmatterTitle(source, body)
+	}
+	return body
+}
+
+// toggleFrontmatterTitle shows or hides the front matter title as a heading
+// atop the document, staying on the current slide.
+func (m *pagerModel) toggleFrontmatterTitle() tea.Cmd {
+	if !utils.IsMarkdownFile(m.currentDocument.localPath) || parseDocumentMeta(m.currentDocument.source).Title == "" {
+		return m.showStatusMessage(pagerStatusMessage{"No front matter title", true})
+	}
+
+	m.frontmatterTitle = !m.frontmatterTitle
+	m.currentDocument.Body = m.documentBody()
+
+	slide, slideMode := m.currentSlide, m.slideMode
+	m.parseSlides()
+	if slideMode && m.slideMode {
+		m.currentSlide = min(slide, len(m.slides)-1)
+	}
+	return renderWithGlamour(*m, m.currentMarkdown())
+}
@@ -0,0 +1,242 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Maximum number of positions remembered in the jump list.
+const maxJumps = 100
+
+// Most digits a count typed before a jump can have.
+const maxCountDigits = 6
+
+var slugStripPattern = regexp.MustCompile(`[^\p{L}\p{N}\s_-]`)
+
+// jumpPosition is a position in the document recorded in the jump list.
+type jumpPosition struct {
+	path   string
+	slide  int
+	offset int
+}
+
+func newGotoInput() textinput.Model {
+	gi := textinput.New()
+	gi.Prompt = ":"
+	gi.PromptStyle = searchInputPromptStyle
+	gi.Cursor.Style = searchInputCursorStyle
+	return gi
+}
+
+// startGoto opens the goto prompt.
+func (m *pagerModel) startGoto() tea.Cmd {
+	m.gotoing = true
+	m.gotoInput.Reset()
+	m.gotoInput.Width = m.viewport.Width - len(m.gotoInput.Prompt) - 1
+	return m.gotoInput.Focus()
+}
+
+// handleGotoInput handles key presses while the goto prompt is open.
+func (m pagerModel) handleGotoInput(msg tea.KeyMsg) (pagerModel, tea.Cmd) {
+	switch msg.String() {
+	case keyEsc:
+		m.gotoing = false
+		m.gotoInput.Blur()
+		return m, nil
+
+	case keyEnter:
+		m.gotoing = false
+		m.gotoInput.Blur()
+		target := strings.TrimSpace(m.gotoInput.Value())
+		if target == "" {
+			return m, nil
+		}
+		return m, m.gotoTarget(target)
+	}
+
+	var cmd tea.Cmd
+	m.gotoInput, cmd = m.gotoInput.Update(msg)
+	return m, cmd
+}
+
+// handleCount handles key presses typing a count: a number followed by
+// enter or G jumps to that slide in slide mode and to that line otherwise.
+// It returns whether the key press was used up. Esc, as well as any other
+// key, drops the count.
+func (m *pagerModel) handleCount(msg tea.KeyMsg) (tea.Cmd, bool) {
+	k := msg.String()
+	if len(k) == 1 && k[0] >= '0' && k[0] <= '9' && (k != "0" || m.count != "") {
+		if len(m.count) < maxCountDigits {
+			m.count += k
+		}
+		// Make way for the count in the status bar
+		m.state = pagerStateBrowse
+		return nil, true
+	}
+	if m.count == "" {
+		return nil, false
+	}
+
+	count := m.count
+	m.count = ""
+	switch k {
+	case keyEnter, "G":
+		if m.slideMode {
+			return m.gotoTarget("s" + count), true
+		}
+		return m.gotoTarget(count), true
+	case keyEsc:
+		return nil, true
+	}
+	return nil, false
+}
+
+// countView describes the jump the count typed so far leads to, for the
+// status bar.
+func (m pagerModel) countView() string {
+	if m.slideMode {
+		return "[slide " + m.count + "]"
+	}
+	return "[line " + m.count + "]"
+}
+
+// gotoTarget jumps to the given target, which is one of:
+//
+//	42        line 42
+//	50%       halfway through the document
+//	#slug     the heading with the given anchor
+//	s3        slide 3
+func (m *pagerModel) gotoTarget(target string) tea.Cmd {
+	switch {
+	case strings.HasPrefix(target, "#"):
+		line, ok := m.headingLine(strings.TrimPrefix(target, "#"))
+		if !ok {
+			return m.showStatusMessage(pagerStatusMessage{"No heading " + target, true})
+		}
+		return m.jumpTo(line)
+
+	case strings.HasSuffix(target, "%"):
+		p, err := strconv.ParseFloat(strings.TrimSuffix(target, "%"), 64)
+		if err != nil || p < 0 || p > 100 {
+			break
+		}
+		maxOffset := max(0, m.viewport.TotalLineCount()-m.viewport.Height)
+		return m.jumpTo(int(p / 100 * float64(maxOffset)))
+
+	case strings.HasPrefix(target, "s"):
+		n, err := strconv.Atoi(strings.TrimPrefix(target, "s"))
+		if err != nil || n < 1 {
+			break
+		}
+		if !m.slideMode {
+			m.parseSlides()
+		}
+		if !m.slideMode {
+			return m.showStatusMessage(pagerStatusMessage{"Not a slide deck", true})
+		}
+		m.recordJump()
+		return m.gotoSlide(n - 1)
+
+	default:
+		// Lines are numbered like in the gutter
+		n, err := strconv.Atoi(target)
+		if err != nil {
+			break
+		}
+		n -= m.currentDocument.lineOffset
+		if n < 1 {
+			break
+		}
+		return m.jumpTo(n - 1)
+	}
+
+	return m.showStatusMessage(pagerStatusMessage{
+		message: fmt.Sprintf("Can't go to %q, try a line, N%%, #heading or sN", target),
+		isError: true,
+	})
+}
+
+// headingLine returns the rendered line of the heading with the given
+// anchor slug.
+func (m pagerModel) headingLine(slug string) (int, bool) {
+	headings := parseHeadings(m.currentMarkdown())
+	lines := findHeadingLines(headings, m.renderedLines())
+	for i, h := range headings {
+		if headingSlug(h.plainText()) == strings.ToLower(slug) && lines[i] >= 0 {
+			return lines[i], true
+		}
+	}
+	return 0, false
+}
+
+// scrollToAnchor scrolls to the heading the document was opened at, by its
+// anchor, or to the top if there's no such heading.
+func (m *pagerModel) scrollToAnchor() tea.Cmd {
+	anchor := m.anchor
+	m.anchor = ""
+	if line, ok := m.headingLine(anchor); ok {
+		return m.scrollTo(line)
+	}
+	m.viewport.SetYOffset(0)
+	return m.showStatusMessage(pagerStatusMessage{
+		message: fmt.Sprintf("No heading #%s, starting at the top", anchor),
+		isError: true,
+	})
+}
+
+// headingSlug returns the anchor GitHub generates for a heading.
+func headingSlug(text string) string {
+	slug := slugStripPattern.ReplaceAllString(strings.ToLower(text), "")
+	return strings.ReplaceAll(slug, " ", "-")
+}
+
+// jumpTo scrolls to the given line, remembering the current position in the
+// jump list.
+func (m *pagerModel) jumpTo(line int) tea.Cmd {
+	m.recordJump()
+	return m.scrollTo(line)
+}
+
+// scrollTo scrolls to the given line.
+func (m *pagerModel) scrollTo(line int) tea.Cmd {
+	m.viewport.SetYOffset(line)
+	if m.viewport.HighPerformanceRendering {
+		return viewport.Sync(m.viewport)
+	}
+	return nil
+}
+
+// recordJump adds the current position to the jump list.
+func (m *pagerModel) recordJump() {
+	m.jumps = append(m.jumps, jumpPosition{
+		path:   m.currentDocument.localPath,
+		slide:  m.currentSlide,
+		offset: m.viewport.YOffset,
+	})
+	if len(m.jumps) > maxJumps {
+		m.jumps = m.jumps[len(m.jumps)-maxJumps:]
+	}
+}
+
+// jumpBack returns to the last position in the jump list.
+func (m *pagerModel) jumpBack() tea.Cmd {
+	if len(m.jumps) == 0 {
+		return nil
+	}
+	pos := m.jumps[len(m.jumps)-1]
+	m.jumps = m.jumps[:len(m.jumps)-1]
+
+	if pos.path != "" && !sameFile(pos.path, m.currentDocument.localPath) {
+		return m.openFileAt(pos.path, pos.offset)
+	}
+	if m.slideMode && pos.slide != m.currentSlide {
+		return m.gotoSlide(pos.slide)
+	}
+	return m.scrollTo(pos.offset)
+}
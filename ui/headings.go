@@ -0,0 +1,219 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+var (
+	atxHeadingPattern    = regexp.MustCompile(`^ {0,3}(#{1,6})(?:\s+(.*?))?(?:\s+#+)?\s*$`)
+	setextH1Pattern      = regexp.MustCompile(`^ {0,3}=+\s*$`)
+	setextH2Pattern      = regexp.MustCompile(`^ {0,3}-+\s*$`)
+	inlineMarkupReplacer = strings.NewReplacer("**", "", "__", "", "*", "", "`", "", "~~", "")
+)
+
+// heading is a heading in a markdown document.
+type heading struct {
+	level int
+	text  string // heading text, without the markers
+	line  int    // 0-based line in the source document
+}
+
+// parseHeadings returns the ATX and Setext headings in the given markdown,
+// skipping anything inside fenced code blocks.
+func parseHeadings(md string) []heading {
+	var (
+		headings []heading
+		inFence  bool
+	)
+
+	lines := strings.Split(md, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		if m := atxHeadingPattern.FindStringSubmatch(line); m != nil {
+			headings = append(headings, heading{
+				level: len(m[1]),
+				text:  m[2],
+				line:  i,
+			})
+			continue
+		}
+
+		if level := setextLevel(lines, i); level > 0 {
+			headings = append(headings, heading{
+				level: level,
+				text:  strings.TrimSpace(lines[i-1]),
+				line:  i - 1,
+			})
+		}
+	}
+
+	return headings
+}
+
+// setextLevel returns the level of the Setext heading underlined by the
+// given line, or 0 if it isn't a Setext underline. Only single-line headings
+// are recognized: the text must directly follow a blank line or another
+// block, so that a --- after a paragraph's last line isn't mistaken for one
+// in the middle of prose.
+func setextLevel(lines []string, i int) int {
+	if i == 0 {
+		return 0
+	}
+
+	var level int
+	switch {
+	case setextH1Pattern.MatchString(lines[i]):
+		level = 1
+	case setextH2Pattern.MatchString(lines[i]):
+		level = 2
+	default:
+		return 0
+	}
+
+	text := strings.TrimSpace(lines[i-1])
+	if text == "" || !isSetextText(lines[i-1]) {
+		return 0
+	}
+	if i >= 2 && strings.TrimSpace(lines[i-2]) != "" && !atxHeadingPattern.MatchString(lines[i-2]) {
+		return 0
+	}
+	return level
+}
+
+// isSetextText returns whether a line can be the text of a Setext heading,
+// rather than belonging to some other block.
+func isSetextText(line string) bool {
+	if strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t") {
+		return false // indented code
+	}
+	trimmed := strings.TrimSpace(line)
+	for _, prefix := range []string{">", "- ", "* ", "+ ", "```", "~~~", "<"} {
+		if strings.HasPrefix(trimmed, prefix) {
+			return false
+		}
+	}
+	return !atxHeadingPattern.MatchString(line) && !setextH2Pattern.MatchString(line)
+}
+
+// plainText returns the heading text with inline markup removed, as it
+// appears once rendered.
+func (h heading) plainText() string {
+	return strings.Join(strings.Fields(inlineMarkupReplacer.Replace(h.text)), " ")
+}
+
+// findHeadingLines locates the given headings in rendered output, returning
+// the index of the rendered line each heading starts on, or -1 if it
+// couldn't be found. Headings are expected to appear in order.
+func findHeadingLines(headings []heading, rendered []string) []int {
+	texts := make([]string, len(headings))
+	for i, h := range headings {
+		texts[i] = h.plainText()
+	}
+	return findTextLines(texts, rendered)
+}
+
+// findTextLines locates the given texts in rendered output, returning the
+// index of the rendered line each text starts on, or -1 if it couldn't be
+// found. Texts are expected to appear in order.
+func findTextLines(texts []string, rendered []string) []int {
+	offsets := make([]int, len(texts))
+	next := 0
+
+	for i, text := range texts {
+		offsets[i] = -1
+
+		if text == "" {
+			continue
+		}
+		// Long texts may wrap, so only look for the beginning
+		if words := strings.Fields(text); len(words) > 3 {
+			text = strings.Join(words[:3], " ")
+		}
+
+		for j := next; j < len(rendered); j++ {
+			if strings.Contains(ansi.Strip(rendered[j]), text) {
+				offsets[i] = j
+				next = j + 1
+				break
+			}
+		}
+	}
+
+	return offsets
+}
+
+// hangHeadingIndents rewraps long headings in rendered output so that wrapped
+// lines align with the start of the heading text, rather than falling back to
+// the left margin.
+func hangHeadingIndents(md string, lines []string) []string {
+	headings := parseHeadings(md)
+	offsets := findHeadingLines(headings, lines)
+
+	// Work backwards so that changing line counts don't invalidate the
+	// offsets we have yet to process.
+	for i := len(headings) - 1; i >= 0; i-- {
+		start := offsets[i]
+		if start < 0 {
+			continue
+		}
+
+		text := headings[i].plainText()
+		first := ansi.Strip(lines[start])
+		idx := strings.Index(first, strings.Fields(text)[0])
+		if idx < 0 {
+			continue
+		}
+		indent := ansi.StringWidth(first[:idx])
+
+		// Gather the lines the heading was wrapped onto
+		parts := []string{trimRightANSI(ansi.TruncateLeft(lines[start], indent, ""))}
+		gathered := strings.TrimSpace(first[idx:])
+		edge := ansi.StringWidth(strings.TrimRight(first, " "))
+		end := start + 1
+		for ; end < len(lines) && len(gathered) < len(text); end++ {
+			plain := ansi.Strip(lines[end])
+			content := strings.TrimSpace(plain)
+			if content == "" {
+				break
+			}
+			margin := len(plain) - len(strings.TrimLeft(plain, " "))
+			parts = append(parts, trimRightANSI(ansi.TruncateLeft(lines[end], margin, "")))
+			gathered += " " + content
+			edge = max(edge, ansi.StringWidth(strings.TrimRight(plain, " ")))
+		}
+		if end == start+1 || edge <= indent {
+			continue
+		}
+
+		wrapped := strings.Split(ansi.Wordwrap(strings.Join(parts, " "), edge-indent, ""), "\n")
+		hung := make([]string, len(wrapped))
+		for j, w := range wrapped {
+			if j == 0 {
+				hung[j] = ansi.Truncate(lines[start], indent, "") + w
+			} else {
+				hung[j] = strings.Repeat(" ", indent) + w
+			}
+		}
+
+		lines = append(lines[:start], append(hung, lines[end:]...)...)
+	}
+
+	return lines
+}
+
+// trimRightANSI removes trailing whitespace from a string that may contain
+// ANSI escape sequences.
+func trimRightANSI(s string) string {
+	return ansi.Truncate(s, ansi.StringWidth(strings.TrimRight(ansi.Strip(s), " ")), "")
+}
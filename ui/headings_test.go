@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseHeadings(t *testing.T) {
+	const md = `Title
+=====
+
+Some intro text.
+
+# ATX heading
+
+Subtitle
+--------
+
+A paragraph
+spanning lines
+---
+
+` + "```" + `
+Not a heading
+=============
+` + "```" + `
+
+## Closed ATX ##
+Trailing setext
+===`
+
+	want := []heading{
+		{level: 1, text: "Title", line: 0},
+		{level: 1, text: "ATX heading", line: 5},
+		{level: 2, text: "Subtitle", line: 7},
+		{level: 2, text: "Closed ATX", line: 19},
+		{level: 1, text: "Trailing setext", line: 20},
+	}
+
+	if got := parseHeadings(md); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected headings\nwant: %+v\ngot:  %+v", want, got)
+	}
+}
+
+func TestParseSlidesWithSetextHeadings(t *testing.T) {
+	const md = `1. Intro
+========
+
+Welcome.
+
+# 2. ATX slide
+
+Content.
+
+3. Setext slide
+===============
+
+More content.
+`
+
+	m := newPagerModel(&commonModel{cfg: Config{PresentationMode: true}})
+	m.currentDocument.Body = md
+	m.parseSlides()
+
+	if len(m.slides) != 3 {
+		t.Fatalf("expected 3 slides, got %d: %q", len(m.slides), m.slides)
+	}
+	for i, prefix := range []string{"1. Intro\n====", "# 2. ATX slide", "3. Setext slide\n===="} {
+		if !strings.HasPrefix(m.slides[i], prefix) {
+			t.Errorf("slide %d: expected to start with %q, got %q", i+1, prefix, m.slides[i])
+		}
+	}
+	if strings.Contains(m.slides[1], "===") {
+		t.Errorf("slide 2 shouldn't contain the next slide's underline: %q", m.slides[1])
+	}
+}
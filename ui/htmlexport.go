@@ -0,0 +1,175 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/log"
+)
+
+// htmlExportedMsg reports a document saved as HTML by exportHTML.
+type htmlExportedMsg struct {
+	path string
+	err  error
+}
+
+// cssColor returns the given color in CSS hex notation.
+func cssColor(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)
+}
+
+// css returns the inline style of a run of cells in this style, on top of
+// the given defaults.
+func (s cellStyle) css(fg, bg color.Color) string {
+	if s.fg != nil {
+		fg = s.fg
+	}
+	if s.bg != nil {
+		bg = s.bg
+	}
+	if s.reverse {
+		fg, bg = bg, fg
+	}
+
+	var rules []string
+	rules = append(rules, "color:"+cssColor(fg))
+	if s.bg != nil || s.reverse {
+		rules = append(rules, "background:"+cssColor(bg))
+	}
+	if s.bold {
+		rules = append(rules, "font-weight:bold")
+	}
+	if s.italic {
+		rules = append(rules, "font-style:italic")
+	}
+	if s.underline {
+		rules = append(rules, "text-decoration:underline")
+	}
+	return strings.Join(rules, ";")
+}
+
+// htmlSnapshot turns ANSI styled text into a standalone HTML page that
+// shows it as it would look in a terminal with the given default colors.
+func htmlSnapshot(s, title string, fg, bg color.Color) string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&b, "<title>%s</title>\n", html.EscapeString(title))
+	fmt.Fprintf(&b, "<style>\nbody { margin: 0; background: %s; }\n", cssColor(bg))
+	fmt.Fprintf(&b, "pre { margin: 0; padding: 1em 2ch; color: %s; font-family: ui-monospace, Menlo, Consolas, monospace; line-height: 1.2; }\n", cssColor(fg))
+	b.WriteString("</style>\n</head>\n<body>\n<pre>")
+
+	for i, line := range parseStyledLines(strings.TrimRight(s, "\n")) {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		// Cells in the same style share a span
+		type run struct {
+			style cellStyle
+			text  string
+		}
+		var runs []run
+		for _, c := range line {
+			if n := len(runs); n > 0 && runs[n-1].style == c.style {
+				runs[n-1].text += c.text
+				continue
+			}
+			runs = append(runs, run{c.style, c.text})
+		}
+
+		// The padding glamour adds to the end of lines is left out
+		for len(runs) > 0 {
+			last := runs[len(runs)-1]
+			if strings.TrimSpace(last.text) != "" || last.style.bg != nil || last.style.reverse {
+				break
+			}
+			runs = runs[:len(runs)-1]
+		}
+
+		for _, r := range runs {
+			if r.style == (cellStyle{}) {
+				b.WriteString(html.EscapeString(r.text))
+				continue
+			}
+			fmt.Fprintf(&b, `<span style="%s">%s</span>`, r.style.css(fg, bg), html.EscapeString(r.text))
+		}
+	}
+
+	b.WriteString("</pre>\n</body>\n</html>\n")
+	return b.String()
+}
+
+// htmlExportPath returns where to save the document as HTML: next to it,
+// with a number added to the name if there's a file by that name already.
+func (m pagerModel) htmlExportPath() string {
+	note := utils.UncompressedName(m.currentDocument.Note)
+	name := strings.TrimSuffix(filepath.Base(note), filepath.Ext(note))
+	if name == "" || name == "." {
+		name = "glow"
+	}
+	dir := m.common.cwd
+	if m.currentDocument.localPath != "" {
+		dir = m.localDir()
+	}
+
+	path := filepath.Join(dir, name+".html")
+	for n := 1; ; n++ {
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			return path
+		}
+		path = filepath.Join(dir, fmt.Sprintf("%s-%d.html", name, n))
+	}
+}
+
+// exportHTML saves the whole document as an HTML page, styled as it's
+// rendered with the current style and width.
+func (m pagerModel) exportHTML() tea.Cmd {
+	if m.currentDocument.Body == "" {
+		return m.showStatusMessage(pagerStatusMessage{"Nothing to export", true})
+	}
+
+	// Render the whole document, not just the current slide, without the
+	// line number gutter
+	common := *m.common
+	common.cfg.ShowLineNumbers = false
+	common.cfg.ShowLineNumbersProse = false
+	common.cfg.ShowLineNumbersCode = false
+	common.cfg.InlineImages = false
+	r := m
+	r.common = &common
+	r.lineNumbers = nil
+	r.slideMode = false
+	body := m.currentDocument.Body
+	path := m.htmlExportPath()
+	title := filepath.Base(m.currentDocument.Note)
+
+	return func() tea.Msg {
+		rendered, err := glamourRender(r, body)
+		if err != nil {
+			return htmlExportedMsg{path: path, err: err}
+		}
+		fg, bg := snapshotColors(common.cfg.GlamourStyle)
+		page := htmlSnapshot(rendered, title, fg, bg)
+		if err := os.WriteFile(path, []byte(page), 0o644); err != nil { //nolint:gosec
+			return htmlExportedMsg{path: path, err: err}
+		}
+		return htmlExportedMsg{path: path}
+	}
+}
+
+// handleHTMLExported reports where the document was saved.
+func (m *pagerModel) handleHTMLExported(msg htmlExportedMsg) tea.Cmd {
+	if msg.err != nil {
+		log.Error("unable to export HTML", "file", msg.path, "error", msg.err)
+		return m.showStatusMessage(pagerStatusMessage{"Unable to export HTML: " + msg.err.Error(), true})
+	}
+	log.Info("exported HTML", "file", msg.path)
+	return m.showStatusMessage(pagerStatusMessage{message: "Saved HTML to " + msg.path})
+}
@@ -0,0 +1,516 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Keys the pager keeps for itself, which can't be bound to actions: esc,
+// ctrl+c and ctrl+z leave, and digits type a count before a jump.
+var reservedKeys = []string{keyEsc, "ctrl+c", "ctrl+z", "1", "2", "3", "4", "5", "6", "7", "8", "9"}
+
+// Names keys are shown with in help, and can be given by in the key
+// bindings configured, for those whose name is hard to type or read.
+var keyNames = map[string]string{
+	" ":      "space",
+	"up":     "↑",
+	"down":   "↓",
+	"left":   "←",
+	"right":  "→",
+	"pgdown": "pgdn",
+}
+
+// pagerKeyMap holds the keys of the pager's actions. See newPagerKeyMap.
+type pagerKeyMap struct {
+	Up, Down                                      key.Binding
+	PageUp, PageDown, HalfPageUp, HalfPageDown    key.Binding
+	Top, Bottom                                   key.Binding
+	ScrollLeft, ScrollRight                       key.Binding
+	HalfScreenLeft, HalfScreenRight               key.Binding
+	LineStart, LineEnd                            key.Binding
+	Wrap, LineNumbers                             key.Binding
+	Goto, TOC, JumpBack, GotoDefinition           key.Binding
+	Footnote                                      key.Binding
+	PrevListItem, NextListItem                    key.Binding
+	PrevParagraph, NextParagraph                  key.Binding
+	PrevHeading, NextHeading                      key.Binding
+	PrevImage, NextImage                          key.Binding
+	NextSlide, PrevSlide                          key.Binding
+	Fullscreen, Notes, ExportSlide, ExportHTML    key.Binding
+	ResetTimer, AutoAdvance                       key.Binding
+	Search, SearchBackward, PrevMatch             key.Binding
+	ExportMatches, ReturnFromSearch               key.Binding
+	ToggleDetails, ToggleComments                 key.Binding
+	ToggleFold, UnfoldAll, FoldAll, Spotlight     key.Binding
+	Copy, CopyPlainText, ShowLongLines            key.Binding
+	CopySection, CopyCodeBlock                    key.Binding
+	NextLink, PrevLink, CopyLink, OpenLink        key.Binding
+	Edit, CopySnippet, Reload, ReloadStyle        key.Binding
+	Recent, Prettify, FrontmatterTitle, AutoWatch key.Binding
+	Stats, WordCount, Debug                       key.Binding
+	ToggleRendering, PickStyle, CycleStyle        key.Binding
+	CompactHelp, Help, Quit                       key.Binding
+}
+
+// keyAction is an action of the pager, by the name its keys are configured
+// with.
+type keyAction struct {
+	name    string
+	binding *key.Binding
+}
+
+// defaultPagerKeyMap returns the keys the pager's actions have unless
+// configured otherwise.
+func defaultPagerKeyMap() pagerKeyMap {
+	b := func(help, desc string, keys ...string) key.Binding {
+		return key.NewBinding(key.WithKeys(keys...), key.WithHelp(help, desc))
+	}
+	return pagerKeyMap{
+		Up:               b("k/↑", "up", "k", "up"),
+		Down:             b("j/↓", "down", "j", "down"),
+		PageUp:           b("b/pgup", "page up", "b", "pgup"),
+		PageDown:         b("f/pgdn", "page down", "f", "pgdown", " "),
+		HalfPageUp:       b("u", "½ page up", "u", "ctrl+u"),
+		HalfPageDown:     b("d", "½ page down", "d", "ctrl+d"),
+		Top:              b("g/home", "go to top", "g", "home"),
+		Bottom:           b("G/end", "go to bottom", "G", "end"),
+		ScrollLeft:       b("h", "scroll left", "h"),
+		ScrollRight:      b("l", "scroll right", "l"),
+		HalfScreenLeft:   b("shift+←", "scroll left half a screen", "shift+left"),
+		HalfScreenRight:  b("shift+→", "scroll right half a screen", "shift+right"),
+		LineStart:        b("0", "line start", "0"),
+		LineEnd:          b("$", "line end", "$"),
+		Wrap:             b("w", "toggle wrapping", "w"),
+		LineNumbers:      b("L", "toggle line numbers", "L"),
+		Goto:             b(":", "go to line/N%/#heading/sN", ":"),
+		TOC:              b("t", "table of contents", "t"),
+		JumpBack:         b("ctrl+o", "jump back", "ctrl+o"),
+		GotoDefinition:   b("ctrl+]", "go to definition (tags)", "ctrl+]"),
+		Footnote:         b("^", "footnote and back", "^"),
+		PrevListItem:     b("(", "prev list item", "("),
+		NextListItem:     b(")", "next list item", ")"),
+		PrevParagraph:    b("{", "prev paragraph", "{"),
+		NextParagraph:    b("}", "next paragraph", "}"),
+		PrevHeading:      b("[", "prev heading", "["),
+		NextHeading:      b("]", "next heading", "]"),
+		PrevImage:        b("<", "prev image", "<"),
+		NextImage:        b(">", "next image", ">"),
+		NextSlide:        b("n", "next slide", "n", "right"),
+		PrevSlide:        b("p", "previous slide", "p", "left"),
+		Fullscreen:       b("F", "fullscreen slides", "F"),
+		Notes:            b("S", "toggle speaker notes", "S"),
+		ExportSlide:      b("X", "save slide as image", "X"),
+		ExportHTML:       b("H", "save as HTML", "H"),
+		ResetTimer:       b("T", "reset presentation timer", "T"),
+		AutoAdvance:      b("A", "pause/resume auto-advance", "A"),
+		Search:           b("/", "search", "/"),
+		SearchBackward:   b("ctrl+r", "search backward", "ctrl+r"),
+		PrevMatch:        b("N", "prev match", "N"),
+		ExportMatches:    b("ctrl+e", "export matches", "ctrl+e"),
+		ReturnFromSearch: b("ctrl+s", "back to before search", "ctrl+s"),
+		ToggleDetails:    b("tab", "toggle details", "tab"),
+		ToggleComments:   b("a", "toggle comments", "a"),
+		ToggleFold:       b("za", "toggle fold", "za"),
+		UnfoldAll:        b("zR", "unfold all", "zR"),
+		FoldAll:          b("zM", "fold all", "zM"),
+		Spotlight:        b("zz", "spotlight paragraph", "zz"),
+		Copy:             b("c", "copy contents", "c"),
+		CopyPlainText:    b("C", "copy as plain text", "C"),
+		ShowLongLines:    b("!", "show long lines in full", "!"),
+		CopySection:      b("Y", "copy section", "Y"),
+		CopyCodeBlock:    b("y", "copy code block", "y"),
+		NextLink:         b("ctrl+n", "next link", "ctrl+n"),
+		PrevLink:         b("ctrl+p", "prev link", "ctrl+p"),
+		CopyLink:         b("U", "copy link URL", "U"),
+		OpenLink:         b("o", "open link", "o"),
+		Edit:             b("e", "edit this document", "e"),
+		CopySnippet:      b("E", "copy lines with context", "E"),
+		Reload:           b("r", "reload this document", "r"),
+		ReloadStyle:      b("R", "reload the style", "R"),
+		Recent:           b("O", "open a recent document", "O"),
+		Prettify:         b("P", "prettify and save", "P"),
+		FrontmatterTitle: b("M", "toggle front matter title", "M"),
+		AutoWatch:        b("W", "toggle auto-reload", "W"),
+		Stats:            b("ctrl+g", "document stats", "ctrl+g"),
+		WordCount:        b("i", "toggle word count", "i"),
+		Debug:            b("D", "debug info", "D"),
+		ToggleRendering:  b("ctrl+x", "toggle rendering mode", "ctrl+x"),
+		PickStyle:        b("ctrl+t", "pick a style", "ctrl+t"),
+		CycleStyle:       b("s", "next style", "s"),
+		CompactHelp:      b("f1", "toggle compact help", "f1"),
+		Help:             b("?", "toggle help", "?"),
+		Quit:             b("q", "quit", "q"),
+	}
+}
+
+// actions returns the pager's actions, by name.
+func (k *pagerKeyMap) actions() []keyAction {
+	return []keyAction{
+		{"up", &k.Up},
+		{"down", &k.Down},
+		{"pageUp", &k.PageUp},
+		{"pageDown", &k.PageDown},
+		{"halfPageUp", &k.HalfPageUp},
+		{"halfPageDown", &k.HalfPageDown},
+		{"top", &k.Top},
+		{"bottom", &k.Bottom},
+		{"scrollLeft", &k.ScrollLeft},
+		{"scrollRight", &k.ScrollRight},
+		{"halfScreenLeft", &k.HalfScreenLeft},
+		{"halfScreenRight", &k.HalfScreenRight},
+		{"lineStart", &k.LineStart},
+		{"lineEnd", &k.LineEnd},
+		{"wrap", &k.Wrap},
+		{"lineNumbers", &k.LineNumbers},
+		{"goto", &k.Goto},
+		{"toc", &k.TOC},
+		{"jumpBack", &k.JumpBack},
+		{"gotoDefinition", &k.GotoDefinition},
+		{"footnote", &k.Footnote},
+		{"prevListItem", &k.PrevListItem},
+		{"nextListItem", &k.NextListItem},
+		{"prevParagraph", &k.PrevParagraph},
+		{"nextParagraph", &k.NextParagraph},
+		{"prevHeading", &k.PrevHeading},
+		{"nextHeading", &k.NextHeading},
+		{"prevImage", &k.PrevImage},
+		{"nextImage", &k.NextImage},
+		{"nextSlide", &k.NextSlide},
+		{"prevSlide", &k.PrevSlide},
+		{"fullscreen", &k.Fullscreen},
+		{"notes", &k.Notes},
+		{"exportSlide", &k.ExportSlide},
+		{"exportHTML", &k.ExportHTML},
+		{"resetTimer", &k.ResetTimer},
+		{"autoAdvance", &k.AutoAdvance},
+		{"search", &k.Search},
+		{"searchBackward", &k.SearchBackward},
+		{"prevMatch", &k.PrevMatch},
+		{"exportMatches", &k.ExportMatches},
+		{"returnFromSearch", &k.ReturnFromSearch},
+		{"toggleDetails", &k.ToggleDetails},
+		{"toggleComments", &k.ToggleComments},
+		{"toggleFold", &k.ToggleFold},
+		{"unfoldAll", &k.UnfoldAll},
+		{"foldAll", &k.FoldAll},
+		{"spotlight", &k.Spotlight},
+		{"copy", &k.Copy},
+		{"copyPlainText", &k.CopyPlainText},
+		{"showLongLines", &k.ShowLongLines},
+		{"copySection", &k.CopySection},
+		{"copyCodeBlock", &k.CopyCodeBlock},
+		{"nextLink", &k.NextLink},
+		{"prevLink", &k.PrevLink},
+		{"copyLink", &k.CopyLink},
+		{"openLink", &k.OpenLink},
+		{"edit", &k.Edit},
+		{"copySnippet", &k.CopySnippet},
+		{"reload", &k.Reload},
+		{"reloadStyle", &k.ReloadStyle},
+		{"recent", &k.Recent},
+		{"prettify", &k.Prettify},
+		{"frontmatterTitle", &k.FrontmatterTitle},
+		{"autoWatch", &k.AutoWatch},
+		{"stats", &k.Stats},
+		{"wordCount", &k.WordCount},
+		{"debug", &k.Debug},
+		{"toggleRendering", &k.ToggleRendering},
+		{"pickStyle", &k.PickStyle},
+		{"cycleStyle", &k.CycleStyle},
+		{"compactHelp", &k.CompactHelp},
+		{"help", &k.Help},
+		{"quit", &k.Quit},
+	}
+}
+
+// newPagerKeyMap returns the keys of the pager's actions: the defaults, but
+// for the actions given keys of their own, by name. Names are matched
+// regardless of case, as configuration keys are lowercased. Keys can also
+// be sequences of characters typed one after the other, like za. It returns
+// the defaults, along with an error, for unknown actions, reserved keys,
+// keys bound to more than one action and keys that begin a sequence bound
+// to another.
+func newPagerKeyMap(bindings map[string][]string) (pagerKeyMap, error) {
+	k := defaultPagerKeyMap()
+	actions := k.actions()
+
+	names := make([]string, 0, len(bindings))
+	for name := range bindings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		i := findAction(actions, name)
+		if i < 0 {
+			return defaultPagerKeyMap(), fmt.Errorf("unknown action %q", name)
+		}
+		keys := make([]string, len(bindings[name]))
+		for j, s := range bindings[name] {
+			keys[j] = parseKeyName(s)
+		}
+		b := actions[i].binding
+		b.SetKeys(keys...)
+		b.SetHelp(keysHelp(keys), b.Help().Desc)
+	}
+
+	boundTo := map[string]string{}
+	for _, r := range reservedKeys {
+		boundTo[r] = ""
+	}
+	for _, a := range actions {
+		for _, s := range a.binding.Keys() {
+			other, ok := boundTo[s]
+			switch {
+			case ok && other == "":
+				return defaultPagerKeyMap(), fmt.Errorf("key %s is reserved, and can't be bound to %s", keyName(s), a.name)
+			case ok:
+				return defaultPagerKeyMap(), fmt.Errorf("key %s is bound to both %s and %s", keyName(s), other, a.name)
+			}
+			boundTo[s] = a.name
+		}
+	}
+	for _, a := range actions {
+		for _, s := range a.binding.Keys() {
+			for i := 1; isKeySequence(s) && i < len(s); i++ {
+				if other := boundTo[s[:i]]; other != "" {
+					return defaultPagerKeyMap(), fmt.Errorf("key %s is bound to %s, and begins %s, bound to %s", keyName(s[:i]), other, s, a.name)
+				}
+			}
+		}
+	}
+	return k, nil
+}
+
+// CheckKeyBindings returns an error if the given key bindings can't be
+// used: they name unknown actions, bind reserved keys or bind the same key
+// to more than one action.
+func CheckKeyBindings(bindings map[string][]string) error {
+	if _, err := newPagerKeyMap(bindings); err != nil {
+		return fmt.Errorf("invalid key bindings: %w", err)
+	}
+	return nil
+}
+
+// rebound returns whether the key is bound to an action other than those
+// it goes back to the file listing alongside, so that it's left to that
+// action instead.
+func (k pagerKeyMap) rebound(msg tea.KeyMsg) bool {
+	for _, a := range k.actions() {
+		if a.binding != &k.ScrollLeft && a.binding != &k.PrevSlide && key.Matches(msg, *a.binding) {
+			return true
+		}
+	}
+	return false
+}
+
+// namedKeys are the keys Bubble Tea has names for, like enter and pgup.
+var namedKeys = func() map[string]bool {
+	names := map[string]bool{}
+	for t := tea.KeyF20; t <= tea.KeyBackspace; t++ {
+		if name := t.String(); name != "" {
+			names[name] = true
+		}
+	}
+	return names
+}()
+
+// isKeySequence returns whether the key is a sequence of characters, typed
+// one after the other, rather than a single key.
+func isKeySequence(k string) bool {
+	return utf8.RuneCountInString(k) > 1 && !strings.Contains(k, "+") && !namedKeys[k]
+}
+
+// matchSequence returns whether the keys typed are a sequence bound to an
+// action and, failing that, whether they begin one.
+func (k *pagerKeyMap) matchSequence(typed string) (match, prefix bool) {
+	for _, a := range k.actions() {
+		for _, s := range a.binding.Keys() {
+			if !isKeySequence(s) || !a.binding.Enabled() {
+				continue
+			}
+			match = match || s == typed
+			prefix = prefix || strings.HasPrefix(s, typed) && s != typed
+		}
+	}
+	return match, prefix
+}
+
+// handleKeySequence collects characters typed towards a key sequence. It
+// returns the key to handle, which is the whole sequence once it's typed,
+// or false while it's still being typed. Keys that don't carry a sequence
+// on drop what was typed of it.
+func (m *pagerModel) handleKeySequence(msg tea.KeyMsg) (tea.KeyMsg, bool) {
+	pending := m.pendingKeys
+	m.pendingKeys = ""
+	if msg.Type != tea.KeyRunes || msg.Alt || msg.Paste {
+		return msg, pending == "" || msg.String() != keyEsc
+	}
+
+	typed := pending + msg.String()
+	match, prefix := m.keys.matchSequence(typed)
+	switch {
+	case match:
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(typed)}, true
+	case prefix:
+		m.pendingKeys = typed
+		return msg, false
+	case pending != "":
+		return m.handleKeySequence(msg)
+	}
+	return msg, true
+}
+
+// findAction returns the index of the named action, or -1.
+func findAction(actions []keyAction, name string) int {
+	for i, a := range actions {
+		if strings.EqualFold(a.name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseKeyName returns the key with the given name, as in keyNames, or as
+// Bubble Tea names it.
+func parseKeyName(name string) string {
+	for k, n := range keyNames {
+		if n == name {
+			return k
+		}
+	}
+	return name
+}
+
+// keyName returns the name a key is shown with.
+func keyName(k string) string {
+	if n, ok := keyNames[k]; ok {
+		return n
+	}
+	return k
+}
+
+// keysHelp returns how keys are shown in help.
+func keysHelp(keys []string) string {
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = keyName(k)
+	}
+	return strings.Join(names, "/")
+}
+
+// keyHelpEntry is a line of the help: what keys do, for one action or a few
+// related ones, like moving to the next and previous list item.
+type keyHelpEntry struct {
+	bindings []key.Binding
+	keys     string // instead of the bindings' keys, for keys that aren't bound
+	desc     string // of the actions together, if there are several
+}
+
+// keyHelp returns the keys of the entry, as shown in help.
+func (e keyHelpEntry) keyHelp() string {
+	if e.keys != "" {
+		return e.keys
+	}
+	keys := make([]string, len(e.bindings))
+	for i, b := range e.bindings {
+		keys[i] = b.Help().Key
+	}
+	// Keys with the same modifier are shown with it once, as in ctrl+n/p
+	if i := strings.LastIndex(keys[0], "+"); len(keys) > 1 && i > 0 {
+		modifier := keys[0][:i+1]
+		shared := true
+		for _, k := range keys[1:] {
+			shared = shared && strings.HasPrefix(k, modifier) && !strings.Contains(k, "/")
+		}
+		for j := 1; shared && j < len(keys); j++ {
+			keys[j] = strings.TrimPrefix(keys[j], modifier)
+		}
+	}
+	return strings.Join(keys, "/")
+}
+
+// description returns what the keys of the entry do.
+func (e keyHelpEntry) description() string {
+	if e.desc != "" || len(e.bindings) == 0 {
+		return e.desc
+	}
+	return e.bindings[0].Help().Desc
+}
+
+// helpEntries returns the entries of the help, in the order they're shown,
+// but for leaving.
+func (k pagerKeyMap) helpEntries() []keyHelpEntry {
+	one := func(b key.Binding) keyHelpEntry { return keyHelpEntry{bindings: []key.Binding{b}} }
+	pair := func(a, b key.Binding, desc string) keyHelpEntry {
+		return keyHelpEntry{bindings: []key.Binding{a, b}, desc: desc}
+	}
+	return []keyHelpEntry{
+		one(k.Up),
+		one(k.Down),
+		one(k.PageUp),
+		one(k.PageDown),
+		one(k.HalfPageUp),
+		one(k.HalfPageDown),
+		{keys: k.ScrollLeft.Help().Key + "/" + k.ScrollRight.Help().Key + " ←/→", desc: "scroll left/right"},
+		pair(k.LineStart, k.LineEnd, "line start/end"),
+		one(k.Wrap),
+		one(k.LineNumbers),
+		one(k.Top),
+		one(k.Bottom),
+		one(k.Goto),
+		{keys: "N⏎/NG", desc: "go to line N or slide N"},
+		one(k.TOC),
+		one(k.JumpBack),
+		one(k.GotoDefinition),
+		one(k.Footnote),
+		pair(k.PrevListItem, k.NextListItem, "prev/next list item"),
+		pair(k.PrevParagraph, k.NextParagraph, "prev/next paragraph"),
+		pair(k.PrevHeading, k.NextHeading, "prev/next heading"),
+		pair(k.PrevImage, k.NextImage, "prev/next image"),
+		pair(k.NextSlide, k.PrevSlide, "next/prev slide"),
+		one(k.Fullscreen),
+		one(k.Notes),
+		one(k.ExportSlide),
+		one(k.ExportHTML),
+		one(k.ResetTimer),
+		one(k.AutoAdvance),
+		one(k.Search),
+		one(k.SearchBackward),
+		pair(k.NextSlide, k.PrevMatch, "next/prev match"),
+		one(k.ExportMatches),
+		one(k.ReturnFromSearch),
+		one(k.ToggleDetails),
+		one(k.ToggleComments),
+		one(k.ToggleFold),
+		pair(k.UnfoldAll, k.FoldAll, "unfold/fold all"),
+		one(k.Spotlight),
+		one(k.Copy),
+		one(k.CopyPlainText),
+		one(k.ShowLongLines),
+		one(k.CopySection),
+		one(k.CopyCodeBlock),
+		pair(k.NextLink, k.PrevLink, "select link"),
+		one(k.CopyLink),
+		one(k.OpenLink),
+		one(k.Edit),
+		one(k.CopySnippet),
+		one(k.Reload),
+		one(k.ReloadStyle),
+		one(k.Recent),
+		one(k.Prettify),
+		one(k.FrontmatterTitle),
+		one(k.AutoWatch),
+		one(k.Stats),
+		one(k.WordCount),
+		one(k.Debug),
+		one(k.ToggleRendering),
+		one(k.PickStyle),
+		one(k.CycleStyle),
+		one(k.CompactHelp),
+	}
+}
@@ -4,3 +4,10 @@ const (
 	keyEnter = "enter"
 	keyEsc   = "esc"
 )
+
+// What esc does in the pager, see Config.QuitKeyBehavior.
+const (
+	quitKeyAuto = "auto"
+	quitKeyQuit = "quit"
+	quitKeyBack = "back"
+)
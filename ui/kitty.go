@@ -0,0 +1,378 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	goimage "image"
+	_ "image/gif" // decoders for inline images
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+	_ "golang.org/x/image/webp"
+)
+
+// Inline images are drawn with the Kitty graphics protocol's Unicode
+// placeholders: each image is sent to the terminal once, and shows wherever
+// placeholder characters colored with its ID are printed, so that it
+// scrolls along with the text around it like any other line.
+
+const (
+	kittyPlaceholder = "\U0010EEEE"
+
+	// Marks the line an inline image goes below, around its ID.
+	inlineImageMark = "⁤"
+
+	// Size of the base64 chunks images are sent in.
+	kittyChunkSize = 4096
+
+	// Image IDs are given as 256 colors.
+	maxInlineImages = 255
+
+	// Largest image file loaded, in bytes.
+	maxInlineImageSize = 20 << 20
+
+	// How long fetching a remote image can take.
+	inlineImageTimeout = 5 * time.Second
+
+	// Width of a terminal cell, in pixels, assumed to size images with.
+	// Cells are taken to be twice as tall as they're wide.
+	inlineImageCellWidth = 8
+
+	// How long Kitty graphics commands stay in the view, for Bubble Tea to
+	// write them out with a frame at least once.
+	kittyGraphicsLinger = 250 * time.Millisecond
+)
+
+// Diacritics encoding the row of a placeholder, from Kitty's
+// rowcolumn-diacritics.txt. Images are at most this many rows tall.
+var kittyDiacritics = []rune{
+	0x0305, 0x030D, 0x030E, 0x0310, 0x0312, 0x033D, 0x033E, 0x033F,
+	0x0346, 0x034A, 0x034B, 0x034C, 0x0350, 0x0351, 0x0352, 0x0357,
+	0x035B, 0x0363, 0x0364, 0x0365, 0x0366, 0x0367, 0x0368, 0x0369,
+	0x036A, 0x036B, 0x036C, 0x036D, 0x036E, 0x036F, 0x0483, 0x0484,
+	0x0485, 0x0486, 0x0487,
+}
+
+var inlineImageMarkPattern = regexp.MustCompile(inlineImageMark + `(\d+)` + inlineImageMark)
+
+// inlineImage is an image loaded for showing inline.
+type inlineImage struct {
+	id            int
+	png           []byte
+	width, height int   // in pixels
+	err           error // why it couldn't be loaded, if it couldn't
+	sent          bool
+	placed        [2]int // columns and rows it was last placed at
+}
+
+// Inline images loaded so far, by source, failures included, so that
+// they're only loaded once.
+var inlineImages struct {
+	sync.Mutex
+	bySource map[string]*inlineImage
+}
+
+// kittyGraphicsSupported returns whether the terminal can show images with
+// Unicode placeholders, as Kitty and Ghostty can. Terminal multiplexers
+// get in the way.
+func kittyGraphicsSupported() bool {
+	if lipgloss.ColorProfile() == termenv.Ascii || os.Getenv("TMUX") != "" {
+		return false
+	}
+	return os.Getenv("KITTY_WINDOW_ID") != "" ||
+		os.Getenv("TERM") == "xterm-kitty" ||
+		os.Getenv("TERM_PROGRAM") == "ghostty"
+}
+
+// showsInlineImages returns whether images are shown inline.
+func (m pagerModel) showsInlineImages() bool {
+	return m.common.cfg.InlineImages && utils.IsMarkdownFile(m.currentDocument.Note) && kittyGraphicsSupported()
+}
+
+// loadInlineImage returns the image at the given path or URL, loading it
+// if it hasn't been yet. Images are read without holding the lock, so that
+// a slow download doesn't hold up looking up the others.
+func loadInlineImage(source string) *inlineImage {
+	inlineImages.Lock()
+	img, ok := inlineImages.bySource[source]
+	full := len(inlineImages.bySource) >= maxInlineImages
+	inlineImages.Unlock()
+	if ok {
+		return img
+	}
+
+	img = &inlineImage{}
+	if full {
+		img.err = errors.New("too many images")
+	} else {
+		img.png, img.width, img.height, img.err = readInlineImage(source)
+	}
+
+	inlineImages.Lock()
+	defer inlineImages.Unlock()
+	// Another render may have loaded it in the meantime
+	if loaded, ok := inlineImages.bySource[source]; ok {
+		return loaded
+	}
+	if inlineImages.bySource == nil {
+		inlineImages.bySource = map[string]*inlineImage{}
+	}
+	img.id = len(inlineImages.bySource) + 1
+	if img.id > maxInlineImages && img.err == nil {
+		img.png, img.err = nil, errors.New("too many images")
+	}
+	if img.err != nil {
+		log.Warn("unable to load image", "image", source, "error", img.err)
+	}
+	inlineImages.bySource[source] = img
+	return img
+}
+
+// readInlineImage reads an image from a file or URL, as PNG, which is what
+// Kitty takes.
+func readInlineImage(source string) ([]byte, int, int, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if u, perr := url.Parse(source); perr == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		data, err = fetchInlineImage(source)
+	} else {
+		data, err = readLimited(source)
+	}
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	decoded, format, err := goimage.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("unable to decode image: %w", err)
+	}
+	if format != "png" {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, decoded); err != nil {
+			return nil, 0, 0, fmt.Errorf("unable to encode image: %w", err)
+		}
+		data = buf.Bytes()
+	}
+	size := decoded.Bounds().Size()
+	return data, size.X, size.Y, nil
+}
+
+// readLimited reads a local image file, up to maxInlineImageSize.
+func readLimited(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open image: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+	data, err := io.ReadAll(io.LimitReader(f, maxInlineImageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read image: %w", err)
+	}
+	if len(data) > maxInlineImageSize {
+		return nil, errors.New("image too large")
+	}
+	return data, nil
+}
+
+// fetchInlineImage downloads a remote image, up to maxInlineImageSize.
+func fetchInlineImage(url string) ([]byte, error) {
+	client := http.Client{Timeout: inlineImageTimeout}
+	resp, err := client.Get(url) //nolint:noctx
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch image: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to fetch image: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxInlineImageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch image: %w", err)
+	}
+	if len(data) > maxInlineImageSize {
+		return nil, errors.New("image too large")
+	}
+	return data, nil
+}
+
+// embedImages marks the images alone on their line in the given markdown
+// to be shown inline, replacing each with its text, for rendered output to
+// label the image with. Images that can't be loaded are left as they are.
+func (m pagerModel) embedImages(md string) (string, map[int]*inlineImage) {
+	images := map[int]*inlineImage{}
+	lines := strings.Split(md, "\n")
+	for _, img := range parseImages(md) {
+		line := lines[img.line]
+		if !imageLinePattern.MatchString(line) || strings.HasPrefix(img.src, "data:") {
+			continue
+		}
+		loaded := loadInlineImage(m.resolveLink(img.src))
+		if loaded.err != nil {
+			continue
+		}
+		images[loaded.id] = loaded
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		lines[img.line] = indent + inlineImageMark + strconv.Itoa(loaded.id) + inlineImageMark + img.text()
+	}
+	return strings.Join(lines, "\n"), images
+}
+
+// inlineImageGraphics are the Kitty graphics commands sending and placing
+// inline images, for the view to write out. Images only count as sent once
+// that's happened, since rendered content may be dropped for newer content
+// on the way, see record.
+type inlineImageGraphics struct {
+	commands   string
+	placements []inlineImagePlacement
+}
+
+// inlineImagePlacement is an image placed at a size in cells.
+type inlineImagePlacement struct {
+	img        *inlineImage
+	cols, rows int
+}
+
+// record records the images as sent to the terminal and placed, so that
+// they're neither sent nor placed again until their size changes.
+func (g inlineImageGraphics) record() {
+	inlineImages.Lock()
+	defer inlineImages.Unlock()
+	for _, p := range g.placements {
+		p.img.sent = true
+		p.img.placed = [2]int{p.cols, p.rows}
+	}
+}
+
+// placeImages puts the embedded images in rendered output, below the lines
+// labeling them, fit to the given width and height in cells. It returns the
+// Kitty graphics commands sending and placing the images as well.
+func placeImages(lines []string, images map[int]*inlineImage, width, height int) ([]string, inlineImageGraphics) {
+	var (
+		commands   strings.Builder
+		placements []inlineImagePlacement
+	)
+	for i := len(lines) - 1; i >= 0; i-- {
+		m := inlineImageMarkPattern.FindStringSubmatchIndex(lines[i])
+		if m == nil {
+			continue
+		}
+		id, _ := strconv.Atoi(lines[i][m[2]:m[3]])
+		label := lines[i][:m[0]] + lines[i][m[1]:]
+		img, ok := images[id]
+		if !ok {
+			lines[i] = label
+			continue
+		}
+
+		plain := ansi.Strip(label)
+		margin := len(plain) - len(strings.TrimLeft(plain, " "))
+		if width-2*margin < 1 || height < 1 {
+			lines[i] = label
+			continue
+		}
+		cols, rows := img.fit(width-2*margin, height)
+		commands.WriteString(img.place(cols, rows))
+		placements = append(placements, inlineImagePlacement{img, cols, rows})
+
+		placed := make([]string, 0, rows+1)
+		placed = append(placed, label)
+		for row := range rows {
+			placed = append(placed, strings.Repeat(" ", margin)+kittyPlaceholderRow(id, row, cols))
+		}
+		lines = append(lines[:i], append(placed, lines[i+1:]...)...)
+	}
+	return lines, inlineImageGraphics{commands: commands.String(), placements: placements}
+}
+
+// fit returns the size of the image in cells, as large as it is in pixels
+// but no larger than the given number of columns and rows, keeping its
+// aspect ratio.
+func (img *inlineImage) fit(maxCols, maxRows int) (cols, rows int) {
+	maxRows = min(maxRows, len(kittyDiacritics))
+	ratio := float64(img.height) / float64(max(1, img.width)) / 2 // rows per column
+
+	cols = max(1, min(maxCols, img.width/inlineImageCellWidth))
+	rows = max(1, int(math.Round(float64(cols)*ratio)))
+	if rows > maxRows {
+		rows = max(1, maxRows)
+		cols = max(1, min(cols, int(math.Round(float64(rows)/ratio))))
+	}
+	return cols, rows
+}
+
+// place returns the commands sending the image to the terminal, if it
+// hasn't been already, and setting how many columns and rows its
+// placeholders take up, if they've changed.
+func (img *inlineImage) place(cols, rows int) string {
+	inlineImages.Lock()
+	defer inlineImages.Unlock()
+
+	var b strings.Builder
+	if !img.sent {
+		data := base64.StdEncoding.EncodeToString(img.png)
+		for i := 0; i < len(data); i += kittyChunkSize {
+			chunk := data[i:min(i+kittyChunkSize, len(data))]
+			more := 0
+			if i+kittyChunkSize < len(data) {
+				more = 1
+			}
+			if i == 0 {
+				fmt.Fprintf(&b, "\x1b_Ga=t,f=100,q=2,i=%d,m=%d;%s\x1b\\", img.id, more, chunk)
+			} else {
+				fmt.Fprintf(&b, "\x1b_Gm=%d;%s\x1b\\", more, chunk)
+			}
+		}
+	}
+	if !img.sent || img.placed != [2]int{cols, rows} {
+		fmt.Fprintf(&b, "\x1b_Ga=p,U=1,q=2,i=%d,c=%d,r=%d\x1b\\", img.id, cols, rows)
+	}
+	return b.String()
+}
+
+// kittyGraphicsSentMsg drops Kitty graphics commands from the view once
+// they've been written out.
+type kittyGraphicsSentMsg struct{ gen int }
+
+// lingerKittyGraphics keeps the given Kitty graphics commands in the view
+// until they've been written out with a frame, recording the images as sent.
+// They're kept there only briefly, as they're written out again with every
+// frame the line they're on changes in.
+func (m *pagerModel) lingerKittyGraphics(graphics inlineImageGraphics) tea.Cmd {
+	graphics.record()
+	m.kittyGraphics += graphics.commands
+	m.kittyGraphicsGen++
+	gen := m.kittyGraphicsGen
+	return tea.Tick(kittyGraphicsLinger, func(time.Time) tea.Msg {
+		return kittyGraphicsSentMsg{gen}
+	})
+}
+
+// kittyPlaceholderRow returns a row of the placeholders showing an image:
+// the image's ID as their color, and the row in a diacritic on the first
+// one, the rest following on from it.
+func kittyPlaceholderRow(id, row, cols int) string {
+	return fmt.Sprintf("\x1b[38;5;%dm", id) +
+		kittyPlaceholder + string(kittyDiacritics[row]) + string(kittyDiacritics[0]) +
+		strings.Repeat(kittyPlaceholder, cols-1) +
+		"\x1b[39m"
+}
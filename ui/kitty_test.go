@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInlineImageFit(t *testing.T) {
+	for _, tt := range []struct {
+		name          string
+		width, height int // in pixels
+		cols, rows    int // room
+		wantCols      int
+		wantRows      int
+	}{
+		{"small", 80, 40, 100, 50, 10, 3},
+		{"wide", 1600, 400, 60, 50, 60, 8},
+		{"tall", 400, 1600, 60, 20, 10, 20},
+		{"taller than the diacritics go", 100, 10000, 60, 100, 1, len(kittyDiacritics)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			img := &inlineImage{width: tt.width, height: tt.height}
+			cols, rows := img.fit(tt.cols, tt.rows)
+			if cols != tt.wantCols || rows != tt.wantRows {
+				t.Errorf("expected %dx%d cells, got %dx%d", tt.wantCols, tt.wantRows, cols, rows)
+			}
+		})
+	}
+}
+
+func TestPlaceImages(t *testing.T) {
+	img := &inlineImage{id: 7, png: []byte("png"), width: 160, height: 40}
+	lines := []string{
+		"  Before",
+		"  " + inlineImageMark + "7" + inlineImageMark + "A picture",
+		"  After",
+	}
+	got, sent := placeImages(lines, map[int]*inlineImage{7: img}, 80, 24)
+
+	want := []string{
+		"  Before",
+		"  A picture",
+		"  " + kittyPlaceholderRow(7, 0, 20),
+		"  " + kittyPlaceholderRow(7, 1, 20),
+		"  " + kittyPlaceholderRow(7, 2, 20),
+		"  After",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected\n%q\ngot\n%q", want, got)
+	}
+
+	// The image is sent once, and placed again only when its size changes
+	if !strings.Contains(sent.commands, "a=t,f=100,q=2,i=7,m=0;cG5n") || !strings.Contains(sent.commands, "a=p,U=1,q=2,i=7,c=20,r=3") {
+		t.Errorf("expected the image to be sent and placed, got %q", sent.commands)
+	}
+	place := func() string {
+		_, sent := placeImages([]string{inlineImageMark + "7" + inlineImageMark + "A picture"}, map[int]*inlineImage{7: img}, 80, 24)
+		return sent.commands
+	}
+	if again := place(); again != sent.commands {
+		t.Errorf("expected the image to be sent again until it's written out, got %q", again)
+	}
+	sent.record()
+	if again := place(); again != "" {
+		t.Errorf("expected nothing to be sent again, got %q", again)
+	}
+}
+
+func TestKittyGraphicsLinger(t *testing.T) {
+	graphics := func(commands string) inlineImageGraphics {
+		return inlineImageGraphics{commands: commands}
+	}
+
+	m := newPagerModel(&commonModel{})
+	m.setSize(80, 10)
+	m, _ = m.update(contentRenderedMsg{content: "text", graphics: graphics("\x1b_Gi=1\x1b\\")})
+	if m.kittyGraphics != "\x1b_Gi=1\x1b\\" {
+		t.Fatalf("expected the graphics to wait to be written out, got %q", m.kittyGraphics)
+	}
+
+	// Only the latest graphics are dropped once written out
+	stale := kittyGraphicsSentMsg{m.kittyGraphicsGen}
+	m, _ = m.update(contentRenderedMsg{content: "text", graphics: graphics("\x1b_Gi=2\x1b\\")})
+	if m, _ = m.update(stale); m.kittyGraphics == "" {
+		t.Error("expected graphics not yet written out to be kept")
+	}
+	if m, _ = m.update(kittyGraphicsSentMsg{m.kittyGraphicsGen}); m.kittyGraphics != "" {
+		t.Errorf("expected the graphics to be dropped, got %q", m.kittyGraphics)
+	}
+
+	// Images of parts of a superseded render are left to be sent again
+	img := &inlineImage{id: 3, png: []byte("png"), width: 160, height: 40}
+	m.streamID = 2
+	m, _ = m.update(contentRenderedMsg{
+		content:  "text",
+		graphics: inlineImageGraphics{commands: "\x1b_Gi=3\x1b\\", placements: []inlineImagePlacement{{img, 20, 3}}},
+		stream:   &renderStream{id: 1, next: 2},
+	})
+	if img.sent || m.kittyGraphics != "" {
+		t.Errorf("expected the image of a stale part not to count as sent")
+	}
+	m, _ = m.update(contentRenderedMsg{
+		content:  "text",
+		graphics: inlineImageGraphics{commands: "\x1b_Gi=3\x1b\\", placements: []inlineImagePlacement{{img, 20, 3}}},
+	})
+	if !img.sent || img.placed != [2]int{20, 3} {
+		t.Errorf("expected the image to be sent once its graphics are written out")
+	}
+}
@@ -0,0 +1,21 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// scrollPastGutter scrolls rendered content horizontally by the given
+// offset, leaving the line number gutter of the given width in place.
+func scrollPastGutter(content string, gutter, offset int) string {
+	if offset <= 0 {
+		return content
+	}
+
+	lines := strings.Split(content, "\n")
+	for i, l := range lines {
+		lines[i] = ansi.Truncate(l, gutter, "") + ansi.TruncateLeft(l, gutter+offset, "")
+	}
+	return strings.Join(lines, "\n")
+}
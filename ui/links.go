@@ -0,0 +1,197 @@
+package ui
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var (
+	inlineLinkDestPattern = regexp.MustCompile(`(!?)\[([^\]]*)\]\(\s*<?([^)\s>]*)>?(?:\s+["'(][^)]*)?\)`)
+	refLinkPattern        = regexp.MustCompile(`(!?)\[([^\]]+)\]\[([^\]]*)\]`)
+	autolinkPattern       = regexp.MustCompile(`<((?:https?|ftp|mailto):[^>\s]+)>`)
+	linkDefPattern        = regexp.MustCompile(`^ {0,3}\[([^\]]+)\]:\s*<?(\S+?)>?(?:\s+.*)?$`)
+)
+
+// link is a link in a markdown document. Images aren't links.
+type link struct {
+	text string // plain text of the link, as rendered
+	url  string // destination, as written
+	line int    // 0-based line in the source document
+}
+
+// parseLinks returns the inline, reference and autolinks in the given
+// markdown, in document order, skipping anything inside fenced code blocks.
+func parseLinks(md string) []link {
+	var (
+		lines   = strings.Split(md, "\n")
+		defs    = make(map[string]string)
+		inFence bool
+	)
+
+	// Reference definitions can come after the links using them
+	for _, line := range lines {
+		if m := linkDefPattern.FindStringSubmatch(line); m != nil {
+			defs[strings.ToLower(m[1])] = m[2]
+		}
+	}
+
+	var links []link
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence || linkDefPattern.MatchString(line) {
+			continue
+		}
+
+		type match struct {
+			pos int
+			link
+		}
+		var found []match
+		for _, m := range inlineLinkDestPattern.FindAllStringSubmatchIndex(line, -1) {
+			if m[3] > m[2] {
+				continue // image
+			}
+			found = append(found, match{m[0], link{text: line[m[4]:m[5]], url: line[m[6]:m[7]]}})
+		}
+		for _, m := range refLinkPattern.FindAllStringSubmatchIndex(line, -1) {
+			ref := line[m[6]:m[7]]
+			if ref == "" {
+				ref = line[m[4]:m[5]]
+			}
+			dest, ok := defs[strings.ToLower(ref)]
+			if m[3] > m[2] || !ok {
+				continue
+			}
+			found = append(found, match{m[0], link{text: line[m[4]:m[5]], url: dest}})
+		}
+		for _, m := range autolinkPattern.FindAllStringSubmatchIndex(line, -1) {
+			dest := line[m[2]:m[3]]
+			found = append(found, match{m[0], link{text: dest, url: dest}})
+		}
+
+		sort.SliceStable(found, func(a, b int) bool { return found[a].pos < found[b].pos })
+		for _, f := range found {
+			f.line = i
+			f.text = strings.Join(strings.Fields(inlineMarkupReplacer.Replace(f.text)), " ")
+			links = append(links, f.link)
+		}
+	}
+
+	return links
+}
+
+// findLinkLines locates the given links in rendered output. See
+// findTextLines.
+func findLinkLines(links []link, rendered []string) []int {
+	texts := make([]string, len(links))
+	for i, l := range links {
+		texts[i] = l.text
+		if texts[i] == "" {
+			texts[i] = l.url
+		}
+	}
+	return findTextLines(texts, rendered)
+}
+
+// selectLink selects the next link or, going backward, the previous one,
+// scrolling it into view. With no link selected yet, it starts from the top
+// of the viewport.
+func (m *pagerModel) selectLink(backward bool) tea.Cmd {
+	links := parseLinks(m.currentMarkdown())
+	if len(links) == 0 {
+		return m.showStatusMessage(pagerStatusMessage{"No links", true})
+	}
+	lines := findLinkLines(links, m.renderedLines())
+
+	switch {
+	case m.selectedLink >= 0 && m.selectedLink < len(links) && backward:
+		m.selectedLink = (m.selectedLink - 1 + len(links)) % len(links)
+	case m.selectedLink >= 0 && m.selectedLink < len(links):
+		m.selectedLink = (m.selectedLink + 1) % len(links)
+	case backward:
+		m.selectedLink = len(links) - 1
+		for i := len(lines) - 1; i >= 0; i-- {
+			if lines[i] >= 0 && lines[i] < m.viewport.YOffset+m.viewport.Height {
+				m.selectedLink = i
+				break
+			}
+		}
+	default:
+		m.selectedLink = 0
+		for i, line := range lines {
+			if line >= m.viewport.YOffset {
+				m.selectedLink = i
+				break
+			}
+		}
+	}
+
+	var cmds []tea.Cmd
+	if line := lines[m.selectedLink]; line >= 0 &&
+		(line < m.viewport.YOffset || line >= m.viewport.YOffset+m.viewport.Height) {
+		cmds = append(cmds, m.scrollTo(line))
+	}
+	l := links[m.selectedLink]
+	cmds = append(cmds, m.showStatusMessage(pagerStatusMessage{
+		message: fmt.Sprintf("Link %d/%d: %s → %s", m.selectedLink+1, len(links), l.text, l.url),
+	}))
+	return tea.Batch(cmds...)
+}
+
+// currentLink returns the selected link, if any.
+func (m pagerModel) currentLink() (link, bool) {
+	links := parseLinks(m.currentMarkdown())
+	if m.selectedLink < 0 || m.selectedLink >= len(links) {
+		return link{}, false
+	}
+	return links[m.selectedLink], true
+}
+
+// yankLink copies the URL of the selected link.
+func (m *pagerModel) yankLink() tea.Cmd {
+	l, ok := m.currentLink()
+	if !ok {
+		return m.showStatusMessage(pagerStatusMessage{"No link selected, select one with ctrl+n", true})
+	}
+
+	dest := l.url
+	if m.common.cfg.ResolveRelativeLinks {
+		dest = m.resolveLink(dest)
+	}
+	return m.copyToClipboard(dest, "Copied "+dest)
+}
+
+// resolveLink resolves a link relative to the document into an absolute
+// path. URLs, anchors and absolute paths are left as they are.
+func (m pagerModel) resolveLink(dest string) string {
+	if u, err := url.Parse(dest); err != nil || u.Scheme != "" || strings.HasPrefix(dest, "#") {
+		return dest
+	}
+
+	path, fragment, _ := strings.Cut(dest, "#")
+	if unescaped, err := url.PathUnescape(path); err == nil {
+		path = unescaped
+	}
+	if filepath.IsAbs(path) {
+		return dest
+	}
+
+	abs, err := filepath.Abs(filepath.Join(m.localDir(), path))
+	if err != nil {
+		return dest
+	}
+	if fragment != "" {
+		abs += "#" + fragment
+	}
+	return abs
+}
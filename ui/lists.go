@@ -0,0 +1,193 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Width of one level of list indentation in rendered output.
+const listIndentWidth = 2
+
+var (
+	listItemTextPattern = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	taskMarkerPattern   = regexp.MustCompile(`^\[[ xX]\]\s*`)
+	inlineLinkPattern   = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+
+	// Bullets and marker colors for each nesting level, repeating for
+	// deeper levels.
+	listBullets      = []string{"•", "◦", "▪", "▹"}
+	listMarkerStyles = []lipgloss.Style{
+		lipgloss.NewStyle().Foreground(fuchsia),
+		lipgloss.NewStyle().Foreground(yellowGreen),
+		lipgloss.NewStyle().Foreground(brightGray),
+		lipgloss.NewStyle().Foreground(dullFuchsia),
+	}
+)
+
+// listItem is an item of a (possibly nested) list in a markdown document.
+type listItem struct {
+	level   int    // nesting level, 0 for top-level items
+	ordered bool   // whether it's part of a numbered list
+	task    bool   // whether it's a task list item
+	text    string // plain text of the item's first line
+	line    int    // 0-based line in the source document
+}
+
+// parseListItems returns the list items in the given markdown, skipping
+// anything inside fenced code blocks.
+func parseListItems(md string) []listItem {
+	var (
+		items   []listItem
+		indents []int // indentation of the enclosing items' markers
+		inFence bool
+	)
+
+	for i, line := range strings.Split(md, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		// Lists end at the first unindented line that's not an item
+		if trimmed != "" && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") &&
+			!listItemTextPattern.MatchString(line) {
+			indents = nil
+			continue
+		}
+
+		m := listItemTextPattern.FindStringSubmatch(line)
+		if m == nil || thematicBreakPattern.MatchString(line) {
+			continue
+		}
+
+		indent := len(strings.ReplaceAll(m[1], "\t", "    "))
+		for len(indents) > 0 && indents[len(indents)-1] >= indent {
+			indents = indents[:len(indents)-1]
+		}
+
+		text := m[3]
+		task := taskMarkerPattern.MatchString(text)
+		text = taskMarkerPattern.ReplaceAllString(text, "")
+		text = inlineLinkPattern.ReplaceAllString(text, "$1")
+
+		items = append(items, listItem{
+			level:   len(indents),
+			ordered: m[2][0] >= '0' && m[2][0] <= '9',
+			task:    task,
+			text:    strings.Join(strings.Fields(inlineMarkupReplacer.Replace(text)), " "),
+			line:    i,
+		})
+		indents = append(indents, indent)
+	}
+
+	return items
+}
+
+// findListItemLines locates the given list items in rendered output. See
+// findTextLines.
+func findListItemLines(items []listItem, rendered []string) []int {
+	texts := make([]string, len(items))
+	for i, item := range items {
+		texts[i] = item.text
+	}
+	return findTextLines(texts, rendered)
+}
+
+// styleListItems restyles list items in rendered output, giving each nesting
+// level its own bullet and marker color, and indenting levels consistently.
+func styleListItems(md string, lines []string) []string {
+	items := parseListItems(md)
+	offsets := findListItemLines(items, lines)
+
+	base := -1
+	for i, item := range items {
+		start := offsets[i]
+		if start < 0 || item.task {
+			continue
+		}
+
+		plain := ansi.Strip(lines[start])
+		lead := len(plain) - len(strings.TrimLeft(plain, " "))
+		fields := strings.Fields(plain)
+		if len(fields) == 0 {
+			continue
+		}
+		marker := fields[0]
+		if item.level == 0 {
+			base = lead
+		}
+		if base < 0 {
+			continue
+		}
+
+		if !item.ordered {
+			marker = listBullets[item.level%len(listBullets)]
+		}
+		style := listMarkerStyles[item.level%len(listMarkerStyles)]
+		newLead := base + item.level*listIndentWidth
+		rest := ansi.TruncateLeft(lines[start], lead+ansi.StringWidth(fields[0]), "")
+		lines[start] = strings.Repeat(" ", newLead) + style.Render(marker) + rest
+
+		// Keep wrapped lines of the item aligned with its text
+		delta := newLead - lead
+		if delta == 0 {
+			continue
+		}
+		for j := start + 1; j < len(lines); j++ {
+			if (i+1 < len(offsets) && j == offsets[i+1]) || strings.TrimSpace(ansi.Strip(lines[j])) == "" {
+				break
+			}
+			if delta > 0 {
+				lines[j] = strings.Repeat(" ", delta) + lines[j]
+			} else {
+				lines[j] = ansi.TruncateLeft(lines[j], -delta, "")
+			}
+		}
+	}
+
+	return lines
+}
+
+// nextListItem scrolls to the next top-level list item below the top of the
+// viewport or, going backward, the previous one above it.
+func (m *pagerModel) nextListItem(backward bool) tea.Cmd {
+	var tops []listItem
+	for _, item := range parseListItems(m.currentMarkdown()) {
+		if item.level == 0 {
+			tops = append(tops, item)
+		}
+	}
+	offsets := findListItemLines(tops, m.renderedLines())
+
+	target := -1
+	for _, line := range offsets {
+		if line < 0 {
+			continue
+		}
+		if backward && line < m.viewport.YOffset {
+			target = line
+		}
+		if !backward && line > m.viewport.YOffset {
+			target = line
+			break
+		}
+	}
+	if target < 0 {
+		return nil
+	}
+
+	m.viewport.SetYOffset(target)
+	if m.viewport.HighPerformanceRendering {
+		return viewport.Sync(m.viewport)
+	}
+	return nil
+}
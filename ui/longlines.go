@@ -0,0 +1,197 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/log"
+)
+
+// cutLongLines cuts lines longer than maxLen bytes short, at a rune
+// boundary, noting how much was left out. A maxLen of zero leaves everything
+// as it is.
+func cutLongLines(s string, maxLen int) string {
+	if maxLen <= 0 || len(s) <= maxLen {
+		return s
+	}
+
+	var b strings.Builder
+	for len(s) > 0 {
+		line, rest, found := strings.Cut(s, "\n")
+		s = rest
+
+		if len(line) > maxLen {
+			end := maxLen
+			for end > 0 && !isRuneStart(line[end]) {
+				end--
+			}
+			fmt.Fprintf(&b, "%s … (%d more characters)", line[:end], utf8.RuneCountInString(line[end:]))
+		} else {
+			b.WriteString(line)
+		}
+		if found {
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// Words longer than this many characters are broken up before rendering, as
+// the time glamour takes to wrap a word grows with the square of its length.
+const longWordLength = 1000
+
+// Room left, when breaking up long words, for the margins and indentation
+// of what they're in.
+const longWordIndent = 8
+
+// Definitions of link references, like [glow]: https://github.com/...
+var linkDefinitionPattern = regexp.MustCompile(`^ {0,3}\[[^\]]+\]:`)
+
+// breakLongWords breaks words longer than longWordLength into pieces that
+// fit the given width, so that they're wrapped at the width, quickly, rather
+// than slowly, and past it. Only prose is broken up: code blocks, fenced or
+// indented, code spans, link destinations and link reference definitions
+// are left as they are, as is everything for a width of zero.
+func breakLongWords(md string, width int) string {
+	if width <= 0 || len(md) <= longWordLength {
+		return md
+	}
+
+	lines := strings.Split(md, "\n")
+	for i := 0; i < len(lines); i++ {
+		if m := fenceOpenPattern.FindStringSubmatch(lines[i]); m != nil {
+			if end := closingFence(lines, i, m[2]); end >= 0 {
+				i = end
+				continue
+			}
+			break
+		}
+		if len(lines[i]) <= longWordLength ||
+			strings.HasPrefix(lines[i], "    ") || strings.HasPrefix(lines[i], "\t") ||
+			linkDefinitionPattern.MatchString(lines[i]) {
+			continue
+		}
+		lines[i] = breakLongProse(lines[i], max(1, width-longWordIndent))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// breakLongProse breaks the long words of a line into pieces of the given
+// number of runes, skipping over code spans and link destinations.
+func breakLongProse(line string, width int) string {
+	var (
+		b     strings.Builder
+		plain int // start of the prose not yet written
+	)
+	keep := func(start, end int) {
+		b.WriteString(breakProseWords(line[plain:start], width))
+		b.WriteString(line[start:end])
+		plain = end
+	}
+	for i := 0; i < len(line); {
+		switch {
+		case line[i] == '`':
+			ticks := len(line[i:]) - len(strings.TrimLeft(line[i:], "`"))
+			end := strings.Index(line[i+ticks:], line[i:i+ticks])
+			if end < 0 {
+				i += ticks
+				continue
+			}
+			keep(i, i+2*ticks+end)
+			i = plain
+		case strings.HasPrefix(line[i:], "]("):
+			end := strings.IndexByte(line[i:], ')')
+			if end < 0 {
+				i += 2
+				continue
+			}
+			keep(i, i+end+1)
+			i = plain
+		default:
+			i++
+		}
+	}
+	b.WriteString(breakProseWords(line[plain:], width))
+	return b.String()
+}
+
+// breakProseWords breaks the words of prose longer than longWordLength into
+// pieces of the given number of runes.
+func breakProseWords(text string, width int) string {
+	if len(text) <= longWordLength {
+		return text
+	}
+	words := strings.Split(text, " ")
+	for i, word := range words {
+		if utf8.RuneCountInString(word) > longWordLength {
+			words[i] = breakWord(word, width)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// breakWord splits a word into pieces of the given number of runes,
+// separated by spaces.
+func breakWord(word string, width int) string {
+	var (
+		b     strings.Builder
+		runes int
+	)
+	for _, r := range word {
+		if runes == width {
+			b.WriteByte(' ')
+			runes = 0
+		}
+		b.WriteRune(r)
+		runes++
+	}
+	return b.String()
+}
+
+// countLongLines returns the number of lines cutLongLines would cut short.
+func countLongLines(s string, maxLen int) int {
+	if maxLen <= 0 || len(s) <= maxLen {
+		return 0
+	}
+
+	var n int
+	for len(s) > 0 {
+		line, rest, _ := strings.Cut(s, "\n")
+		s = rest
+		if len(line) > maxLen {
+			n++
+		}
+	}
+	return n
+}
+
+// isRuneStart returns whether b is the first byte of a UTF-8 encoded rune.
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+// warnAboutLongLines lets the user know, once per document, when lines of
+// a code file were cut short, and how to see them in full.
+func (m *pagerModel) warnAboutLongLines() tea.Cmd {
+	if m.longLinesHit || m.fullLongLines || utils.IsMarkdownFile(m.currentDocument.Note) {
+		return nil
+	}
+	n := countLongLines(m.currentDocument.Body, m.common.cfg.MaxLineLength)
+	if n == 0 {
+		return nil
+	}
+	m.longLinesHit = true
+
+	log.Info("cut long lines short", "lines", n, "max", m.common.cfg.MaxLineLength)
+	lines := "lines"
+	if n == 1 {
+		lines = "line"
+	}
+	return m.showStatusMessage(pagerStatusMessage{
+		message: fmt.Sprintf("Cut %d long %s short, press ! to show in full", n, lines),
+	})
+}
@@ -0,0 +1,153 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestRenderCodeWithVeryLongLine(t *testing.T) {
+	// A 4MB minified JSON document on a single line
+	line := `{"items":[` + strings.Repeat(`{"id":1,"name":"glow"},`, 4<<20/23) + `{}]}`
+	path := filepath.Join(t.TempDir(), "data.json")
+	if err := os.WriteFile(path, []byte(line), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := Config{Path: path, GlamourEnabled: true, GlamourStyle: "dark", MaxLineLength: 10000}
+	config = cfg
+	t.Cleanup(func() { config = Config{} })
+
+	m := newModel(cfg, "").(model)
+	m.pager.setSize(80, 24)
+	m.pager.currentDocument.Body = line
+
+	done := make(chan string, 1)
+	go func() {
+		out, err := glamourRender(m.pager, line)
+		if err != nil {
+			t.Error(err)
+		}
+		done <- out
+	}()
+
+	select {
+	case out := <-done:
+		if !strings.Contains(ansi.Strip(out), fmt.Sprintf("(%d more characters)", len(line)-10000)) {
+			t.Error("expected the line to be cut short")
+		}
+		if len(out) > 1<<20 {
+			t.Errorf("expected the cut line to render small, got %d bytes", len(out))
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("rendering a very long line took too long")
+	}
+
+	if cmd := m.pager.warnAboutLongLines(); cmd == nil {
+		t.Error("expected a warning about the long line")
+	}
+	if !strings.Contains(m.pager.statusMessage, "press !") {
+		t.Errorf("expected the warning to mention how to show the line, got %q", m.pager.statusMessage)
+	}
+}
+
+func TestCutLongLines(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     string
+		maxLen int
+		want   string
+	}{
+		{name: "short", in: "abc\ndef", maxLen: 5, want: "abc\ndef"},
+		{name: "disabled", in: "abcdefgh", maxLen: 0, want: "abcdefgh"},
+		{name: "long", in: "abcdefgh\nij\n", maxLen: 5, want: "abcde … (3 more characters)\nij\n"},
+		{name: "rune boundary", in: "aé€b", maxLen: 4, want: "aé … (2 more characters)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cutLongLines(tt.in, tt.maxLen); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+			if want := strings.Count(tt.want, "…"); countLongLines(tt.in, tt.maxLen) != want {
+				t.Errorf("expected %d long lines, got %d", want, countLongLines(tt.in, tt.maxLen))
+			}
+		})
+	}
+}
+
+func TestRenderProseWithVeryLongLine(t *testing.T) {
+	line := strings.Repeat("0123456789", 5000)
+	body := "# Data\n\n" + line + "\n\n```\n" + line + "\n```\n"
+
+	cfg := Config{GlamourEnabled: true, GlamourStyle: "dark", GlamourMaxWidth: 80}
+	config = cfg
+	t.Cleanup(func() { config = Config{} })
+
+	m := newModel(cfg, "").(model)
+	m.common.width, m.common.height = 80, 24
+	m.pager.setSize(80, 24)
+	m.pager.currentDocument = markdown{Note: "data.md", Body: body}
+
+	start := time.Now()
+	out, err := glamourRender(m.pager, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("rendering a very long line took %v", elapsed)
+	}
+	m.pager.setContent(out)
+
+	// The line is wrapped in prose, and scrolled sideways in code
+	lines := strings.Split(out, "\n")
+	if wrapped := lines[len(lines)/4]; stringWidth(wrapped) > 80 {
+		t.Errorf("expected the line to be wrapped, got one %d wide", stringWidth(wrapped))
+	}
+	if m.pager.contentWidth < len(line) {
+		t.Errorf("expected the code block to keep the line whole, got content %d wide", m.pager.contentWidth)
+	}
+
+	var b strings.Builder
+	m.pager.statusBarView(&b)
+	if width := stringWidth(b.String()); width != 80 {
+		t.Errorf("expected the status bar to be 80 wide, got %d", width)
+	}
+	m.pager.xOffset = 40000
+	for i, line := range strings.Split(m.pager.View(), "\n") {
+		if width := ansi.StringWidth(line); width > 80 {
+			t.Errorf("expected line %d of the view to fit, got one %d wide", i, width)
+		}
+	}
+}
+
+func TestBreakLongWords(t *testing.T) {
+	long := strings.Repeat("a", 1200)
+	url := "https://example.com/" + long
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "prose", in: "see " + long, want: "see " + breakWord(long, 72)},
+		{name: "link", in: "[a link](" + url + ") and " + long, want: "[a link](" + url + ") and " + breakWord(long, 72)},
+		{name: "image", in: "![data](data:image/png;base64," + long + ")", want: "![data](data:image/png;base64," + long + ")"},
+		{name: "code span", in: "run `" + long + "` or ``" + long + "``", want: "run `" + long + "` or ``" + long + "``"},
+		{name: "indented code", in: "    " + long, want: "    " + long},
+		{name: "link definition", in: "[ref]: " + url, want: "[ref]: " + url},
+		{name: "fenced code", in: "```\n" + long + "\n```", want: "```\n" + long + "\n```"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := breakLongWords(tt.in, 80); got != tt.want {
+				t.Errorf("expected %d spaces, got %d", strings.Count(tt.want, " "), strings.Count(got, " "))
+			}
+		})
+	}
+}
@@ -23,6 +23,15 @@ type markdown struct {
 	// field is ephemeral, and should only be referenced during filtering.
 	filterValue string
 
+	// Contents of the document exactly as they were read, before any of the
+	// transformations we apply for display, such as removing front matter.
+	// This is what gets copied to the clipboard.
+	source string
+
+	// Offset added to displayed line numbers, for documents that are
+	// fragments of a larger file.
+	lineOffset int
+
 	Body    string
 	Note    string
 	Modtime time.Time
@@ -0,0 +1,572 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// Mermaid diagrams in ```mermaid fences are drawn as text with
+// Config.RenderMermaid, by Config.MermaidCommand if set and otherwise by
+// drawMermaid, which knows simple flowcharts and sequence diagrams. Diagrams
+// that can't be drawn are shown as they're written.
+
+// How long Config.MermaidCommand gets to draw a diagram.
+const mermaidCommandTimeout = 10 * time.Second
+
+// Language of the code blocks drawn diagrams are shown in, which keeps them
+// from being highlighted.
+const mermaidDrawnLanguage = "text"
+
+var (
+	errMermaidUnsupported = errors.New("unsupported mermaid diagram")
+
+	flowchartHeaderPattern = regexp.MustCompile(`^(?:graph|flowchart)(?:\s+(TB|TD|BT|LR|RL))?\s*;?$`)
+	flowNodePattern        = regexp.MustCompile(`^(\w+)\s*(?:\[\[(.*?)\]\]|\(\[(.*?)\]\)|\[\((.*?)\)\]|\(\((.*?)\)\)|\[(.*?)\]|\((.*?)\)|\{(.*?)\}|>(.*?)\])?`)
+	flowEdgePattern        = regexp.MustCompile(`^(?:(-{2,}>|-{3,}|-\.+->|-\.+-|={2,}>|={3,})\s*(?:\|([^|]*)\|)?|--\s*([^->|][^>]*?)\s*-{2,}>|==\s*([^=>|][^>]*?)\s*={2,}>|-\.\s*([^.>|][^>]*?)\s*\.+->)\s*`)
+
+	sequenceParticipantPattern = regexp.MustCompile(`^(?:participant|actor)\s+(\S+)(?:\s+as\s+(.+))?$`)
+	sequenceMessagePattern     = regexp.MustCompile(`^([^\s:>-]+)\s*(-{1,2}(?:>>|>|x|\)))\s*[+-]?([^\s:]+)\s*:\s*(.*)$`)
+)
+
+// mermaidDrawings caches diagrams drawn by Config.MermaidCommand, by
+// command and diagram, which documents are rendered again with on every
+// resize.
+var mermaidDrawings struct {
+	sync.Mutex
+	byDiagram map[[2]string]string
+}
+
+// renderMermaidBlocks replaces the mermaid diagrams in the given markdown
+// with code blocks drawing them as text, by the given command if there's
+// one. Diagrams that can't be drawn are left as they are.
+func renderMermaidBlocks(md, command string) string {
+	if !strings.Contains(md, "mermaid") {
+		return md
+	}
+
+	lines := strings.Split(md, "\n")
+	out := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); i++ {
+		m := fenceOpenPattern.FindStringSubmatch(lines[i])
+		if m == nil {
+			out = append(out, lines[i])
+			continue
+		}
+		end := closingFence(lines, i, m[2])
+		if end < 0 {
+			out = append(out, lines[i:]...)
+			break
+		}
+		if info := strings.Fields(m[3]); len(info) == 0 || info[0] != "mermaid" {
+			out = append(out, lines[i:end+1]...)
+			i = end
+			continue
+		}
+
+		diagram := strings.Join(lines[i+1:end], "\n")
+		drawn, err := drawMermaidWith(command, diagram)
+		if err != nil {
+			log.Debug("unable to draw mermaid diagram, showing it as is", "line", i+1, "error", err)
+			out = append(out, lines[i:end+1]...)
+			i = end
+			continue
+		}
+		drawnLines := strings.Split(strings.TrimRight(drawn, "\n"), "\n")
+		fence := normalizedFence(drawnLines, m[2], mermaidDrawnLanguage)
+		out = append(out, m[1]+fence+mermaidDrawnLanguage)
+		out = append(out, drawnLines...)
+		out = append(out, m[1]+fence)
+		i = end
+	}
+	return strings.Join(out, "\n")
+}
+
+// drawMermaidWith draws a mermaid diagram as text by the given command, or
+// by drawMermaid if there's none.
+func drawMermaidWith(command, diagram string) (string, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return drawMermaid(diagram)
+	}
+
+	key := [2]string{command, diagram}
+	mermaidDrawings.Lock()
+	drawn, ok := mermaidDrawings.byDiagram[key]
+	mermaidDrawings.Unlock()
+	if ok {
+		return drawn, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mermaidCommandTimeout)
+	defer cancel()
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...) //nolint:gosec
+	cmd.Stdin = strings.NewReader(diagram)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("unable to run %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	if strings.TrimSpace(string(out)) == "" {
+		return "", fmt.Errorf("%s drew nothing", args[0])
+	}
+
+	mermaidDrawings.Lock()
+	if mermaidDrawings.byDiagram == nil {
+		mermaidDrawings.byDiagram = map[[2]string]string{}
+	}
+	mermaidDrawings.byDiagram[key] = string(out)
+	mermaidDrawings.Unlock()
+	return string(out), nil
+}
+
+// drawMermaid draws a flowchart or sequence diagram as text.
+func drawMermaid(diagram string) (string, error) {
+	var lines []string
+	for _, line := range strings.Split(diagram, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "%%") {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return "", errMermaidUnsupported
+	}
+
+	// Flowcharts can go on on the line they start on
+	header, rest, _ := strings.Cut(lines[0], ";")
+	header = strings.TrimSpace(header)
+	if rest = strings.TrimSpace(rest); rest != "" {
+		lines = append([]string{header, rest}, lines[1:]...)
+	}
+
+	switch {
+	case flowchartHeaderPattern.MatchString(header):
+		f, err := parseFlowchart(lines[1:])
+		if err != nil {
+			return "", err
+		}
+		direction := flowchartHeaderPattern.FindStringSubmatch(header)[1]
+		return f.draw(direction == "LR" || direction == "RL"), nil
+	case header == "sequenceDiagram":
+		s, err := parseSequenceDiagram(lines[1:])
+		if err != nil {
+			return "", err
+		}
+		return s.draw(), nil
+	}
+	return "", fmt.Errorf("%w: %s", errMermaidUnsupported, header)
+}
+
+// textCanvas is a grid of characters diagrams are drawn on, growing as
+// they're drawn.
+type textCanvas [][]rune
+
+// put writes s on the given row, starting at the given column.
+func (c *textCanvas) put(row, col int, s string) {
+	for len(*c) <= row {
+		*c = append(*c, nil)
+	}
+	for _, r := range s {
+		line := (*c)[row]
+		for len(line) <= col {
+			line = append(line, ' ')
+		}
+		line[col] = r
+		(*c)[row] = line
+		col++
+	}
+}
+
+// String returns what's drawn on the canvas, without trailing spaces.
+func (c textCanvas) String() string {
+	lines := make([]string, len(c))
+	for i, line := range c {
+		lines[i] = strings.TrimRight(string(line), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// textBox draws a label in a box, returning its lines, which are all as
+// wide.
+func textBox(label string) [3]string {
+	bar := strings.Repeat("─", stringWidth(label)+2)
+	return [3]string{"┌" + bar + "┐", "│ " + label + " │", "└" + bar + "┘"}
+}
+
+// flowNode is a node of a flowchart.
+type flowNode struct {
+	id, label string
+}
+
+// flowEdge is an edge of a flowchart, between the nodes with the given
+// ids.
+type flowEdge struct {
+	from, to, label string
+}
+
+// flowchart is a mermaid flowchart.
+type flowchart struct {
+	nodes []flowNode // in the order they appear in
+	edges []flowEdge
+}
+
+// node returns the index of the node with the given id, adding it if it's
+// new. A label gives it its label.
+func (f *flowchart) node(id, label string) int {
+	i := slices.IndexFunc(f.nodes, func(n flowNode) bool { return n.id == id })
+	if i < 0 {
+		f.nodes = append(f.nodes, flowNode{id: id, label: id})
+		i = len(f.nodes) - 1
+	}
+	if label != "" {
+		f.nodes[i].label = strings.Trim(label, `"`)
+	}
+	return i
+}
+
+// parseFlowchart parses the statements of a flowchart: nodes, and chains
+// of nodes linked by edges. Styling is ignored.
+func parseFlowchart(lines []string) (*flowchart, error) {
+	f := &flowchart{}
+	for _, line := range lines {
+		for _, stmt := range strings.Split(line, ";") {
+			stmt = strings.TrimSpace(stmt)
+			switch keyword, _, _ := strings.Cut(stmt, " "); keyword {
+			case "":
+				continue
+			case "style", "classDef", "class", "click", "linkStyle":
+				continue
+			case "subgraph", "end", "direction":
+				return nil, fmt.Errorf("%w: %s", errMermaidUnsupported, keyword)
+			}
+
+			var from string
+			for rest, label := stmt, ""; ; {
+				m := flowNodePattern.FindStringSubmatch(rest)
+				if m == nil {
+					return nil, fmt.Errorf("%w: %s", errMermaidUnsupported, stmt)
+				}
+				var text string
+				for _, t := range m[2:] {
+					text += t
+				}
+				f.node(m[1], text)
+				if from != "" {
+					f.edges = append(f.edges, flowEdge{from: from, to: m[1], label: label})
+				}
+				from = m[1]
+
+				rest = strings.TrimSpace(rest[len(m[0]):])
+				if rest == "" {
+					break
+				}
+				e := flowEdgePattern.FindStringSubmatch(rest)
+				if e == nil {
+					return nil, fmt.Errorf("%w: %s", errMermaidUnsupported, stmt)
+				}
+				label = strings.Trim(strings.TrimSpace(e[2]+e[3]+e[4]+e[5]), `"`)
+				rest = rest[len(e[0]):]
+			}
+		}
+	}
+	if len(f.nodes) == 0 {
+		return nil, errMermaidUnsupported
+	}
+	return f, nil
+}
+
+// order returns the nodes of the flowchart, by index, each after those with
+// edges to it, cycles allowing, and otherwise in the order they appear in.
+func (f *flowchart) order() []int {
+	incoming := make([]int, len(f.nodes))
+	for _, e := range f.edges {
+		incoming[f.node(e.to, "")]++
+	}
+
+	var (
+		order  []int
+		placed = make([]bool, len(f.nodes))
+	)
+	for len(order) < len(f.nodes) {
+		// The first node nothing unplaced leads to or, in a cycle, the
+		// first node unplaced
+		next := -1
+		for i := range f.nodes {
+			if !placed[i] && (incoming[i] == 0 || next < 0) {
+				next = i
+				if incoming[i] == 0 {
+					break
+				}
+			}
+		}
+		placed[next] = true
+		order = append(order, next)
+		for _, e := range f.edges {
+			if e.from == f.nodes[next].id {
+				incoming[f.node(e.to, "")]--
+			}
+		}
+	}
+	return order
+}
+
+// chain returns the edges of the flowchart, in order, if the nodes, in the
+// given order, each lead on to the next, and nowhere else.
+func (f *flowchart) chain(order []int) ([]flowEdge, bool) {
+	if len(f.edges) != len(f.nodes)-1 {
+		return nil, false
+	}
+	edges := make([]flowEdge, len(f.edges))
+	for i := range edges {
+		from, to := f.nodes[order[i]].id, f.nodes[order[i+1]].id
+		j := slices.IndexFunc(f.edges, func(e flowEdge) bool { return e.from == from && e.to == to })
+		if j < 0 {
+			return nil, false
+		}
+		edges[i] = f.edges[j]
+	}
+	return edges, true
+}
+
+// draw draws the flowchart, from top to bottom, or from left to right if
+// it's across and a single chain of nodes.
+func (f *flowchart) draw(across bool) string {
+	order := f.order()
+	if edges, ok := f.chain(order); across && ok {
+		return f.drawAcross(order, edges)
+	}
+
+	var axis int
+	for _, n := range f.nodes {
+		axis = max(axis, (stringWidth(n.label)+4)/2)
+	}
+
+	var c textCanvas
+	row := 0
+	for i, n := range order {
+		node := f.nodes[n]
+		box := textBox(node.label)
+		left := axis - stringWidth(box[0])/2
+
+		var edges []flowEdge
+		for _, e := range f.edges {
+			if e.from == node.id {
+				edges = append(edges, e)
+			}
+		}
+		if len(edges) > 0 {
+			runes := []rune(box[2])
+			runes[axis-left] = '┬'
+			box[2] = string(runes)
+		}
+		for j, line := range box {
+			c.put(row+j, left, line)
+		}
+		row += 3
+
+		// Straight on to the next node, or a list of where the edges go
+		if len(edges) == 1 && i+1 < len(order) && edges[0].to == f.nodes[order[i+1]].id {
+			c.put(row, axis, "│")
+			if edges[0].label != "" {
+				c.put(row, axis+2, edges[0].label)
+			}
+			c.put(row+1, axis, "▼")
+			row += 2
+			continue
+		}
+		for j, e := range edges {
+			branch := "├─"
+			if j == len(edges)-1 {
+				branch = "└─"
+			}
+			if e.label != "" {
+				branch += " " + e.label + " ─"
+			}
+			c.put(row, axis, branch+"▶ "+f.nodes[f.node(e.to, "")].label)
+			row++
+		}
+		row++ // blank line before the next node
+	}
+	return strings.TrimRight(c.String(), "\n")
+}
+
+// drawAcross draws a chain of nodes, and the edges between them, from left
+// to right.
+func (f *flowchart) drawAcross(order []int, edges []flowEdge) string {
+	var c textCanvas
+	col := 0
+	for i, n := range order {
+		box := textBox(f.nodes[n].label)
+		for j, line := range box {
+			c.put(j, col, line)
+		}
+		col += stringWidth(box[0])
+		if i+1 < len(order) {
+			arrow := " ──▶ "
+			if label := edges[i].label; label != "" {
+				arrow = " ── " + label + " ──▶ "
+			}
+			c.put(1, col, arrow)
+			col += stringWidth(arrow)
+		}
+	}
+	return c.String()
+}
+
+// sequenceMessage is a message of a sequence diagram, between the
+// participants at the given indexes.
+type sequenceMessage struct {
+	from, to int
+	arrow    string
+	text     string
+}
+
+// sequenceDiagram is a mermaid sequence diagram.
+type sequenceDiagram struct {
+	ids, labels []string // of the participants, in order
+	messages    []sequenceMessage
+}
+
+// participant returns the index of the participant with the given id,
+// adding it if it's new.
+func (s *sequenceDiagram) participant(id string) int {
+	if i := slices.Index(s.ids, id); i >= 0 {
+		return i
+	}
+	s.ids = append(s.ids, id)
+	s.labels = append(s.labels, id)
+	return len(s.ids) - 1
+}
+
+// parseSequenceDiagram parses the participants and messages of a sequence
+// diagram. Activations are ignored.
+func parseSequenceDiagram(lines []string) (*sequenceDiagram, error) {
+	s := &sequenceDiagram{}
+	for _, line := range lines {
+		if m := sequenceParticipantPattern.FindStringSubmatch(line); m != nil {
+			i := s.participant(m[1])
+			if m[2] != "" {
+				s.labels[i] = strings.TrimSpace(m[2])
+			}
+			continue
+		}
+		if m := sequenceMessagePattern.FindStringSubmatch(line); m != nil {
+			s.messages = append(s.messages, sequenceMessage{
+				from:  s.participant(m[1]),
+				to:    s.participant(m[3]),
+				arrow: m[2],
+				text:  strings.TrimSpace(m[4]),
+			})
+			continue
+		}
+		switch keyword, _, _ := strings.Cut(line, " "); keyword {
+		case "autonumber", "activate", "deactivate":
+			continue
+		default:
+			return nil, fmt.Errorf("%w: %s", errMermaidUnsupported, line)
+		}
+	}
+	if len(s.ids) == 0 {
+		return nil, errMermaidUnsupported
+	}
+	return s, nil
+}
+
+// draw draws the sequence diagram: the participants side by side, their
+// lifelines below them and the messages between them as arrows, from top
+// to bottom.
+func (s *sequenceDiagram) draw() string {
+	// Participants are spread out for their boxes, and the messages between
+	// them, to fit
+	centers := make([]int, len(s.ids))
+	left := 0
+	for i, label := range s.labels {
+		width := stringWidth(label) + 4
+		centers[i] = left + width/2
+		left += width + 3
+	}
+	push := func(from, need int) {
+		if from < len(centers) && centers[from]-centers[from-1] < need {
+			shift := need - (centers[from] - centers[from-1])
+			for i := from; i < len(centers); i++ {
+				centers[i] += shift
+			}
+		}
+	}
+	for _, msg := range s.messages {
+		lo, hi := min(msg.from, msg.to), max(msg.from, msg.to)
+		if lo == hi {
+			push(lo+1, stringWidth(msg.text)+6)
+			continue
+		}
+		if have, need := centers[hi]-centers[lo], stringWidth(msg.text)+4; have < need {
+			push(hi, centers[hi]-centers[hi-1]+need-have)
+		}
+	}
+
+	var c textCanvas
+	for i, label := range s.labels {
+		box := textBox(label)
+		runes := []rune(box[2])
+		runes[len(runes)/2] = '┬'
+		box[2] = string(runes)
+		for j, line := range box {
+			c.put(j, centers[i]-len(runes)/2, line)
+		}
+	}
+	lifelines := func(row int) {
+		for _, center := range centers {
+			c.put(row, center, "│")
+		}
+	}
+
+	row := 3
+	for _, msg := range s.messages {
+		lifelines(row)
+		lifelines(row + 1)
+		from, to := centers[msg.from], centers[msg.to]
+		line := "─"
+		if strings.HasPrefix(msg.arrow, "--") {
+			line = "┄"
+		}
+		head := "▶"
+		switch strings.TrimLeft(msg.arrow, "-") {
+		case "x":
+			head = "x"
+		case ")":
+			head = "▷"
+		}
+
+		switch {
+		case from == to:
+			c.put(row, from, "├"+line+"┐ "+msg.text)
+			c.put(row+1, from, "│◀┘")
+		case from < to:
+			c.put(row, from+1+(to-from-1-stringWidth(msg.text))/2, msg.text)
+			c.put(row+1, from+1, strings.Repeat(line, to-from-2)+head)
+		default:
+			switch head {
+			case "▶":
+				head = "◀"
+			case "▷":
+				head = "◁"
+			}
+			c.put(row, to+1+(from-to-1-stringWidth(msg.text))/2, msg.text)
+			c.put(row+1, to+1, head+strings.Repeat(line, from-to-2))
+		}
+		row += 2
+	}
+	lifelines(row)
+	return c.String()
+}
@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDrawMermaid(t *testing.T) {
+	tests := []struct {
+		name    string
+		diagram string
+		want    string
+	}{
+		{
+			name:    "flowchart",
+			diagram: "graph TD\n  A[Start] --> B{Ready?}\n  B -->|yes| C[Go]\n  B -- no --> A",
+			want: "" +
+				" ┌───────┐\n" +
+				" │ Start │\n" +
+				" └───┬───┘\n" +
+				"     │\n" +
+				"     ▼\n" +
+				"┌────────┐\n" +
+				"│ Ready? │\n" +
+				"└────┬───┘\n" +
+				"     ├─ yes ─▶ Go\n" +
+				"     └─ no ─▶ Start\n" +
+				"\n" +
+				"  ┌────┐\n" +
+				"  │ Go │\n" +
+				"  └────┘",
+		},
+		{
+			name:    "flowchart across",
+			diagram: "graph LR; A[Write] --> B(Review) -- approve --> C((Merge))",
+			want: "" +
+				"┌───────┐     ┌────────┐                ┌───────┐\n" +
+				"│ Write │ ──▶ │ Review │ ── approve ──▶ │ Merge │\n" +
+				"└───────┘     └────────┘                └───────┘",
+		},
+		{
+			name:    "sequence",
+			diagram: "sequenceDiagram\n  participant A as Alice\n  A->>Bob: Hello\n  Bob-->>A: Hi",
+			want: "" +
+				"┌───────┐   ┌─────┐\n" +
+				"│ Alice │   │ Bob │\n" +
+				"└───┬───┘   └──┬──┘\n" +
+				"    │  Hello   │\n" +
+				"    │─────────▶│\n" +
+				"    │    Hi    │\n" +
+				"    │◀┄┄┄┄┄┄┄┄┄│\n" +
+				"    │          │",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := drawMermaid(tt.diagram)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRenderMermaidBlocks(t *testing.T) {
+	md := "# Flow\n\n```mermaid\ngraph TD\n  A --> B\n```\n\n```mermaid\npie title Pets\n  \"Dogs\" : 3\n```\n"
+
+	got := renderMermaidBlocks(md, "")
+	if !strings.Contains(got, "```text\n┌───┐\n│ A │\n└─┬─┘\n  │\n  ▼\n┌───┐\n│ B │\n└───┘\n```") {
+		t.Errorf("expected the flowchart to be drawn, got:\n%s", got)
+	}
+	if !strings.Contains(got, "```mermaid\npie title Pets") {
+		t.Errorf("expected the pie chart to be shown as written, got:\n%s", got)
+	}
+
+	if got := renderMermaidBlocks(md, "glow-no-such-command"); got != md {
+		t.Errorf("expected diagrams to be shown as written when the command fails, got:\n%s", got)
+	}
+}
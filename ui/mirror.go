@@ -0,0 +1,112 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/log"
+)
+
+// The current slide is mirrored to Config.SlideMirrorPath for a second glow
+// instance, or anything else watching it, to show on another screen. Files
+// are replaced whole, and named pipes written to without waiting, so that a
+// reader that's slow or gone never holds up the presenter.
+
+// slideMirror serializes writes to the slide mirror, which are made in the
+// background, so that an older slide never overwrites a newer one.
+var slideMirror struct {
+	sync.Mutex
+	queued  int // sequence number of the last write queued
+	written int // and of the last one made, or skipped as outdated
+}
+
+// slideMirrorPath returns where the current slide is mirrored to, or "" if
+// it isn't.
+func (m pagerModel) slideMirrorPath() string {
+	return utils.ExpandPath(m.common.cfg.SlideMirrorPath)
+}
+
+// mirrorSlide writes the current slide's markdown to the slide mirror, if
+// it's changed since it was last written.
+func (m *pagerModel) mirrorSlide() tea.Cmd {
+	path := m.slideMirrorPath()
+	if path == "" || !m.slideMode || m.currentSlide >= len(m.slides) {
+		return nil
+	}
+	md := m.slides[m.currentSlide]
+	if md == m.mirroredSlide {
+		return nil
+	}
+	m.mirroredSlide = md
+
+	slideMirror.Lock()
+	slideMirror.queued++
+	seq := slideMirror.queued
+	slideMirror.Unlock()
+
+	return func() tea.Msg {
+		slideMirror.Lock()
+		defer slideMirror.Unlock()
+		if seq <= slideMirror.written {
+			return nil
+		}
+		slideMirror.written = seq
+		if err := writeSlideMirror(path, md); err != nil {
+			log.Warn("unable to mirror slide", "file", path, "error", err)
+		}
+		return nil
+	}
+}
+
+// writeSlideMirror writes a slide to the given file, replacing it whole,
+// or to the given named pipe, dropping the slide if nothing is reading or
+// the reader's fallen behind.
+func writeSlideMirror(path, md string) error {
+	info, err := os.Stat(path)
+	if err != nil || info.Mode()&os.ModeNamedPipe == 0 {
+		return replaceFile(path, []byte(md))
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+	if errors.Is(err, syscall.ENXIO) {
+		return nil // no reader
+	}
+	if err != nil {
+		return fmt.Errorf("unable to open pipe: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+	if _, err := f.WriteString(md); errors.Is(err, syscall.EAGAIN) {
+		log.Debug("slide mirror reader fell behind, dropping slide")
+	} else if err != nil {
+		return fmt.Errorf("unable to write to pipe: %w", err)
+	}
+	return nil
+}
+
+// clearSlideMirror removes the slide mirror file, once the deck's closed,
+// and drops writes still on their way. Named pipes are left for their
+// reader.
+func (m *pagerModel) clearSlideMirror() {
+	path := m.slideMirrorPath()
+	if path == "" || m.mirroredSlide == "" {
+		return
+	}
+	m.mirroredSlide = ""
+
+	slideMirror.Lock()
+	defer slideMirror.Unlock()
+	slideMirror.queued++
+	slideMirror.written = slideMirror.queued
+
+	if info, err := os.Stat(path); err != nil || info.Mode()&os.ModeNamedPipe != 0 {
+		return
+	}
+	if err := os.Remove(path); err != nil {
+		log.Warn("unable to remove slide mirror", "file", path, "error", err)
+	}
+}
@@ -0,0 +1,102 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Lines scrolled per mouse wheel step, unless configured otherwise.
+const defaultMouseScrollLines = 3
+
+// handleMouse scrolls the document with the mouse wheel and follows links
+// clicked on. It returns false for events it leaves to the viewport, like
+// horizontal scrolling.
+func (m *pagerModel) handleMouse(msg tea.MouseMsg) (tea.Cmd, bool) {
+	if msg.Action != tea.MouseActionPress || msg.Shift {
+		return nil, false
+	}
+
+	if msg.Button == tea.MouseButtonLeft {
+		if m.capturesKeys() {
+			return nil, false // the link is under an overlay
+		}
+		top, left := 0, 0
+		if !m.fullscreen {
+			top, _, _, left = m.viewPadding()
+		}
+		l, ok := m.linkAt(msg.X-left, msg.Y-top)
+		if !ok {
+			return nil, false
+		}
+		return m.openLink(l), true
+	}
+
+	lines := m.common.cfg.MouseScrollLines
+	if lines <= 0 {
+		lines = defaultMouseScrollLines
+	}
+
+	switch msg.Button { //nolint:exhaustive
+	case tea.MouseButtonWheelUp:
+		// The viewport keeps the offset within the document
+		m.viewport.ScrollUp(lines)
+	case tea.MouseButtonWheelDown:
+		m.viewport.ScrollDown(lines)
+	default:
+		return nil, false
+	}
+
+	if m.viewport.HighPerformanceRendering {
+		return viewport.Sync(m.viewport), true
+	}
+	return nil, true
+}
+
+// linkAt returns the link rendered at the given cell of the viewport, going
+// by the text of the links on the source line the cell's line was rendered
+// from. The URL glamour shows after a link's text counts as part of it.
+func (m pagerModel) linkAt(x, y int) (link, bool) {
+	rendered := m.renderedLines()
+	row := m.viewport.YOffset + y
+	if y < 0 || y >= m.viewport.Height || row >= len(rendered) {
+		return link{}, false
+	}
+	col := x + m.xOffset
+	if m.showsLineNumbers() {
+		col -= m.lineNumberWidth
+	}
+	if col < 0 {
+		return link{}, false
+	}
+
+	md := m.currentMarkdown()
+	source := sourceLineMap(md, rendered)[row]
+	line := ansi.Strip(rendered[row])
+	from := 0 // where in the line the next link is looked for
+	for _, l := range parseLinks(md) {
+		if l.line != source || l.text == "" {
+			continue
+		}
+		i := strings.Index(line[from:], l.text)
+		if i < 0 {
+			continue
+		}
+		start := from + i
+		end := start + len(l.text)
+		if rest := strings.TrimLeft(line[end:], " "); rest != "" {
+			url, _, _ := strings.Cut(rest, " ")
+			if l.url != "" && strings.Contains(url, strings.TrimPrefix(l.url, "./")) {
+				end = len(line) - len(rest) + len(url)
+			}
+		}
+		from = end
+
+		if col >= ansi.StringWidth(line[:start]) && col < ansi.StringWidth(line[:end]) {
+			return l, true
+		}
+	}
+	return link{}, false
+}
@@ -0,0 +1,161 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Title of the speaker notes panel.
+const notesTitle = "Notes"
+
+// notesOpenPattern matches the start of a speaker notes comment, like
+// <!-- notes: Mention the benchmarks -->, which can go on for several lines.
+var notesOpenPattern = regexp.MustCompile(`^\s*<!--\s*notes:(.*)$`)
+
+// extractSlideNotes removes speaker notes comments from the given slides,
+// returning the slides without them along with each slide's notes. Notes in
+// code blocks are left as they are.
+func extractSlideNotes(slides []string) ([]string, []string) {
+	notes := make([]string, len(slides))
+	for i, slide := range slides {
+		var (
+			lines = strings.Split(slide, "\n")
+			kept  = make([]string, 0, len(lines))
+			found []string
+		)
+		for j := 0; j < len(lines); j++ {
+			if m := fenceOpenPattern.FindStringSubmatch(lines[j]); m != nil {
+				end := closingFence(lines, j, m[2])
+				if end < 0 {
+					end = len(lines) - 1
+				}
+				kept = append(kept, lines[j:end+1]...)
+				j = end
+				continue
+			}
+
+			m := notesOpenPattern.FindStringSubmatch(lines[j])
+			if m == nil {
+				kept = append(kept, lines[j])
+				continue
+			}
+			text, end := m[1], j
+			for !strings.Contains(text, "-->") && end+1 < len(lines) {
+				end++
+				text += "\n" + lines[end]
+			}
+			text, _, closed := strings.Cut(text, "-->")
+			if !closed {
+				kept = append(kept, lines[j])
+				continue
+			}
+			if text = dedent(text); text != "" {
+				found = append(found, text)
+			}
+			j = end
+		}
+		slides[i] = strings.Join(kept, "\n")
+		notes[i] = strings.Join(found, "\n\n")
+	}
+	return slides, notes
+}
+
+// dedent trims the text of a notes comment and strips the indentation its
+// lines share, but for the one following the comment's opening.
+func dedent(text string) string {
+	first, rest, _ := strings.Cut(text, "\n")
+	lines := strings.Split(rest, "\n")
+	common := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " \t"))
+		if common < 0 || n < common {
+			common = n
+		}
+	}
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line[min(len(line), max(0, common)):], " \t")
+	}
+	return strings.TrimSpace(strings.TrimSpace(first) + "\n" + strings.Join(lines, "\n"))
+}
+
+// currentNotes returns the speaker notes of the current slide.
+func (m pagerModel) currentNotes() string {
+	if !m.slideMode || m.currentSlide >= len(m.slideNotes) {
+		return ""
+	}
+	return m.slideNotes[m.currentSlide]
+}
+
+// notesHeight returns how many lines the speaker notes panel takes up: a
+// third of the pager, so that the slide keeps most of the room, and the same
+// for every slide, so that slides don't move about.
+func (m pagerModel) notesHeight() int {
+	top, _, bottom, _ := m.viewPadding()
+	return max(3, (m.common.height-top-bottom)/3)
+}
+
+// showsNotes returns whether the speaker notes panel is showing.
+func (m pagerModel) showsNotes() bool {
+	return m.showNotes && m.slideMode && !m.fullscreen
+}
+
+// toggleNotes shows or hides the speaker notes panel beneath the slide.
+func (m *pagerModel) toggleNotes() tea.Cmd {
+	if !m.showNotes && !m.slideMode {
+		m.parseSlides()
+	}
+	if !m.slideMode {
+		return m.showStatusMessage(pagerStatusMessage{"Not a slide deck", true})
+	}
+
+	m.showNotes = !m.showNotes
+	m.setSize(m.common.width, m.common.height)
+	if m.viewport.PastBottom() {
+		m.viewport.GotoBottom()
+	}
+
+	// Slides are laid out for the height of the viewport
+	offset := m.viewport.YOffset
+	m.pendingYOffset = &offset
+	cmds := []tea.Cmd{renderWithGlamour(*m, m.currentMarkdown())}
+	if m.viewport.HighPerformanceRendering {
+		cmds = append(cmds, tea.ClearScrollArea) //nolint:staticcheck
+	}
+	return tea.Batch(cmds...)
+}
+
+// notesView renders the speaker notes panel: the current slide's notes,
+// wrapped to the width of the pager and cut short if they don't fit.
+func (m pagerModel) notesView() string {
+	height := m.notesHeight()
+	width := max(1, m.width()-4)
+
+	title := fmt.Sprintf("%s · %d/%d", notesTitle, m.currentSlide+1, len(m.slides))
+	lines := []string{title}
+	if notes := m.currentNotes(); notes == "" {
+		lines = append(lines, "No notes for this slide")
+	} else {
+		lines = append(lines, strings.Split(ansi.Wrap(notes, width, ""), "\n")...)
+	}
+	if len(lines) > height {
+		lines = lines[:height]
+		lines[height-1] = truncateWidth(lines[height-1], width-1, "") + ellipsis
+	}
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+
+	s := indent(strings.Join(lines, "\n"), 2)
+	rows := strings.Split(s, "\n")
+	for i, row := range rows {
+		rows[i] = row + strings.Repeat(" ", max(0, m.width()-stringWidth(row)))
+	}
+	return helpViewStyle(strings.Join(rows, "\n"))
+}
@@ -0,0 +1,206 @@
+package ui
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/log"
+)
+
+// Most links offered to pick from, each numbered with a single digit.
+const maxLinkChoices = 9
+
+// Schemes of links handed to the default application. Anything else, like
+// file: links, could run a program rather than show a page.
+var openableSchemes = []string{"http", "https", "mailto"}
+
+// linkOpenedMsg reports a link opened in the default application.
+type linkOpenedMsg struct {
+	target string
+	err    error
+}
+
+// systemOpener returns the command opening the given URL in the default
+// application. None of them go through a shell, which would read the URL's
+// &, | and ^ as commands of its own.
+func systemOpener(target string) *exec.Cmd {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", target)
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", target)
+	}
+	return exec.Command("xdg-open", target)
+}
+
+// visibleLinks returns the links on the source lines in view, in order.
+func (m pagerModel) visibleLinks() []link {
+	md := m.currentMarkdown()
+	sources := sourceLineMap(md, m.renderedLines())
+	if len(sources) == 0 {
+		return nil
+	}
+	first := sources[min(m.viewport.YOffset, len(sources)-1)]
+	last := sources[min(m.viewport.YOffset+m.viewport.Height, len(sources))-1]
+
+	var visible []link
+	for _, l := range parseLinks(md) {
+		if l.line >= first && l.line <= last {
+			visible = append(visible, l)
+		}
+	}
+	return visible
+}
+
+// openLinkInView opens the selected link or, if none is, the link in view.
+// With several links in view, they're offered to pick from.
+func (m *pagerModel) openLinkInView() tea.Cmd {
+	if l, ok := m.currentLink(); ok {
+		return m.openLink(l)
+	}
+
+	links := m.visibleLinks()
+	switch len(links) {
+	case 0:
+		return m.showStatusMessage(pagerStatusMessage{"No links in view", true})
+	case 1:
+		return m.openLink(links[0])
+	}
+
+	m.linkChoices = links[:min(len(links), maxLinkChoices)]
+	m.linkChoice = 0
+	m.pickingLink = true
+	if m.viewport.HighPerformanceRendering {
+		return tea.ClearScrollArea //nolint:staticcheck
+	}
+	return nil
+}
+
+// closeLinkPicker closes the links offered to pick from.
+func (m *pagerModel) closeLinkPicker() tea.Cmd {
+	m.pickingLink = false
+	m.linkChoices = nil
+	if m.viewport.HighPerformanceRendering {
+		return viewport.Sync(m.viewport)
+	}
+	return nil
+}
+
+// handleLinkPickerInput handles key presses while links are offered to pick
+// from.
+func (m pagerModel) handleLinkPickerInput(msg tea.KeyMsg) (pagerModel, tea.Cmd) {
+	switch key := msg.String(); key {
+	case keyEsc, "q", "o":
+		return m, m.closeLinkPicker()
+
+	case "k", "up":
+		m.linkChoice = max(0, m.linkChoice-1)
+
+	case "j", "down":
+		m.linkChoice = min(len(m.linkChoices)-1, m.linkChoice+1)
+
+	case keyEnter:
+		l := m.linkChoices[m.linkChoice]
+		cmd := m.closeLinkPicker()
+		return m, tea.Batch(cmd, m.openLink(l))
+
+	default:
+		if n, err := strconv.Atoi(key); err == nil && n >= 1 && n <= len(m.linkChoices) {
+			l := m.linkChoices[n-1]
+			cmd := m.closeLinkPicker()
+			return m, tea.Batch(cmd, m.openLink(l))
+		}
+	}
+
+	return m, nil
+}
+
+// linkPickerView renders the links offered to pick from.
+func (m pagerModel) linkPickerView() string {
+	width := max(1, m.viewport.Width-tocViewStyle.GetHorizontalFrameSize())
+
+	var b strings.Builder
+	b.WriteString(fuchsiaFg("Open link") + "\n")
+	for i, l := range m.linkChoices {
+		entry := l.url
+		if l.text != "" && l.text != l.url {
+			entry = l.text + " → " + l.url
+		}
+		entry = truncateWidth(fmt.Sprintf("%d. %s", i+1, entry), width, ellipsis)
+		if i == m.linkChoice {
+			entry = fuchsiaFg(entry)
+		}
+		b.WriteString("\n" + entry)
+	}
+
+	return tocViewStyle.Render(b.String())
+}
+
+// openLink follows a link: anchors scroll to their heading, markdown files
+// are loaded in the pager and web and mailto links are opened in the default
+// application. Other files aren't opened, as they may well be programs.
+func (m *pagerModel) openLink(l link) tea.Cmd {
+	dest := l.url
+	switch {
+	case dest == "":
+		return m.showStatusMessage(pagerStatusMessage{"Link has no destination", true})
+	case strings.HasPrefix(dest, "#"):
+		return m.gotoTarget(dest)
+	}
+
+	u, err := url.Parse(dest)
+	if err != nil {
+		return m.showStatusMessage(pagerStatusMessage{"Invalid link: " + dest, true})
+	}
+
+	// Links to local files are relative to the document
+	if u.Scheme == "" {
+		if m.currentDocument.localPath == "" {
+			return m.showStatusMessage(pagerStatusMessage{"Can't open relative links of this document", true})
+		}
+		path, _, _ := strings.Cut(m.resolveLink(dest), "#")
+		info, err := os.Stat(path)
+		if err != nil {
+			return m.showStatusMessage(pagerStatusMessage{"No such file: " + path, true})
+		}
+		if !utils.IsMarkdownFile(path) || info.IsDir() {
+			return m.showStatusMessage(pagerStatusMessage{"Only markdown files are opened: " + path, true})
+		}
+		m.recordJump()
+		return m.openFileAt(path, 0)
+	}
+	if !slices.Contains(openableSchemes, strings.ToLower(u.Scheme)) {
+		return m.showStatusMessage(pagerStatusMessage{"Not opening " + u.Scheme + ": links", true})
+	}
+
+	target := dest
+	return func() tea.Msg {
+		cmd := systemOpener(target)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			if msg := strings.TrimSpace(string(out)); msg != "" {
+				err = fmt.Errorf("%w: %s", err, msg)
+			}
+			return linkOpenedMsg{target: target, err: err}
+		}
+		return linkOpenedMsg{target: target}
+	}
+}
+
+// handleLinkOpened reports a link opened in the default application.
+func (m *pagerModel) handleLinkOpened(msg linkOpenedMsg) tea.Cmd {
+	if msg.err != nil {
+		log.Error("unable to open link", "link", msg.target, "error", msg.err)
+		return m.showStatusMessage(pagerStatusMessage{"Unable to open link: " + msg.err.Error(), true})
+	}
+	log.Info("opened link", "link", msg.target)
+	return m.showStatusMessage(pagerStatusMessage{message: "Opened " + msg.target})
+}
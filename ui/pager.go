@@ -4,26 +4,39 @@ import (
 	"fmt"
 	"math"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/atotto/clipboard"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/glow/v2/utils"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/fsnotify/fsnotify"
-	runewidth "github.com/mattn/go-runewidth"
-	"github.com/muesli/reflow/ansi"
-	"github.com/muesli/reflow/truncate"
-	"github.com/muesli/termenv"
 )
 
 const (
 	statusBarHeight = 1
-	lineNumberWidth = 4
+
+	// Narrowest line number gutter, wide enough for numbers up to 9999.
+	minLineNumberWidth = 4
+
+	// Slide indicator style showing one dot per slide, the default being
+	// text.
+	slideIndicatorDots = "dots"
+
+	// Values of Config.SingleSlide. Glow hides the slide indicator by
+	// default, and decks are shown like any other when it's left empty.
+	singleSlideShow     = "show"
+	singleSlideHide     = "hide"
+	singleSlideDocument = "document"
 )
 
 var (
@@ -34,11 +47,13 @@ var (
 
 	lineNumberFg = lipgloss.AdaptiveColor{Light: "#656565", Dark: "#7D7D7D"}
 
-	statusBarNoteFg = lipgloss.AdaptiveColor{Light: "#656565", Dark: "#7D7D7D"}
-	statusBarBg     = lipgloss.AdaptiveColor{Light: "#E6E6E6", Dark: "#242424"}
+	statusBarNoteFg      = lipgloss.AdaptiveColor{Light: "#656565", Dark: "#7D7D7D"}
+	statusBarBg          = lipgloss.AdaptiveColor{Light: "#E6E6E6", Dark: "#242424"}
+	statusBarScrollPosFg = lipgloss.AdaptiveColor{Light: "#949494", Dark: "#5A5A5A"}
+	statusBarHelpBg      = lipgloss.AdaptiveColor{Light: "#DCDCDC", Dark: "#323232"}
 
 	statusBarScrollPosStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.AdaptiveColor{Light: "#949494", Dark: "#5A5A5A"}).
+				Foreground(statusBarScrollPosFg).
 				Background(statusBarBg).
 				Render
 
@@ -49,7 +64,7 @@ var (
 
 	statusBarHelpStyle = lipgloss.NewStyle().
 				Foreground(statusBarNoteFg).
-				Background(lipgloss.AdaptiveColor{Light: "#DCDCDC", Dark: "#323232"}).
+				Background(statusBarHelpBg).
 				Render
 
 	statusBarMessageStyle = lipgloss.NewStyle().
@@ -78,8 +93,14 @@ var (
 )
 
 type (
-	contentRenderedMsg string
-	reloadMsg          struct{}
+	contentRenderedMsg struct {
+		content  string
+		graphics inlineImageGraphics // Kitty graphics showing inline images
+		duration time.Duration       // how long rendering took
+		stream   *renderStream       // for documents rendered in parts, nil otherwise
+	}
+	reloadMsg      struct{}
+	fileChangedMsg struct{}
 )
 
 type pagerState int
@@ -91,58 +112,420 @@ const (
 
 type pagerModel struct {
 	common   *commonModel
+	keys     pagerKeyMap
 	viewport viewport.Model
 	state    pagerState
 	showHelp bool
 
+	// One-line reminder of the most common keys, shown below the status
+	// bar unless the full help is open.
+	showCompactHelp bool
+
+	// Expanded state of the document's <details> sections, by id. Sections
+	// not in here use their open attribute.
+	detailsExpanded map[int]bool
+
+	// Sections folded away, by the source line of their heading. Kept
+	// across resizes, and dropped when the document's reloaded.
+	folded map[int]bool
+
+	// Keys typed so far of a sequence bound to an action, like za.
+	pendingKeys string
+
+	// Whether the paragraphs around the one being read are dimmed, the
+	// rendered lines paragraphs start on, and the range of lines of the one
+	// in the spotlight.
+	spotlight       bool
+	spotlightStarts []int
+	spotlit         [2]int
+
+	// Document statistics, shown in an overlay on demand.
+	stats     documentStats
+	showStats bool
+
+	// Whether the status bar shows the word count in place of the note
+	showWordCount bool
+
+	// Rendering details, shown in the debug overlay.
+	showDebug     bool
+	debug         renderDebugInfo
+	slowRenderHit bool // whether we've warned about slow rendering
+
 	statusMessage      string
 	statusMessageTimer *time.Timer
+	scrollFlash        bool // whether the status message is a scroll flash
 
 	// Current document being rendered, sans-glamour rendering. We cache
 	// it here so we can re-render it on resize.
 	currentDocument markdown
 
-	watcher *fsnotify.Watcher
+	// Kitty graphics commands for inline images, written out in front of
+	// the view until kittyGraphicsSentMsg drops them
+	kittyGraphics    string
+	kittyGraphicsGen int
+
+	watcher *watchFanout
+	watch   *fileWatch // of the current document, if it's being watched
+
+	// In-document search. The direction of the last search determines which
+	// way n and N move through the matches.
+	searchInput    textinput.Model
+	searching      bool
+	searchQuery    string
+	searchBackward bool
+	searchMatches  []searchMatch
+	searchIndex    int           // current match
+	slideSearch    bool          // whether n/N go to the next slide with a match
+	preSearch      *jumpPosition // where the search started, to return to
+
+	// Table of contents overlay, and whether it's been opened for the
+	// document per Config.OpenTOCOnLoad
+	toc          []tocEntry
+	tocIndex     int
+	tocOrigin    int // scroll position to return to
+	showTOC      bool
+	tocShownOnce bool
+
+	// Anchor of the heading to scroll to once the document first renders
+	anchor string
+
+	// Recently opened documents, to pick one to open
+	recent      []string
+	recentIndex int
+	showRecent  bool
+
+	// Links in view, to pick one to open
+	linkChoices []link
+	linkChoice  int
+	pickingLink bool
+
+	// Style picker
+	styleChoices  []string
+	stylePreviews []string // of each style, rendered
+	styleChoice   int
+	pickingStyle  bool
+
+	// Prettified document waiting for confirmation to be saved
+	prettified         string
+	prettifyChanges    prettifyChanges
+	confirmingPrettify bool
+
+	// Goto prompt, and the positions left behind by it and other jumps
+	gotoInput textinput.Model
+	gotoing   bool
+	jumps     []jumpPosition
+	count     string // digits typed before a jump, like 12 in 12G
+
+	// Jumps to footnote definitions, for going back to their references
+	footnoteJumps []footnoteJump
+
+	// Horizontal scroll position and the width of the widest rendered line
+	xOffset      int
+	contentWidth int
+
+	// Width of the line number gutter of the rendered content
+	lineNumberWidth int
+
+	// Segments of the status bar, in order, see Config.StatusBarSegments
+	statusBarSegments []string
 
 	// Slide navigation: track slides and current position
-	slides             []string // Each slide's markdown content
-	currentSlide       int      // Current slide index (0-based)
-	slideMode          bool     // Whether we're in slide presentation mode
-	originalContent    string   // Full document content
-	renderedContent    string   // For backwards compatibility
-	resetScrollPosition bool    // Track if we should reset scroll position on next render
+	slides              []string       // Each slide's markdown content
+	slideMetas          []slideMeta    // Settings of each slide, from its metadata comments
+	slideNotes          []string       // Speaker notes of each slide, from its notes comments
+	slideHeader         *regexp.Regexp // What numbered-h1 slide headings match
+	showNotes           bool           // Whether the speaker notes panel is shown
+	mirroredSlide       string         // Markdown last written to the slide mirror
+	currentSlide        int            // Current slide index (0-based)
+	slideMode           bool           // Whether we're in slide presentation mode
+	originalContent     string         // Full document content
+	renderedContent     string         // For backwards compatibility
+	resetScrollPosition bool           // Track if we should reset scroll position on next render
+
+	// Whether slides take up the whole terminal, without the status bar
+	// and help
+	fullscreen bool
+
+	// Countdown to the next slide, see Config.SlideAutoAdvance
+	autoAdvanceGen    int // countdowns of an earlier slide are ignored
+	autoAdvancePaused bool
+
+	// Time spent presenting the current deck
+	timer presentationTimer
+
+	// Whether line numbers are shown, overriding the configuration, once
+	// toggled
+	lineNumbers *bool
+
+	// Whether HTML comments are shown as annotations
+	showComments bool
+
+	// Whether reloading the document when its file changes is paused
+	watchPaused bool
+
+	// Polling for changes, for when the file can't be watched
+	polling   bool
+	pollStamp fileStamp
+	pollGen   int
+
+	// Document being rendered in parts, 0 if none
+	streamID int64
+
+	// Whether the front matter title is shown as a heading atop the
+	// document
+	frontmatterTitle bool
+
+	// Whether prose is rendered without wrapping, relying on horizontal
+	// scrolling for long lines
+	noWrap bool
+
+	// Relative scroll position to restore on the next render, if any, for
+	// re-renders that change the number of lines
+	restoreScroll *float64
+
+	// Line to scroll to on the next render, for documents opened at a
+	// specific position
+	pendingYOffset *int
+
+	// Whether the next render picks up reading where the document was
+	// left, if RememberPosition is set
+	restorePosition bool
+
+	// Headings of the current markdown and the lines they're rendered on,
+	// for the status bar breadcrumb
+	headings     []heading
+	headingLines []int
+
+	// Index of the selected link in the current markdown, -1 if none
+	selectedLink int
+
+	// Whether overly long lines of code are rendered in full, and whether
+	// we've warned about cutting them short
+	fullLongLines bool
+	longLinesHit  bool
 }
 
 func newPagerModel(common *commonModel) pagerModel {
 	// Init viewport
 	vp := viewport.New(0, 0)
 	vp.YPosition = 0
-	vp.HighPerformanceRendering = config.HighPerformancePager
-
+	vp.KeyMap = viewport.KeyMap{} // the pager handles keys itself, see pagerKeyMap
+	keys, err := newPagerKeyMap(common.cfg.KeyBindings)
+	if err != nil {
+		log.Error("invalid key bindings, using the defaults", "error", err)
+	}
 	m := pagerModel{
-		common:   common,
-		state:    pagerStateBrowse,
-		viewport: vp,
+		common:            common,
+		keys:              keys,
+		state:             pagerStateBrowse,
+		slideHeader:       compileSlideHeaderPattern(common.cfg.SlideHeaderPattern),
+		statusBarSegments: parseStatusBarSegments(common.cfg.StatusBarSegments),
+		viewport:          vp,
+		searchInput:       newSearchInput(),
+		gotoInput:         newGotoInput(),
+		selectedLink:      -1,
+
+		restorePosition:  true,
+		frontmatterTitle: common.cfg.FrontmatterTitle,
+		anchor:           common.cfg.Anchor,
 	}
+
+	// High performance rendering draws lines straight to the terminal, where
+	// padding doesn't apply
+	top, right, bottom, left := m.viewPadding()
+	m.viewport.HighPerformanceRendering = config.HighPerformancePager && top+right+bottom+left == 0
 	m.initWatcher()
 	return m
 }
 
 func (m *pagerModel) setSize(w, h int) {
+	if m.fullscreen {
+		m.viewport.Width = w
+		m.viewport.Height = h
+		return
+	}
+
+	top, right, bottom, left := m.viewPadding()
+	w = max(1, w-left-right)
+	h = max(1, h-top-bottom)
 	m.viewport.Width = w
 	m.viewport.Height = h - statusBarHeight
 
 	if m.showHelp {
-		if pagerHelpHeight == 0 {
-			pagerHelpHeight = strings.Count(m.helpView(), "\n")
-		}
+		// The help layout depends on the width, so measure it every time
+		pagerHelpHeight = strings.Count(m.helpView(), "\n")
 		m.viewport.Height -= (statusBarHeight + pagerHelpHeight)
+	} else if m.showCompactHelp {
+		m.viewport.Height--
+	}
+	if m.showsNotes() {
+		m.viewport.Height = max(1, m.viewport.Height-m.notesHeight())
+	}
+	if m.showsProgressBar() {
+		m.viewport.Height = max(1, m.viewport.Height-1)
 	}
 }
 
+// viewPadding returns the padding around the pager, as configured with
+// ViewPadding.
+func (m pagerModel) viewPadding() (top, right, bottom, left int) {
+	top, right, bottom, left = lipgloss.NewStyle().Padding(m.common.cfg.ViewPadding...).GetPadding()
+	return max(0, top), max(0, right), max(0, bottom), max(0, left)
+}
+
+// width returns the width of the pager within its padding.
+func (m pagerModel) width() int {
+	_, right, _, left := m.viewPadding()
+	return max(0, m.common.width-left-right)
+}
+
 func (m *pagerModel) setContent(s string) {
-	m.viewport.SetContent(s)
 	m.renderedContent = s
+	var sourceLines int
+	if m.streamID != 0 {
+		sourceLines = strings.Count(m.currentMarkdown(), "\n") + 1
+	}
+	m.lineNumberWidth = m.gutterWidth(strings.Count(s, "\n")+1, sourceLines)
+	m.spotlightStarts = nil
+	m.spotlit = m.spotlightRange()
+	m.viewport.SetContent(m.displayedContent())
+
+	m.contentWidth = 0
+	for _, l := range strings.Split(s, "\n") {
+		m.contentWidth = max(m.contentWidth, stringWidth(l))
+	}
+	m.setXOffset(m.xOffset)
+}
+
+// setXOffset scrolls horizontally to the given column, clamped so that we
+// never scroll past the content.
+func (m *pagerModel) setXOffset(n int) {
+	m.xOffset = max(0, min(n, m.contentWidth-m.viewport.Width))
+	if !m.showsLineNumbers() {
+		m.viewport.SetXOffset(m.xOffset)
+		return
+	}
+
+	// Keep the line number gutter in place, only scrolling the content
+	m.viewport.SetContent(scrollPastGutter(m.displayedContent(), m.lineNumberWidth, m.xOffset))
+}
+
+// displayedContent returns the rendered content as shown, with search
+// matches highlighted and the lines around the spotlight dimmed.
+func (m pagerModel) displayedContent() string {
+	return m.dimOutsideSpotlight(m.highlightSearchMatches())
+}
+
+// scrollsHorizontally returns whether there's content to scroll sideways to.
+func (m pagerModel) scrollsHorizontally() bool {
+	return m.xOffset > 0 || m.contentWidth > m.viewport.Width
+}
+
+// arrowsScrollHorizontally returns whether the left and right arrows scroll
+// sideways, rather than moving between slides.
+func (m pagerModel) arrowsScrollHorizontally() bool {
+	return !m.slideMode && m.scrollsHorizontally()
+}
+
+// scrollVertically scrolls down by the given number of lines, up if
+// negative. Jumps of a page or so flash the scroll position.
+func (m *pagerModel) scrollVertically(n int, jump bool) tea.Cmd {
+	yOffset := m.viewport.YOffset
+	if n < 0 {
+		m.viewport.ScrollUp(-n)
+	} else {
+		m.viewport.ScrollDown(n)
+	}
+	var cmds []tea.Cmd
+	if m.viewport.HighPerformanceRendering {
+		cmds = append(cmds, viewport.Sync(m.viewport))
+	}
+	if jump {
+		cmds = append(cmds, m.flashScrollPosition(yOffset))
+	}
+	return tea.Batch(cmds...)
+}
+
+// scrollHorizontally scrolls sideways by the given number of columns, to
+// the right if positive.
+func (m *pagerModel) scrollHorizontally(n int) tea.Cmd {
+	m.setXOffset(m.xOffset + n)
+	if m.viewport.HighPerformanceRendering {
+		return viewport.Sync(m.viewport)
+	}
+	return nil
+}
+
+// showsLineNumbers returns whether rendered content has a line number gutter.
+func (m pagerModel) showsLineNumbers() bool {
+	if !config.GlamourEnabled {
+		return false
+	}
+	if m.lineNumbers != nil {
+		return *m.lineNumbers
+	}
+	if utils.IsMarkdownFile(m.currentDocument.Note) {
+		return m.common.cfg.ShowLineNumbersProse || m.common.cfg.ShowLineNumbers
+	}
+	return m.common.cfg.ShowLineNumbersCode || m.common.cfg.ShowLineNumbers
+}
+
+// toggleLineNumbers shows or hides line numbers, regardless of the
+// configuration, for the rest of the session.
+func (m *pagerModel) toggleLineNumbers() tea.Cmd {
+	if !config.GlamourEnabled {
+		return m.showStatusMessage(pagerStatusMessage{"Line numbers need Glamour", true})
+	}
+	show := !m.showsLineNumbers()
+	m.lineNumbers = &show
+
+	// The gutter takes up room, so the content is rendered anew to fit
+	percent := m.viewport.ScrollPercent()
+	m.restoreScroll = &percent
+	message := "Line numbers: off"
+	if show {
+		message = "Line numbers: on"
+	}
+	return tea.Batch(
+		renderWithGlamour(*m, m.currentMarkdown()),
+		m.showStatusMessage(pagerStatusMessage{message: message}),
+	)
+}
+
+// gutterWidth returns how wide the line number gutter is for content of the
+// given number of lines. Documents rendered in parts are numbered before
+// their length is known, so they go by their number of source lines, which
+// wrapping rarely more than doubles, as well.
+func (m pagerModel) gutterWidth(lines, sourceLines int) int {
+	last := m.currentDocument.lineOffset + max(lines, 2*sourceLines)
+	return max(minLineNumberWidth, len(strconv.Itoa(last)))
+}
+
+// renderedLines returns the lines of rendered content without the line
+// number gutter, so that line numbers aren't mistaken for text when looking
+// for it.
+func (m pagerModel) renderedLines() []string {
+	lines := strings.Split(m.renderedContent, "\n")
+	if m.showsLineNumbers() {
+		for i, l := range lines {
+			lines[i] = ansi.TruncateLeft(l, m.lineNumberWidth, "")
+		}
+	}
+	return lines
+}
+
+// visibleLinesWidth returns the printable width of the widest line currently
+// in view.
+func (m pagerModel) visibleLinesWidth() int {
+	lines := strings.Split(m.renderedContent, "\n")
+	top := min(m.viewport.YOffset, len(lines))
+	bottom := min(top+m.viewport.Height, len(lines))
+
+	var w int
+	for _, l := range lines[top:bottom] {
+		w = max(w, stringWidth(l))
+	}
+	return w
 }
 
 func (m *pagerModel) toggleHelp() {
@@ -165,6 +548,7 @@ func (m *pagerModel) showStatusMessage(msg pagerStatusMessage) tea.Cmd {
 	// Show a success message to the user
 	m.state = pagerStateStatusMessage
 	m.statusMessage = msg.message
+	m.scrollFlash = false
 	if m.statusMessageTimer != nil {
 		m.statusMessageTimer.Stop()
 	}
@@ -175,6 +559,7 @@ func (m *pagerModel) showStatusMessage(msg pagerStatusMessage) tea.Cmd {
 
 func (m *pagerModel) unload() {
 	log.Debug("unload")
+	m.savePosition()
 	if m.showHelp {
 		m.toggleHelp()
 	}
@@ -182,15 +567,53 @@ func (m *pagerModel) unload() {
 		m.statusMessageTimer.Stop()
 	}
 	m.state = pagerStateBrowse
+	m.timer.pause()
+	m.autoAdvanceGen++
+	m.autoAdvancePaused = false
+	m.clearSearch()
+	m.gotoing = false
+	m.count = ""
+	m.jumps = nil
+	m.footnoteJumps = nil
+	m.confirmingPrettify = false
+	m.prettified = ""
+	m.showTOC = false
+	m.toc = nil
+	m.showRecent = false
+	m.recent = nil
+	m.pickingLink = false
+	m.linkChoices = nil
+	m.pickingStyle = false
+	m.styleChoices = nil
+	m.stylePreviews = nil
+	m.tocShownOnce = false
+	m.anchor = ""
+	m.kittyGraphics = ""
 	m.viewport.SetContent("")
 	m.viewport.YOffset = 0
+	m.xOffset = 0
+	m.viewport.SetXOffset(0)
 	m.unwatchFile()
+	m.clearSlideMirror()
 
 	// Reset slide mode
 	m.slides = nil
 	m.slideMode = false
 	m.currentSlide = 0
 	m.originalContent = ""
+	m.fullscreen = false
+	m.showNotes = false
+	m.detailsExpanded = nil
+	m.folded = nil
+	m.pendingKeys = ""
+	m.spotlight = false
+	m.spotlightStarts = nil
+	m.spotlit = [2]int{}
+	m.slowRenderHit = false
+	m.fullLongLines = false
+	m.longLinesHit = false
+	m.selectedLink = -1
+	m.restorePosition = true
 }
 
 func (m pagerModel) update(msg tea.Msg) (pagerModel, tea.Cmd) {
@@ -199,95 +622,524 @@ func (m pagerModel) update(msg tea.Msg) (pagerModel, tea.Cmd) {
 		cmds []tea.Cmd
 	)
 
+	if m.searching {
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			return m.handleSearchInput(msg)
+		}
+		m.searchInput, cmd = m.searchInput.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+	if m.gotoing {
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			return m.handleGotoInput(msg)
+		}
+		m.gotoInput, cmd = m.gotoInput.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+	if m.showTOC {
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			return m.handleTOCInput(msg)
+		}
+	}
+	if m.showRecent {
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			return m.handleRecentInput(msg)
+		}
+	}
+	if m.pickingLink {
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			return m.handleLinkPickerInput(msg)
+		}
+	}
+	if m.pickingStyle {
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			return m.handleStylePickerInput(msg)
+		}
+	}
+	if m.confirmingPrettify {
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			return m.handlePrettifyInput(msg)
+		}
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "q", keyEsc:
+		// Any key dismisses overlays
+		if m.showStats || m.showDebug {
+			m.showStats = false
+			m.showDebug = false
+			if m.viewport.HighPerformanceRendering {
+				cmds = append(cmds, viewport.Sync(m.viewport))
+			}
+			return m, tea.Batch(cmds...)
+		}
+
+		if cmd, ok := m.handleCount(msg); ok {
+			return m, cmd
+		}
+		msg, ok := m.handleKeySequence(msg)
+		if !ok {
+			return m, nil
+		}
+
+		switch {
+		case key.Matches(msg, m.keys.Quit):
+			m.savePosition()
+			return m, tea.Quit
+
+		case msg.String() == keyEsc:
 			if m.state != pagerStateBrowse {
 				m.state = pagerStateBrowse
 				return m, nil
 			}
-		case "home", "g":
+			if m.searchActive() {
+				m.clearSearch()
+				if m.viewport.HighPerformanceRendering {
+					return m, viewport.Sync(m.viewport)
+				}
+				return m, nil
+			}
+			if m.fullscreen {
+				return m, m.toggleFullscreen()
+			}
+
+		case key.Matches(msg, m.keys.Search):
+			return m, m.startSearch(false)
+
+		case key.Matches(msg, m.keys.SearchBackward):
+			return m, m.startSearch(true)
+
+		case key.Matches(msg, m.keys.Goto):
+			return m, m.startGoto()
+
+		case key.Matches(msg, m.keys.TOC):
+			cmds = append(cmds, m.openTOC())
+
+		case key.Matches(msg, m.keys.GotoDefinition):
+			cmds = append(cmds, m.jumpToTag())
+
+		case key.Matches(msg, m.keys.Footnote):
+			cmds = append(cmds, m.toggleFootnote())
+
+		case key.Matches(msg, m.keys.JumpBack):
+			cmds = append(cmds, m.jumpBack())
+
+		case key.Matches(msg, m.keys.PrevMatch):
+			if m.searchActive() {
+				cmds = append(cmds, m.nextSearchMatch(!m.searchBackward))
+			}
+		case key.Matches(msg, m.keys.Top):
 			m.viewport.GotoTop()
 			if m.viewport.HighPerformanceRendering {
 				cmds = append(cmds, viewport.Sync(m.viewport))
 			}
-		case "end", "G":
+		case key.Matches(msg, m.keys.Bottom):
 			m.viewport.GotoBottom()
 			if m.viewport.HighPerformanceRendering {
 				cmds = append(cmds, viewport.Sync(m.viewport))
 			}
 
-		case "d":
-			m.viewport.HalfViewDown()
+		case key.Matches(msg, m.keys.LineEnd):
+			m.setXOffset(m.visibleLinesWidth() - m.viewport.Width)
 			if m.viewport.HighPerformanceRendering {
 				cmds = append(cmds, viewport.Sync(m.viewport))
 			}
 
-		case "u":
-			m.viewport.HalfViewUp()
+		case key.Matches(msg, m.keys.ScrollLeft):
+			cmds = append(cmds, m.scrollHorizontally(-max(1, m.common.cfg.HorizontalScrollStep)))
+
+		case key.Matches(msg, m.keys.ScrollRight):
+			cmds = append(cmds, m.scrollHorizontally(max(1, m.common.cfg.HorizontalScrollStep)))
+
+		case key.Matches(msg, m.keys.HalfScreenLeft):
+			cmds = append(cmds, m.scrollHorizontally(-max(1, m.viewport.Width/2)))
+
+		case key.Matches(msg, m.keys.HalfScreenRight):
+			cmds = append(cmds, m.scrollHorizontally(max(1, m.viewport.Width/2)))
+
+		case key.Matches(msg, m.keys.LineStart):
+			m.setXOffset(0)
 			if m.viewport.HighPerformanceRendering {
 				cmds = append(cmds, viewport.Sync(m.viewport))
 			}
 
-		case "e":
-			lineno := int(math.RoundToEven(float64(m.viewport.TotalLineCount()) * m.viewport.ScrollPercent()))
-			if m.viewport.AtTop() {
-				lineno = 0
+		case key.Matches(msg, m.keys.Up):
+			cmds = append(cmds, m.scrollVertically(-1, false))
+
+		case key.Matches(msg, m.keys.Down):
+			cmds = append(cmds, m.scrollVertically(1, false))
+
+		case key.Matches(msg, m.keys.PageUp):
+			cmds = append(cmds, m.scrollVertically(-m.viewport.Height, true))
+
+		case key.Matches(msg, m.keys.PageDown):
+			cmds = append(cmds, m.scrollVertically(m.viewport.Height, true))
+
+		case key.Matches(msg, m.keys.HalfPageUp):
+			cmds = append(cmds, m.scrollVertically(-(m.viewport.Height/2), true))
+
+		case key.Matches(msg, m.keys.HalfPageDown):
+			cmds = append(cmds, m.scrollVertically(m.viewport.Height/2, true))
+
+		case key.Matches(msg, m.keys.CopySnippet):
+			cmds = append(cmds, m.copySnippet())
+
+		case key.Matches(msg, m.keys.Edit):
+			if utils.IsCompressed(m.currentDocument.localPath) {
+				cmds = append(cmds, m.showStatusMessage(pagerStatusMessage{"Compressed documents can't be edited", true}))
+				break
 			}
+			// The editor opens the file itself, so the line stays relative
+			// to it, regardless of the document's line number offset
+			line, col := m.sourcePosition()
 			log.Info(
 				"opening editor",
 				"file", m.currentDocument.localPath,
-				"line", fmt.Sprintf("%d/%d", lineno, m.viewport.TotalLineCount()),
+				"line", fmt.Sprintf("%d/%d", line, strings.Count(m.currentDocument.source, "\n")+1),
+				"column", col,
 			)
-			return m, openEditor(m.currentDocument.localPath, lineno)
+			return m, openEditor(m.currentDocument.localPath, line, col)
+
+		case key.Matches(msg, m.keys.Copy):
+			cmds = append(cmds, m.copyToClipboard(m.currentDocument.source, "Copied contents"))
+
+		case key.Matches(msg, m.keys.CopyPlainText):
+			cmds = append(cmds, m.copyPlainText())
+
+		case key.Matches(msg, m.keys.CopySection):
+			cmds = append(cmds, m.copySection())
+
+		case key.Matches(msg, m.keys.CopyCodeBlock):
+			cmds = append(cmds, m.copyCodeBlock())
+
+		case key.Matches(msg, m.keys.NextLink):
+			cmds = append(cmds, m.selectLink(false))
+
+		case key.Matches(msg, m.keys.PrevLink):
+			cmds = append(cmds, m.selectLink(true))
+
+		case key.Matches(msg, m.keys.CopyLink):
+			cmds = append(cmds, m.yankLink())
+
+		case key.Matches(msg, m.keys.OpenLink):
+			cmds = append(cmds, m.openLinkInView())
+
+		case key.Matches(msg, m.keys.PickStyle):
+			cmds = append(cmds, m.openStylePicker())
+
+		case key.Matches(msg, m.keys.CycleStyle):
+			cmds = append(cmds, m.cycleStyle())
+
+		case key.Matches(msg, m.keys.ReloadStyle):
+			cmds = append(cmds, m.reloadStyle())
+
+		case key.Matches(msg, m.keys.Reload):
+			return m, loadLocalMarkdown(&m.currentDocument)
+
+		case key.Matches(msg, m.keys.Prettify):
+			cmds = append(cmds, m.startPrettify())
+
+		case key.Matches(msg, m.keys.FrontmatterTitle):
+			cmds = append(cmds, m.toggleFrontmatterTitle())
+
+		case key.Matches(msg, m.keys.NextListItem):
+			cmds = append(cmds, m.nextListItem(false))
+
+		case key.Matches(msg, m.keys.PrevListItem):
+			cmds = append(cmds, m.nextListItem(true))
+
+		case key.Matches(msg, m.keys.NextHeading):
+			cmds = append(cmds, m.nextHeading(false))
+
+		case key.Matches(msg, m.keys.PrevHeading):
+			cmds = append(cmds, m.nextHeading(true))
+
+		case key.Matches(msg, m.keys.NextImage):
+			cmds = append(cmds, m.nextImage(false))
+
+		case key.Matches(msg, m.keys.PrevImage):
+			cmds = append(cmds, m.nextImage(true))
+
+		case key.Matches(msg, m.keys.NextParagraph):
+			cmds = append(cmds, m.nextParagraph(false))
+
+		case key.Matches(msg, m.keys.PrevParagraph):
+			cmds = append(cmds, m.nextParagraph(true))
+
+		case key.Matches(msg, m.keys.Wrap):
+			m.noWrap = !m.noWrap
+			percent := m.viewport.ScrollPercent()
+			m.restoreScroll = &percent
+			cmds = append(cmds, renderWithGlamour(m, m.currentMarkdown()))
+
+		case key.Matches(msg, m.keys.LineNumbers):
+			cmds = append(cmds, m.toggleLineNumbers())
+
+		case key.Matches(msg, m.keys.ToggleComments):
+			m.showComments = !m.showComments
+			percent := m.viewport.ScrollPercent()
+			m.restoreScroll = &percent
+			message := "Comments: hidden"
+			if m.showComments {
+				message = "Comments: shown"
+			}
+			cmds = append(cmds,
+				renderWithGlamour(m, m.currentMarkdown()),
+				m.showStatusMessage(pagerStatusMessage{message: message}),
+			)
+
+		case key.Matches(msg, m.keys.ToggleFold):
+			return m, m.toggleFold()
 
-		case "c":
-			// Copy using OSC 52
-			termenv.Copy(m.currentDocument.Body)
-			// Copy using native system clipboard
-			_ = clipboard.WriteAll(m.currentDocument.Body)
-			cmds = append(cmds, m.showStatusMessage(pagerStatusMessage{"Copied contents", false}))
+		case key.Matches(msg, m.keys.UnfoldAll):
+			return m, m.foldAll(true)
 
-		case "r":
+		case key.Matches(msg, m.keys.FoldAll):
+			return m, m.foldAll(false)
+
+		case key.Matches(msg, m.keys.Spotlight):
+			return m, m.toggleSpotlight()
+
+		case key.Matches(msg, m.keys.ToggleDetails):
+			if cmd := m.toggleDetails(); cmd != nil {
+				cmds = append(cmds, cmd)
+			}
+
+		case key.Matches(msg, m.keys.WordCount):
+			m.showWordCount = !m.showWordCount
+
+		case key.Matches(msg, m.keys.Stats):
+			m.showStats = true
+			if m.viewport.HighPerformanceRendering {
+				cmds = append(cmds, tea.ClearScrollArea) //nolint:staticcheck
+			}
+
+		case key.Matches(msg, m.keys.Debug):
+			m.showDebug = true
+			if m.viewport.HighPerformanceRendering {
+				cmds = append(cmds, tea.ClearScrollArea) //nolint:staticcheck
+			}
+
+		case key.Matches(msg, m.keys.ToggleRendering):
+			cmds = append(cmds, m.toggleHighPerformance())
+
+		case key.Matches(msg, m.keys.AutoWatch):
+			m.watchPaused = !m.watchPaused
+			if m.watchPaused || m.currentDocument.localPath == "" {
+				break
+			}
+			// Catch up on changes made while paused
+			m.slides = nil
+			m.slideMode = false
+			m.currentSlide = 0
 			return m, loadLocalMarkdown(&m.currentDocument)
 
-		case "?":
+		case key.Matches(msg, m.keys.CompactHelp):
+			m.showCompactHelp = !m.showCompactHelp
+			m.setSize(m.common.width, m.common.height)
+			if m.viewport.PastBottom() {
+				m.viewport.GotoBottom()
+			}
+			if m.viewport.HighPerformanceRendering {
+				cmds = append(cmds, viewport.Sync(m.viewport))
+			}
+
+		case key.Matches(msg, m.keys.Fullscreen):
+			cmds = append(cmds, m.toggleFullscreen())
+
+		case key.Matches(msg, m.keys.Notes):
+			cmds = append(cmds, m.toggleNotes())
+
+		case key.Matches(msg, m.keys.ExportSlide):
+			cmds = append(cmds, m.exportSlide())
+
+		case key.Matches(msg, m.keys.Recent):
+			cmds = append(cmds, m.openRecent())
+
+		case key.Matches(msg, m.keys.ExportHTML):
+			cmds = append(cmds, m.exportHTML())
+
+		case key.Matches(msg, m.keys.AutoAdvance):
+			cmds = append(cmds, m.toggleAutoAdvance())
+
+		case key.Matches(msg, m.keys.ResetTimer):
+			if !m.slideMode {
+				cmds = append(cmds, m.showStatusMessage(pagerStatusMessage{"Not a slide deck", true}))
+				break
+			}
+			m.timer.reset()
+			cmds = append(cmds, m.showStatusMessage(pagerStatusMessage{message: "Timer reset"}))
+
+		case key.Matches(msg, m.keys.ExportMatches):
+			cmds = append(cmds, m.exportSearch())
+
+		case key.Matches(msg, m.keys.ReturnFromSearch):
+			cmds = append(cmds, m.returnFromSearch())
+
+		case key.Matches(msg, m.keys.ShowLongLines):
+			if m.longLinesHit && !m.fullLongLines {
+				m.fullLongLines = true
+				return m, renderWithGlamour(m, m.currentMarkdown())
+			}
+
+		case key.Matches(msg, m.keys.Help):
 			m.toggleHelp()
 			if m.viewport.HighPerformanceRendering {
 				cmds = append(cmds, viewport.Sync(m.viewport))
 			}
 
-		case "n", "right":
-			if cmd := m.nextPage(); cmd != nil {
-				cmds = append(cmds, cmd)
+		case key.Matches(msg, m.keys.NextSlide):
+			if msg.String() == "right" && m.arrowsScrollHorizontally() {
+				cmds = append(cmds, m.scrollHorizontally(max(1, m.common.cfg.HorizontalScrollStep)))
+				break
+			}
+			// While searching, n moves through matches rather than slides
+			if msg.String() != "right" && m.searchActive() {
+				cmds = append(cmds, m.nextSearchMatch(m.searchBackward))
+				break
 			}
+			cmds = append(cmds, m.nextPage(), m.scheduleAutoAdvance())
 
-		case "p", "left":
-			if cmd := m.previousPage(); cmd != nil {
-				cmds = append(cmds, cmd)
+		case key.Matches(msg, m.keys.PrevSlide):
+			if msg.String() == "left" && m.arrowsScrollHorizontally() {
+				cmds = append(cmds, m.scrollHorizontally(-max(1, m.common.cfg.HorizontalScrollStep)))
+				break
 			}
+			cmds = append(cmds, m.previousPage(), m.scheduleAutoAdvance())
 		}
 
 	// Glow has rendered the content
 	case contentRenderedMsg:
 		log.Info("content rendered", "state", m.state)
 
-		m.setContent(string(msg))
+		// Parts after the first are appended to what's there, unless the
+		// document has been rendered anew since
+		content := msg.content
+		if s := msg.stream; s != nil && s.next > 1 {
+			if s.id != m.streamID {
+				return m, nil
+			}
+			content = m.renderedContent + "\n" + content
+		}
+		m.streamID = 0
+		if msg.stream != nil {
+			m.streamID = msg.stream.id
+		}
+		streaming := msg.stream != nil && !msg.stream.done()
+
+		m.setContent(content)
+		m.indexHeadings()
+		if msg.graphics.commands != "" {
+			cmds = append(cmds, m.lingerKittyGraphics(msg.graphics))
+		}
+		m.debug.record(msg.duration)
+		if cmd := m.warnAboutSlowRender(msg.duration); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+		if cmd := m.warnAboutLongLines(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+		if m.searchActive() {
+			m.findSearchMatches()
+		}
+		if m.slideMode {
+			cmds = append(cmds, m.startPresentationTimer(), m.mirrorSlide())
+			if !streaming {
+				cmds = append(cmds, m.scheduleAutoAdvance())
+			}
+		}
+
+		// Pick up reading where the document was left, unless it was
+		// opened at a specific position
+		if m.restorePosition && !streaming {
+			m.restorePosition = false
+			if offset, ok := m.savedPosition(); ok && !m.resetScrollPosition && m.pendingYOffset == nil {
+				m.viewport.SetYOffset(offset)
+			}
+		}
 
 		// Reset scroll position if we just switched slides
 		if m.resetScrollPosition {
 			m.viewport.YOffset = 0
 			m.resetScrollPosition = false
+			m.selectedLink = -1
+		}
+		// The scroll position is left as it is while parts arrive, until
+		// there's enough content to restore it
+		if m.pendingYOffset != nil && (!streaming || *m.pendingYOffset < m.viewport.TotalLineCount()) {
+			m.viewport.SetYOffset(*m.pendingYOffset)
+			m.pendingYOffset = nil
+		}
+		if m.restoreScroll != nil && !streaming {
+			maxOffset := max(0, m.viewport.TotalLineCount()-m.viewport.Height)
+			m.viewport.SetYOffset(int(math.Round(*m.restoreScroll * float64(maxOffset))))
+			m.restoreScroll = nil
 		}
 
-		if m.viewport.HighPerformanceRendering {
+		if streaming {
+			s := *msg.stream
+			s.lines = strings.Count(m.renderedContent, "\n") + 1
+			cmds = append(cmds, renderNextPart(m, s))
+		} else {
+			if m.anchor != "" {
+				cmds = append(cmds, m.scrollToAnchor())
+			}
+			if m.common.cfg.OpenTOCOnLoad && !m.tocShownOnce {
+				m.tocShownOnce = true
+				if parseHeadings(m.currentMarkdown()) != nil {
+					cmds = append(cmds, m.openTOC())
+				}
+			}
+			cmds = append(cmds, m.rewatchFile())
+		}
+
+		if m.viewport.HighPerformanceRendering && !m.showTOC && !m.showRecent && !m.pickingLink && !m.pickingStyle {
 			cmds = append(cmds, viewport.Sync(m.viewport))
 		}
-		cmds = append(cmds, m.watchFile)
+
+	// Slide navigation requested through the control server
+	case nextSlideMsg:
+		return m, tea.Batch(m.nextPage(), m.scheduleAutoAdvance())
+	case prevSlideMsg:
+		return m, tea.Batch(m.previousPage(), m.scheduleAutoAdvance())
+	case gotoSlideMsg:
+		return m, m.gotoSlide(int(msg))
+
+	case slideExportedMsg:
+		cmds = append(cmds, m.handleSlideExported(msg))
+
+	case linkOpenedMsg:
+		cmds = append(cmds, m.handleLinkOpened(msg))
+
+	case styleReloadedMsg:
+		cmds = append(cmds, m.handleStyleReloaded(msg))
+
+	case htmlExportedMsg:
+		cmds = append(cmds, m.handleHTMLExported(msg))
+
+	// The file can't be watched, so poll it for changes instead
+	case watchFailedMsg:
+		return m, m.startPolling()
+	case presentationTickMsg:
+		return m, m.handlePresentationTick(msg)
+
+	case autoAdvanceMsg:
+		return m, m.handleAutoAdvance(msg)
+
+	case filePolledMsg:
+		return m, m.checkPolledFile(msg)
 
 	// The file was changed on disk and we're reloading it
-	case reloadMsg:
+	case reloadMsg, fileChangedMsg:
+		// While watching is paused, keep an eye on the file but leave the
+		// document as it is
+		if _, ok := msg.(fileChangedMsg); ok && m.watchPaused {
+			return m, m.rewatchFile()
+		}
+		if _, ok := msg.(reloadMsg); ok {
+			m.savePosition()
+			m.restorePosition = true
+		}
 		m.slides = nil
 		m.slideMode = false
 		m.currentSlide = 0
@@ -310,14 +1162,21 @@ func (m pagerModel) update(msg tea.Msg) (pagerModel, tea.Cmd) {
 			m.parseSlides()
 		}
 
-		// Render the current slide if in slide mode, otherwise full content
-		if m.slideMode && len(m.slides) > 0 {
-			return m, renderWithGlamour(m, m.slides[m.currentSlide])
+		return m, renderWithGlamour(m, m.currentMarkdown())
+
+	case kittyGraphicsSentMsg:
+		if msg.gen == m.kittyGraphicsGen {
+			m.kittyGraphics = ""
 		}
-		return m, renderWithGlamour(m, m.currentDocument.Body)
+		return m, nil
 
 	case statusMessageTimeoutMsg:
 		m.state = pagerStateBrowse
+
+	case tea.MouseMsg:
+		if cmd, ok := m.handleMouse(msg); ok {
+			return m, cmd
+		}
 	}
 
 	m.viewport, cmd = m.viewport.Update(msg)
@@ -326,134 +1185,268 @@ func (m pagerModel) update(msg tea.Msg) (pagerModel, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// handlesEsc returns whether the pager has something to close on esc, rather
+// than esc leaving the document.
+func (m pagerModel) handlesEsc() bool {
+	return m.count != "" || m.pendingKeys != "" || m.searchActive() || m.fullscreen || m.showTOC || m.showRecent || m.pickingLink || m.pickingStyle
+}
+
+// capturesKeys returns whether the pager is showing something, like an
+// overlay, that should receive all key presses.
+func (m pagerModel) capturesKeys() bool {
+	return m.showStats || m.showDebug || m.showTOC || m.showRecent || m.pickingLink || m.pickingStyle ||
+		m.searching || m.gotoing || m.confirmingPrettify
+}
+
 func (m pagerModel) View() string {
 	var b strings.Builder
-	fmt.Fprint(&b, m.viewport.View()+"\n")
+	if m.showTOC {
+		fmt.Fprint(&b, m.tocOverlayView()+"\n")
+	} else if m.showStats || m.showDebug || m.showRecent || m.pickingLink || m.pickingStyle {
+		overlay := m.stats.view(m.common.cfg.ReadingWPM)
+		if m.showDebug {
+			overlay = m.debugView()
+		} else if m.showRecent {
+			overlay = m.recentView()
+		} else if m.pickingLink {
+			overlay = m.linkPickerView()
+		} else if m.pickingStyle {
+			overlay = m.stylePickerView()
+		}
+		fmt.Fprint(&b, lipgloss.Place(
+			m.viewport.Width, m.viewport.Height,
+			lipgloss.Center, lipgloss.Center,
+			overlay,
+		)+"\n")
+	} else if m.fullscreen {
+		return m.fullscreenView()
+	} else {
+		fmt.Fprint(&b, m.viewport.View()+"\n")
+	}
+
+	if m.showsNotes() {
+		fmt.Fprint(&b, m.notesView()+"\n")
+	}
+	if m.showsProgressBar() {
+		fmt.Fprint(&b, m.progressBarView()+"\n")
+	}
 
 	// Footer
-	m.statusBarView(&b)
+	if m.confirmingPrettify {
+		fmt.Fprint(&b, m.prettifyPromptView())
+	} else if m.gotoing {
+		fmt.Fprint(&b, m.gotoInput.View())
+	} else if m.searching {
+		m.searchInputView(&b)
+	} else {
+		m.statusBarView(&b)
+	}
 
 	if m.showHelp {
 		fmt.Fprint(&b, "\n"+m.helpView())
+	} else if m.showCompactHelp {
+		fmt.Fprint(&b, "\n"+m.compactHelpView())
 	}
 
+	if top, right, bottom, left := m.viewPadding(); top+right+bottom+left > 0 {
+		return lipgloss.NewStyle().Padding(top, right, bottom, left).Render(b.String())
+	}
 	return b.String()
 }
 
 func (m pagerModel) statusBarView(b *strings.Builder) {
-	const (
-		minPercent               float64 = 0.0
-		maxPercent               float64 = 1.0
-		percentToStringMagnitude float64 = 100.0
-	)
-
 	showStatusMessage := m.state == pagerStateStatusMessage
 
-	// Logo
-	logo := glowLogoView()
-
-	// Scroll percent
-	percent := math.Max(minPercent, math.Min(maxPercent, m.viewport.ScrollPercent()))
-	scrollPercent := fmt.Sprintf(" %3.f%% ", percent*percentToStringMagnitude)
-	if showStatusMessage {
-		scrollPercent = statusBarMessageScrollPosStyle(scrollPercent)
-	} else {
-		scrollPercent = statusBarScrollPosStyle(scrollPercent)
+	// The note takes up the room the other segments leave
+	views := make([]string, len(m.statusBarSegments))
+	room := m.width()
+	for i, name := range m.statusBarSegments {
+		if name != statusBarNoteSegment {
+			var width int
+			views[i], width = statusBarSegments[name](m, showStatusMessage)
+			room -= width
+		}
 	}
+	room = max(0, room)
 
-	// "Help" note
-	var helpNote string
-	if showStatusMessage {
-		helpNote = statusBarMessageHelpStyle(" ? Help ")
-	} else {
-		helpNote = statusBarHelpStyle(" ? Help ")
+	filled := false
+	for i, name := range m.statusBarSegments {
+		if name == statusBarNoteSegment && !filled {
+			views[i] = m.statusBarNoteView(showStatusMessage, room)
+			filled = true
+		}
+	}
+	b.WriteString(strings.Join(views, ""))
+	if !filled {
+		b.WriteString(statusBarFill(showStatusMessage, room))
 	}
+}
 
-	// Note
+// statusBarNoteView renders the status bar note, or the status message
+// showing, filling the given width.
+func (m pagerModel) statusBarNoteView(showStatusMessage bool, width int) string {
 	var note string
 	if showStatusMessage {
 		note = m.statusMessage
 	} else {
 		note = m.currentDocument.Note
+		if m.showWordCount {
+			note = m.stats.note(m.common.cfg.ReadingWPM)
+		}
 		// Add slide indicator if in slide mode
-		if m.slideMode && len(m.slides) > 0 {
-			slideIndicator := fmt.Sprintf(" [Slide %d/%d]", m.currentSlide+1, len(m.slides))
-			note = note + slideIndicator
+		if m.showsSlideIndicator() {
+			note = note + " " + m.slideIndicatorView()
+		}
+		if m.slideMode {
+			note += " " + m.timer.view()
+		}
+		if m.autoAdvances() {
+			note += " " + m.autoAdvanceView()
+		}
+		if m.noWrap {
+			note += " [nowrap]"
+		}
+		if m.xOffset > 0 {
+			note += fmt.Sprintf(" [col %d]", m.xOffset+1)
+		}
+		if m.watchPaused {
+			note += " [watch: off]"
+		}
+		if m.searchActive() && m.searchIndex >= 0 && m.searchIndex < len(m.searchMatches) {
+			note += fmt.Sprintf(" [%d/%d]", m.searchIndex+1, len(m.searchMatches))
+		}
+		if m.count != "" {
+			note = m.countView() + " " + note
+		}
+		if m.pendingKeys != "" {
+			note = "[" + m.pendingKeys + "] " + note
 		}
 	}
-	note = truncate.StringWithTail(" "+note+" ", uint(max(0, //nolint:gosec
-		m.common.width-
-			ansi.PrintableRuneWidth(logo)-
-			ansi.PrintableRuneWidth(scrollPercent)-
-			ansi.PrintableRuneWidth(helpNote),
-	)), ellipsis)
-	if showStatusMessage {
-		note = statusBarMessageStyle(note)
-	} else {
-		note = statusBarNoteStyle(note)
+	note = truncateWidth(" "+note+" ", width, ellipsis)
+
+	// Empty space, which a short note can make use of
+	padding := max(0, width-stringWidth(note))
+	var crumb string
+	if m.usesNoteLayout() {
+		switch m.common.cfg.StatusBarNote {
+		case statusBarNoteCenter:
+			note = strings.Repeat(" ", padding/2) + note
+			padding -= padding / 2
+		case statusBarNoteBreadcrumb:
+			if crumb = m.breadcrumb(padding - 2); crumb != "" {
+				crumb += " "
+				padding -= stringWidth(crumb)
+			}
+		}
 	}
+	return styleStatusBar(showStatusMessage, note) +
+		styleStatusBar(showStatusMessage, strings.Repeat(" ", padding)+crumb)
+}
 
-	// Empty space
-	padding := max(0,
-		m.common.width-
-			ansi.PrintableRuneWidth(logo)-
-			ansi.PrintableRuneWidth(note)-
-			ansi.PrintableRuneWidth(scrollPercent)-
-			ansi.PrintableRuneWidth(helpNote),
-	)
-	emptySpace := strings.Repeat(" ", padding)
-	if showStatusMessage {
-		emptySpace = statusBarMessageStyle(emptySpace)
-	} else {
-		emptySpace = statusBarNoteStyle(emptySpace)
+// slideIndicatorView renders the position in the slide deck for the status
+// bar. Dots are only used while they fit comfortably; larger decks fall back
+// to text regardless of the configured style.
+func (m pagerModel) slideIndicatorView() string {
+	if m.common.cfg.SlideIndicatorStyle == slideIndicatorDots &&
+		len(m.slides) <= m.common.cfg.SlideIndicatorDotsMax {
+		var b strings.Builder
+		for i := range m.slides {
+			if i == m.currentSlide {
+				b.WriteString("●")
+			} else {
+				b.WriteString("○")
+			}
+		}
+		if page, pages := m.slidePage(); pages > 1 {
+			fmt.Fprintf(&b, " · page %d/%d", page, pages)
+		}
+		return b.String()
 	}
-
-	fmt.Fprintf(b, "%s%s%s%s%s",
-		logo,
-		note,
-		emptySpace,
-		scrollPercent,
-		helpNote,
-	)
+	if page, pages := m.slidePage(); pages > 1 {
+		return fmt.Sprintf("[Slide %d/%d · page %d/%d]", m.currentSlide+1, len(m.slides), page, pages)
+	}
+	return fmt.Sprintf("[Slide %d/%d]", m.currentSlide+1, len(m.slides))
 }
 
 func (m pagerModel) helpView() (s string) {
-	col1 := []string{
-		"g/home  go to top",
-		"G/end   go to bottom",
-		"n       next slide",
-		"p       previous slide",
-		"c       copy contents",
-		"e       edit this document",
-		"r       reload this document",
-		"esc     back to files",
-		"q       quit",
+	const (
+		colGap  = 4
+		minRows = 7
+	)
+
+	escHelp := keyHelpEntry{keys: keyEsc, desc: "back to files"}
+	if m.common.escQuits() {
+		escHelp.desc = "quit"
+	}
+	// h and left only go back when they've nothing to scroll, see ui.go
+	backHelp := keyHelpEntry{keys: "h/←", desc: "back to files, if not scrolling"}
+	help := append(m.keys.helpEntries(), escHelp, backHelp, keyHelpEntry{bindings: []key.Binding{m.keys.Quit}})
+
+	// Line up descriptions, leaving room for the longest keys
+	keysWidth := 8
+	for _, e := range help {
+		keysWidth = max(keysWidth, stringWidth(e.keyHelp()))
+	}
+	entries := make([]string, len(help))
+	for i, e := range help {
+		entries[i] = e.keyHelp() + strings.Repeat(" ", keysWidth+1-stringWidth(e.keyHelp())) + e.description()
 	}
 
-	s += "\n"
-	s += "k/↑      up                  " + col1[0] + "\n"
-	s += "j/↓      down                " + col1[1] + "\n"
-	s += "b/pgup   page up             " + col1[2] + "\n"
-	s += "f/pgdn   page down           " + col1[3] + "\n"
-	s += "u        ½ page up           " + col1[4] + "\n"
-	s += "d        ½ page down         " + col1[5] + "\n"
-	s += "                             " + col1[6]
+	// Use as few rows as the width allows
+	var (
+		cols      [][]string
+		colWidths []int
+	)
+	for rows := minRows; ; rows++ {
+		cols, colWidths = nil, nil
+		width := 0
+		for i := 0; i < len(entries); i += rows {
+			col := entries[i:min(i+rows, len(entries))]
+			colWidth := 0
+			for _, cell := range col {
+				colWidth = max(colWidth, stringWidth(cell)+colGap)
+			}
+			cols = append(cols, col)
+			colWidths = append(colWidths, colWidth)
+			width += colWidth
+		}
+		if width-colGap+2 <= m.width() || len(cols) == 1 {
+			break
+		}
+	}
 
-	if len(col1) > 7 {
-		s += "\n                             " + col1[7]
+	var rows int
+	for _, col := range cols {
+		rows = max(rows, len(col))
 	}
-	if len(col1) > 8 {
-		s += "\n                             " + col1[8]
+
+	s += "\n"
+	for i := 0; i < rows; i++ {
+		var row string
+		for j, col := range cols {
+			var cell string
+			if i < len(col) {
+				cell = col[i]
+			}
+			if j < len(cols)-1 {
+				cell += strings.Repeat(" ", colWidths[j]-stringWidth(cell))
+			}
+			row += cell
+		}
+		s += strings.TrimRight(row, " ")
+		if i < rows-1 {
+			s += "\n"
+		}
 	}
 
 	s = indent(s, 2)
 
 	// Fill up empty cells with spaces for background coloring
-	if m.common.width > 0 {
+	if m.width() > 0 {
 		lines := strings.Split(s, "\n")
 		for i := 0; i < len(lines); i++ {
-			l := runewidth.StringWidth(lines[i])
-			n := max(m.common.width-l, 0)
+			l := stringWidth(lines[i])
+			n := max(m.width()-l, 0)
 			lines[i] += strings.Repeat(" ", n)
 		}
 
@@ -463,11 +1456,45 @@ func (m pagerModel) helpView() (s string) {
 	return helpViewStyle(s)
 }
 
-// parseSlides splits the markdown into individual slides based on numbered H1 headers.
-// Each slide contains one H1 header and all content until the next H1 header.
-// Only activates if PresentationMode is enabled in config.
+// compactHelpView renders a single line with the most common keys.
+func (m pagerModel) compactHelpView() string {
+	first := func(b key.Binding) string {
+		if keys := b.Keys(); len(keys) > 0 {
+			return keyName(keys[0])
+		}
+		return ""
+	}
+	k := m.keys
+	keys := []string{
+		first(k.Down) + "/" + first(k.Up) + " scroll",
+		first(k.Search) + " search",
+		first(k.Goto) + " go to",
+		first(k.NextSlide) + "/" + first(k.PrevSlide) + " slide",
+		first(k.Copy) + " copy",
+		first(k.Help) + " help",
+		first(k.Quit) + " quit",
+	}
+	s := truncateWidth(" "+strings.Join(keys, " • "), max(0, m.width()), ellipsis)
+	return helpViewStyle(s + strings.Repeat(" ", max(0, m.width()-stringWidth(s))))
+}
+
+// currentMarkdown returns the markdown being shown: the current slide in
+// slide mode, otherwise the whole document.
+func (m pagerModel) currentMarkdown() string {
+	if m.slideMode && len(m.slides) > 0 {
+		return m.slides[m.currentSlide]
+	}
+	return m.currentDocument.Body
+}
+
+// parseSlides splits the markdown into individual slides at the separators
+// set by SlideSeparator, numbered H1 headers by default. Each slide contains
+// one H1 header and all content until the next, or everything between two
+// horizontal rules. Only activates if PresentationMode is enabled in config.
 func (m *pagerModel) parseSlides() {
 	m.slides = []string{}
+	m.slideMetas = nil
+	m.slideNotes = nil
 	m.slideMode = false
 
 	// Only parse slides if presentation mode is enabled
@@ -479,41 +1506,14 @@ func (m *pagerModel) parseSlides() {
 		return
 	}
 
-	lines := strings.Split(m.currentDocument.Body, "\n")
-	var currentSlideLines []string
-	foundNumberedH1 := false
-
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-
-		// Check if this is a numbered H1 header
-		isNumberedH1 := false
-		if after, ok := strings.CutPrefix(trimmed, "# "); ok {
-			headerText := after
-			headerText = strings.TrimSpace(headerText)
-
-			// Check if it starts with a number
-			if len(headerText) > 0 && headerText[0] >= '0' && headerText[0] <= '9' {
-				isNumberedH1 = true
-				foundNumberedH1 = true
-			}
-		}
-
-		// If we hit a new numbered H1 and we have accumulated content, save the slide
-		if isNumberedH1 && len(currentSlideLines) > 0 {
-			m.slides = append(m.slides, strings.Join(currentSlideLines, "\n"))
-			currentSlideLines = []string{}
-		}
+	m.slides = splitSlides(m.currentDocument.Body, m.common.cfg.SlideSeparator, m.slideHeader)
+	m.slides, m.slideMetas = extractSlideMetas(m.slides)
+	m.slides, m.slideNotes = extractSlideNotes(m.slides)
 
-		// Add line to current slide if we're in slide mode
-		if foundNumberedH1 {
-			currentSlideLines = append(currentSlideLines, line)
-		}
-	}
-
-	// Don't forget the last slide
-	if len(currentSlideLines) > 0 {
-		m.slides = append(m.slides, strings.Join(currentSlideLines, "\n"))
+	// There's nothing to navigate in a single slide
+	if len(m.slides) == 1 && m.common.cfg.SingleSlide == singleSlideDocument {
+		log.Debug("single slide - slide mode disabled")
+		m.slides, m.slideMetas, m.slideNotes = nil, nil, nil
 	}
 
 	if len(m.slides) > 0 {
@@ -522,7 +1522,17 @@ func (m *pagerModel) parseSlides() {
 		m.originalContent = m.currentDocument.Body
 		log.Info("slide mode enabled", "slides", len(m.slides))
 	} else {
-		log.Debug("no numbered h1 headers found - slide mode disabled")
+		log.Debug("no slide separators found - slide mode disabled", "separator", m.common.cfg.SlideSeparator)
+	}
+
+	// Fullscreen is for slides only
+	if !m.slideMode && m.fullscreen {
+		m.fullscreen = false
+	}
+
+	// The notes panel and progress bar are for slides only too
+	if m.common.height > 0 {
+		m.setSize(m.common.width, m.common.height)
 	}
 }
 
@@ -537,11 +1547,18 @@ func (m *pagerModel) nextPage() tea.Cmd {
 		return nil
 	}
 
+	if page, pages := m.slidePage(); page < pages {
+		return m.scrollTo(page * m.viewport.Height)
+	}
+
 	if m.currentSlide < len(m.slides)-1 {
 		m.currentSlide++
 		m.resetScrollPosition = true
 		log.Debug("navigating to next slide", "slide", m.currentSlide+1, "total", len(m.slides))
-		return renderWithGlamour(*m, m.slides[m.currentSlide])
+		return tea.Batch(
+			renderWithGlamour(*m, m.slides[m.currentSlide]),
+			m.flashSlideTransition("→"),
+		)
 	}
 
 	log.Debug("already at last slide")
@@ -559,64 +1576,210 @@ func (m *pagerModel) previousPage() tea.Cmd {
 		return nil
 	}
 
+	if page, _ := m.slidePage(); page > 1 {
+		return m.scrollTo((page - 2) * m.viewport.Height)
+	}
+
 	if m.currentSlide > 0 {
 		m.currentSlide--
 		m.resetScrollPosition = true
+		// Going back, tall slides start from their last page
+		if m.paginatesSlides() {
+			last := math.MaxInt
+			m.pendingYOffset = &last
+		}
 		log.Debug("navigating to previous slide", "slide", m.currentSlide+1, "total", len(m.slides))
-		return renderWithGlamour(*m, m.slides[m.currentSlide])
+		return tea.Batch(
+			renderWithGlamour(*m, m.slides[m.currentSlide]),
+			m.flashSlideTransition("←"),
+		)
 	}
 
 	log.Debug("already at first slide")
 	return nil
 }
 
+// gotoSlide navigates to the slide with the given index, clamped to the
+// available slides.
+func (m *pagerModel) gotoSlide(i int) tea.Cmd {
+	if !m.slideMode {
+		m.parseSlides()
+	}
+
+	if !m.slideMode || len(m.slides) == 0 {
+		log.Debug("no slides found for navigation")
+		return nil
+	}
+
+	i = max(0, min(i, len(m.slides)-1))
+	if i == m.currentSlide {
+		return nil
+	}
+
+	arrow := "→"
+	if i < m.currentSlide {
+		arrow = "←"
+	}
+	m.currentSlide = i
+	m.resetScrollPosition = true
+	log.Debug("navigating to slide", "slide", m.currentSlide+1, "total", len(m.slides))
+	return tea.Batch(
+		renderWithGlamour(*m, m.slides[m.currentSlide]),
+		m.flashSlideTransition(arrow),
+	)
+}
+
+// flashSlideTransition briefly announces the current slide in the status bar,
+// if enabled.
+func (m *pagerModel) flashSlideTransition(arrow string) tea.Cmd {
+	if !m.common.cfg.SlideTransitionFlash {
+		return nil
+	}
+	return m.showStatusMessage(pagerStatusMessage{
+		message: fmt.Sprintf("%s Slide %d/%d", arrow, m.currentSlide+1, len(m.slides)),
+	})
+}
+
 // COMMANDS
 
 func renderWithGlamour(m pagerModel, md string) tea.Cmd {
+	if parts := m.renderParts(md); len(parts) > 1 {
+		log.Info("rendering in parts", "parts", len(parts))
+		return renderNextPart(m, renderStream{
+			id:          renderStreams.Add(1),
+			parts:       parts,
+			sourceLines: strings.Count(md, "\n") + 1,
+		})
+	}
 	return func() tea.Msg {
-		s, err := glamourRender(m, md)
+		start := time.Now()
+		out, err := glamourRenderPart(m, md, renderPart{last: true})
 		if err != nil {
 			log.Error("error rendering with Glamour", "error", err)
 			return errMsg{err}
 		}
-		return contentRenderedMsg(s)
+		return contentRenderedMsg{content: out.content, graphics: out.graphics, duration: time.Since(start)}
+	}
+}
+
+// codeLanguage returns the language to highlight the current code file as,
+// or an empty string to render it as plain text. That's the case for
+// languages we don't know how to highlight, and for extensions the user
+// asked to always show as plain text.
+func (m pagerModel) codeLanguage() string {
+	ext := filepath.Ext(utils.UncompressedName(m.currentDocument.Note))
+	for _, plain := range m.common.cfg.PlainCodeExtensions {
+		if strings.EqualFold(strings.TrimPrefix(plain, "."), strings.TrimPrefix(ext, ".")) {
+			return ""
+		}
+	}
+
+	if lexers.Get(ext) == nil && lexers.Match(filepath.Base(m.currentDocument.Note)) == nil {
+		log.Info("unknown language, rendering as plain text", "file", m.currentDocument.Note, "language", ext)
+		return ""
 	}
+	return ext
 }
 
 // This is where the magic happens.
 func glamourRender(m pagerModel, markdown string) (string, error) {
-	trunc := lipgloss.NewStyle().MaxWidth(m.viewport.Width - lineNumberWidth).Render
+	out, err := glamourRenderPart(m, markdown, renderPart{last: true})
+	return out.content, err
+}
 
+// glamourRenderPart renders a part of a document rendered in parts, along
+// with the Kitty graphics commands showing its inline images. See
+// renderStream.
+func glamourRenderPart(m pagerModel, markdown string, part renderPart) (renderedPart, error) {
 	if !config.GlamourEnabled {
-		return markdown, nil
+		return renderedPart{content: markdown}, nil
 	}
 
 	isCode := !utils.IsMarkdownFile(m.currentDocument.Note)
 	width := max(0, min(int(m.common.cfg.GlamourMaxWidth), m.viewport.Width)) //nolint:gosec
-	if isCode {
+	if m.common.cfg.OverflowWidth {
+		width = int(m.common.cfg.GlamourMaxWidth) //nolint:gosec
+	}
+	if isCode || m.noWrap {
 		width = 0
 	}
 
+	style := utils.GlamourStyle(m.common.cfg.GlamourStyle, isCode)
+	overrides := utils.StyleOverrides{CodeTheme: m.common.cfg.CodeStyle}
+	if !isCode {
+		overrides.InlineCodeForeground = m.common.cfg.InlineCodeForeground
+		overrides.InlineCodeBackground = m.common.cfg.InlineCodeBackground
+	}
+	if overrides != (utils.StyleOverrides{}) {
+		if option, err := utils.WithStyleOverrides(m.common.cfg.GlamourStyle, isCode, overrides); err != nil {
+			log.Warn("unable to apply style overrides", "error", err)
+		} else {
+			style = option
+		}
+	}
 	options := []glamour.TermRendererOption{
-		utils.GlamourStyle(m.common.cfg.GlamourStyle, isCode),
+		style,
 		glamour.WithWordWrap(width),
 	}
-
 	if m.common.cfg.PreserveNewLines {
 		options = append(options, glamour.WithPreservedNewLines())
 	}
 	r, err := glamour.NewTermRenderer(options...)
 	if err != nil {
-		return "", fmt.Errorf("error creating glamour renderer: %w", err)
+		return renderedPart{}, fmt.Errorf("error creating glamour renderer: %w", err)
 	}
 
+	var (
+		code, lang string
+		alerts     []alertKind
+		comments   bool
+		images     map[int]*inlineImage
+		graphics   inlineImageGraphics
+		figures    int
+	)
 	if isCode {
-		markdown = utils.WrapCodeBlock(markdown, filepath.Ext(m.currentDocument.Note))
+		code, lang = markdown, m.codeLanguage()
+		if !m.fullLongLines {
+			code = cutLongLines(code, m.common.cfg.MaxLineLength)
+		}
+		markdown = utils.WrapCodeBlock(code, lang)
+	} else {
+		if !part.collapsed {
+			markdown = m.collapseSections(markdown)
+		}
+		if m.common.cfg.ReflowHardWraps {
+			markdown = reflowHardWraps(markdown)
+		}
+		markdown = breakLongWords(markdown, width)
+		if m.common.cfg.AutoDetectCodeLanguage {
+			markdown = labelCodeFences(markdown)
+		}
+		if m.common.cfg.RenderMermaid {
+			markdown = renderMermaidBlocks(markdown, m.common.cfg.MermaidCommand)
+		}
+		if m.common.cfg.FigureStyling {
+			markdown = separateFigureCaptions(markdown)
+		}
+		markdown, alerts = rewriteAlerts(markdown)
+		if m.showComments {
+			markdown, comments = revealComments(markdown)
+		}
 	}
 
-	out, err := r.Render(markdown)
+	// Images are found in rendered output by their text, like everything
+	// else, so the markdown they're embedded in is only rendered
+	rendered := markdown
+	if !isCode && m.showsInlineImages() {
+		rendered, images = m.embedImages(markdown)
+	}
+	out, err := r.Render(rendered)
+	if err != nil && isCode && lang != "" {
+		// Show the code without highlighting rather than not at all
+		log.Warn("unable to highlight code, falling back to plain text", "language", lang, "error", err)
+		out, err = r.Render(utils.WrapCodeBlock(code, ""))
+	}
 	if err != nil {
-		return "", fmt.Errorf("error rendering markdown: %w", err)
+		return renderedPart{}, fmt.Errorf("error rendering markdown: %w", err)
 	}
 
 	if isCode {
@@ -626,10 +1789,61 @@ func glamourRender(m pagerModel, markdown string) (string, error) {
 	// trim lines
 	lines := strings.Split(out, "\n")
 
+	gutter := m.gutterWidth(part.lineOffset+len(lines), part.sourceLines)
+	trunc := func(s string) string { return truncateWidth(s, m.viewport.Width-gutter, "") }
+	// Prose wider than the viewport is scrolled horizontally, so keep it
+	// whole when adding the line number gutter
+	if !isCode && (m.noWrap || width > m.viewport.Width-gutter) {
+		trunc = func(s string) string { return s }
+	}
+
+	if !isCode && m.common.cfg.HeadingHangingIndent {
+		lines = hangHeadingIndents(markdown, lines)
+	}
+	if !isCode && m.common.cfg.ListStyling {
+		lines = styleListItems(markdown, lines)
+	}
+	if len(alerts) > 0 {
+		lines = styleAlerts(alerts, lines)
+	}
+	if comments {
+		lines = styleComments(lines)
+	}
+	if !isCode && m.common.cfg.FigureStyling {
+		lines, figures = styleFigures(markdown, lines, width, part.figureOffset)
+	}
+	if !isCode && m.common.cfg.ValidateConfigBlocks {
+		lines = markConfigBlocks(markdown, lines)
+	}
+	if len(images) > 0 {
+		room := m.viewport.Width - gutter
+		if width > 0 {
+			room = min(room, width)
+		}
+		lines, graphics = placeImages(lines, images, room, m.viewport.Height-1)
+	}
+	if m.slideMode && m.slideAlign() == slideAlignCenter {
+		width := m.viewport.Width
+		if m.showsLineNumbers() {
+			width -= gutter
+		}
+		lines = centerSlide(markdown, lines, width)
+	}
+	if m.paginatesSlides() {
+		lines = paginateSlide(lines, m.viewport.Height)
+	}
+
+	// Parts are joined without the blank lines glamour ends documents with
+	if !part.last {
+		for len(lines) > 0 && strings.TrimSpace(ansi.Strip(lines[len(lines)-1])) == "" {
+			lines = lines[:len(lines)-1]
+		}
+	}
+
 	var content strings.Builder
 	for i, s := range lines {
-		if isCode || m.common.cfg.ShowLineNumbers {
-			content.WriteString(lineNumberStyle(fmt.Sprintf("%"+fmt.Sprint(lineNumberWidth)+"d", i+1)))
+		if m.showsLineNumbers() {
+			content.WriteString(lineNumberStyle(fmt.Sprintf("%*d", gutter, i+1+m.currentDocument.lineOffset+part.lineOffset)))
 			content.WriteString(trunc(s))
 		} else {
 			content.WriteString(s)
@@ -641,58 +1855,16 @@ func glamourRender(m pagerModel, markdown string) (string, error) {
 		}
 	}
 
-	return content.String(), nil
+	return renderedPart{content: content.String(), graphics: graphics, figures: figures}, nil
 }
 
 func (m *pagerModel) initWatcher() {
-	var err error
-	m.watcher, err = fsnotify.NewWatcher()
+	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		log.Error("error creating fsnotify watcher", "error", err)
+		return
 	}
-}
-
-func (m *pagerModel) watchFile() tea.Msg {
-	dir := m.localDir()
-
-	if err := m.watcher.Add(dir); err != nil {
-		log.Error("error adding dir to fsnotify watcher", "error", err)
-		return nil
-	}
-
-	log.Info("fsnotify watching dir", "dir", dir)
-
-	for {
-		select {
-		case event, ok := <-m.watcher.Events:
-			if !ok || event.Name != m.currentDocument.localPath {
-				continue
-			}
-
-			if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
-				continue
-			}
-
-			log.Debug("fsnotify event", "file", event.Name, "event", event.Op)
-			return reloadMsg{}
-		case err, ok := <-m.watcher.Errors:
-			if !ok {
-				continue
-			}
-			log.Debug("fsnotify error", "dir", dir, "error", err)
-		}
-	}
-}
-
-func (m *pagerModel) unwatchFile() {
-	dir := m.localDir()
-
-	err := m.watcher.Remove(dir)
-	if err == nil {
-		log.Debug("fsnotify dir unwatched", "dir", dir)
-	} else {
-		log.Error("fsnotify fail to unwatch dir", "dir", dir, "error", err)
-	}
+	m.watcher = newWatchFanout(watcher)
 }
 
 func (m *pagerModel) localDir() string {
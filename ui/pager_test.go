@@ -0,0 +1,1108 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestCopySourceOfCodeFile(t *testing.T) {
+	const src = "---\nname: glow\n---\n\tfunc main() {\n\t\tfmt.Println(\"hi\")  \n\t}\n"
+
+	path := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(path, []byte(src), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	m := newModel(Config{Path: path, GlamourEnabled: true}, "").(model)
+	msg := loadLocalMarkdown(&m.pager.currentDocument)()
+	if _, ok := msg.(fetchedMarkdownMsg); !ok {
+		t.Fatalf("expected fetchedMarkdownMsg, got %T", msg)
+	}
+
+	updated, _ := m.Update(msg)
+	doc := updated.(model).pager.currentDocument
+	if doc.source != src {
+		t.Errorf("expected copied contents to match the file on disk\nwant: %q\ngot:  %q", src, doc.source)
+	}
+	if doc.Body != src {
+		t.Errorf("expected code file body to be left untouched, got %q", doc.Body)
+	}
+}
+
+func TestSingleSlide(t *testing.T) {
+	const deck = "Intro\n\n# 1. The only slide\n\nSome text\n"
+
+	tt := []struct {
+		setting       string
+		wantSlideMode bool
+		wantIndicator bool
+	}{
+		{singleSlideShow, true, true},
+		{"", true, true},
+		{singleSlideHide, true, false},
+		{singleSlideDocument, false, false},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.setting, func(t *testing.T) {
+			common := &commonModel{
+				cfg:   Config{PresentationMode: true, SingleSlide: tc.setting},
+				width: 80,
+			}
+			m := newPagerModel(common)
+			m.currentDocument.Body = deck
+			m.parseSlides()
+
+			if m.slideMode != tc.wantSlideMode {
+				t.Errorf("expected slide mode to be %t", tc.wantSlideMode)
+			}
+
+			var b strings.Builder
+			m.statusBarView(&b)
+			if got := strings.Contains(b.String(), "Slide 1/1"); got != tc.wantIndicator {
+				t.Errorf("expected slide indicator to be shown: %t, status bar: %q", tc.wantIndicator, b.String())
+			}
+
+			if cmd := m.nextPage(); cmd != nil {
+				t.Errorf("expected next slide to do nothing")
+			}
+			if cmd := m.previousPage(); cmd != nil {
+				t.Errorf("expected previous slide to do nothing")
+			}
+			if m.currentSlide != 0 {
+				t.Errorf("expected to stay on the first slide, got %d", m.currentSlide)
+			}
+		})
+	}
+}
+
+func TestSplitSlides(t *testing.T) {
+	tt := []struct {
+		name      string
+		separator string
+		pattern   string
+		doc       string
+		want      []string
+	}{
+		{
+			name:      "numbered h1",
+			separator: slideSeparatorNumberedH1,
+			doc:       "Intro\n\n# 1. One\n\n# Aside\n\n# 2. Two\n",
+			want:      []string{"# 1. One\n\n# Aside\n", "# 2. Two\n"},
+		},
+		{
+			name:      "numbered h1 with leading zeros",
+			separator: slideSeparatorNumberedH1,
+			doc:       "# 01. One\n\n# 02. Two\n",
+			want:      []string{"# 01. One\n", "# 02. Two\n"},
+		},
+		{
+			name:      "roman numerals",
+			separator: slideSeparatorNumberedH1,
+			pattern:   `^[IVXLC]+\.\s`,
+			doc:       "# I. One\n\n# Intro\n\n# IV. Four\n",
+			want:      []string{"# I. One\n\n# Intro\n", "# IV. Four\n"},
+		},
+		{
+			name:      "section signs",
+			separator: slideSeparatorNumberedH1,
+			pattern:   `^§\s*\d`,
+			doc:       "# §1 Intro\n\n# 2. Not a slide\n\n# § 2 Topic\n",
+			want:      []string{"# §1 Intro\n\n# 2. Not a slide\n", "# § 2 Topic\n"},
+		},
+		{
+			name:      "invalid pattern",
+			separator: slideSeparatorNumberedH1,
+			pattern:   `^(`,
+			doc:       "# 1. One\n\n# Aside\n",
+			want:      []string{"# 1. One\n\n# Aside\n"},
+		},
+		{
+			name:      "h1",
+			separator: slideSeparatorH1,
+			doc:       "Intro\n\n# One\n\n## Sub\n\nTwo\n===\n",
+			want:      []string{"# One\n\n## Sub\n", "Two\n===\n"},
+		},
+		{
+			name:      "rules",
+			separator: slideSeparatorHR,
+			doc:       "One\n\n---\n\n```yaml\n---\n```\n\n ---\nThree\n",
+			want:      []string{"One", "```yaml\n---\n```", "Three"},
+		},
+		{
+			name:      "rules after front matter",
+			separator: slideSeparatorHR,
+			doc:       "---\ntitle: Deck\n---\nOne\n---\nTwo\n",
+			want:      []string{"One", "Two"},
+		},
+		{
+			name:      "leading rule",
+			separator: slideSeparatorHR,
+			doc:       "---\nOne\n---\nTwo\n",
+			want:      []string{"One", "Two"},
+		},
+		{
+			name:      "no rules",
+			separator: slideSeparatorHR,
+			doc:       "# 1. One\n\nText\n",
+			want:      nil,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got := splitSlides(tc.doc, tc.separator, compileSlideHeaderPattern(tc.pattern))
+			if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", tc.want) {
+				t.Errorf("expected slides %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestPaginateSlide(t *testing.T) {
+	lines := []string{"", "Title", "", "One", "two", "three", "", "• four", "• five", "• six", "", ""}
+
+	got := paginateSlide(lines, 5)
+	want := []string{
+		"", "Title", "", "", "",
+		"", "One", "two", "three", "",
+		"• four", "• five", "• six", "", "",
+	}
+	if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", want) {
+		t.Errorf("expected pages %q, got %q", want, got)
+	}
+
+	if got := paginateSlide(lines, len(lines)); len(got) != len(lines) {
+		t.Errorf("expected a slide that fits to be left as it is, got %q", got)
+	}
+}
+
+func TestFileLine(t *testing.T) {
+	const source = "---\ntitle: Deck\n---\n# 1. One\n\nText\n\n# 2. Two\n\nMore text\n"
+
+	tt := []struct {
+		name string
+		md   string
+		line int
+		want int
+	}{
+		{"whole file", source, 3, 4},
+		{"without front matter", "# 1. One\n\nText\n\n# 2. Two\n\nMore text\n", 2, 6},
+		{"slide", "# 2. Two\n\nMore text", 2, 10},
+		{"with a title", "# Deck\n\n# 1. One\n\nText", 4, 6},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := fileLine(source, tc.md, tc.line); got != tc.want {
+				t.Errorf("expected line %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRenderInParts(t *testing.T) {
+	var b strings.Builder
+	for i := range 100 {
+		fmt.Fprintf(&b, "## Section %d\n\nSee [the docs][docs]. %s\n\n", i+1, strings.Repeat("Lorem ipsum dolor. ", 100))
+		fmt.Fprintf(&b, "![Chart %d](chart.png)\n*Results of section %d*\n\n", i+1, i+1)
+		if i%30 == 0 {
+			fmt.Fprintf(&b, "<details>\n<summary>Notes %d</summary>\n\nHidden notes %d\n\n</details>\n\n", i+1, i+1)
+		}
+	}
+	b.WriteString("[docs]: https://example.com\n")
+	md := b.String()
+
+	cfg := Config{GlamourEnabled: true, GlamourStyle: "dark", GlamourMaxWidth: 80, ShowLineNumbersProse: true, StreamRenderThreshold: 1, FigureStyling: true}
+	config = cfg
+	t.Cleanup(func() { config = Config{} })
+
+	m := newPagerModel(&commonModel{cfg: cfg})
+	m.setSize(80, 24)
+	m.currentDocument.Note = "doc.md"
+
+	// Parts render the same as the whole document, but for the padding of
+	// blank lines between them
+	normalize := func(s string) []string {
+		lines := strings.Split(ansi.Strip(s), "\n")
+		for i, l := range lines {
+			lines[i] = strings.TrimRight(l, " ")
+		}
+		return lines
+	}
+	renderInParts := func() string {
+		parts := m.renderParts(md)
+		if len(parts) < 2 {
+			t.Fatalf("expected the document to be split up, got %d parts", len(parts))
+		}
+		var (
+			rendered []string
+			lines    int
+			figures  int
+		)
+		for i, part := range parts {
+			out, err := glamourRenderPart(m, part, renderPart{lineOffset: lines, figureOffset: figures, collapsed: true, last: i == len(parts)-1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			rendered = append(rendered, out.content)
+			lines += strings.Count(out.content, "\n") + 1
+			figures += out.figures
+		}
+		return strings.Join(rendered, "\n")
+	}
+
+	var folded int
+	for _, h := range parseHeadings(md) {
+		if h.text == "Section 80" {
+			folded = h.line
+		}
+	}
+	tests := []struct {
+		name     string
+		folded   map[int]bool
+		expanded map[int]bool
+		want     []string
+	}{
+		{name: "unfolded", want: []string{"Results of section 80"}},
+		{
+			name:     "folded",
+			folded:   map[int]bool{folded: true},
+			expanded: map[int]bool{2: true},
+			want:     []string{foldedMarker + " Section 80", "Hidden notes 61"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m.folded, m.detailsExpanded = tc.folded, tc.expanded
+			whole, err := glamourRender(m, md)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, w := range tc.want {
+				if !strings.Contains(ansi.Strip(whole), w) {
+					t.Fatalf("expected %q in the rendered document", w)
+				}
+			}
+
+			want, got := normalize(whole), normalize(renderInParts())
+			if len(got) != len(want) {
+				t.Fatalf("expected %d lines, got %d", len(want), len(got))
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Fatalf("line %d differs\nwant: %q\ngot:  %q", i+1, want[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLineNumberGutterWidth(t *testing.T) {
+	var b strings.Builder
+	for i := range 12000 {
+		fmt.Fprintf(&b, "x = %d\n", i+1)
+	}
+
+	cfg := Config{GlamourEnabled: true, GlamourStyle: "dark", GlamourMaxWidth: 80, ShowLineNumbersCode: true}
+	config = cfg
+	t.Cleanup(func() { config = Config{} })
+
+	m := newPagerModel(&commonModel{cfg: cfg})
+	m.setSize(80, 24)
+	m.currentDocument.Note = "big.py"
+
+	out, err := glamourRender(m, b.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.setContent(out)
+	if m.lineNumberWidth != 5 {
+		t.Errorf("expected a gutter 5 columns wide, got %d", m.lineNumberWidth)
+	}
+
+	lines := strings.Split(ansi.Strip(out), "\n")
+	if len(lines) < 12000 {
+		t.Fatalf("expected at least 12000 lines, got %d", len(lines))
+	}
+	for _, want := range []struct {
+		line   int
+		gutter string
+	}{
+		{0, "    1"},
+		{9, "   10"},
+		{9998, " 9999"},
+		{11999, "12000"},
+	} {
+		if got := lines[want.line][:5]; got != want.gutter {
+			t.Errorf("expected line %d to be numbered %q, got %q", want.line+1, want.gutter, got)
+		}
+		if text := strings.TrimSpace(lines[want.line][5:]); !strings.HasPrefix(text, "x = ") {
+			t.Errorf("expected line %d to follow the gutter, got %q", want.line+1, lines[want.line])
+		}
+	}
+}
+
+func TestScrollPercentAtBoundaries(t *testing.T) {
+	for _, lines := range []int{1, 10, 11, 12, 99, 200, 201, 1000, 1999} {
+		t.Run(fmt.Sprint(lines), func(t *testing.T) {
+			m := newPagerModel(&commonModel{})
+			m.viewport.Height = 10
+			m.viewport.SetContent(strings.Repeat("line\n", lines-1) + "line")
+
+			m.viewport.GotoBottom()
+			if got := m.scrollPercent(); got != 100 {
+				t.Errorf("expected 100%% at the bottom, got %d%%", got)
+			}
+			if m.viewport.AtBottom() && m.viewport.AtTop() {
+				return // the whole document fits
+			}
+
+			m.viewport.GotoTop()
+			if got := m.scrollPercent(); got != 0 {
+				t.Errorf("expected 0%% at the top, got %d%%", got)
+			}
+
+			m.viewport.GotoBottom()
+			m.viewport.ScrollUp(1)
+			if m.viewport.AtTop() {
+				return
+			}
+			want := int(math.RoundToEven(m.viewport.ScrollPercent() * 100))
+			if got := m.scrollPercent(); got != want {
+				t.Errorf("expected %d%% a line above the bottom, got %d%%", want, got)
+			}
+		})
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	source := "one\ntwo\n```go\nx\n```\nsix\nseven\n"
+
+	got, from, to := snippet("docs/notes.md.gz", source, 4, 2)
+	want := "notes.md#L2-L6\n\n````markdown\ntwo\n```go\nx\n```\nsix\n````\n"
+	if got != want || from != 2 || to != 6 {
+		t.Errorf("expected lines 2-6 as\n%s\ngot lines %d-%d as\n%s", want, from, to, got)
+	}
+
+	// Context is cut short at either end of the file
+	got, from, to = snippet("main.go", "a\nb\n", 1, 3)
+	want = "main.go#L1-L2\n\n```go\na\nb\n```\n"
+	if got != want || from != 1 || to != 2 {
+		t.Errorf("expected lines 1-2 as\n%s\ngot lines %d-%d as\n%s", want, from, to, got)
+	}
+}
+
+func TestRememberPosition(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	m := newPagerModel(&commonModel{cfg: Config{RememberPosition: true}})
+	m.setSize(80, 10)
+	m.currentDocument.localPath = filepath.Join(t.TempDir(), "notes.md")
+	m.setContent(strings.Repeat("line\n", 100))
+
+	m.viewport.SetYOffset(42)
+	m.savePosition()
+	if offset, ok := m.savedPosition(); !ok || offset != 42 {
+		t.Errorf("expected position 42 to be saved, got %d (%t)", offset, ok)
+	}
+
+	// Documents read from the top aren't kept
+	m.viewport.SetYOffset(0)
+	m.savePosition()
+	if offset, ok := m.savedPosition(); ok {
+		t.Errorf("expected no saved position, got %d", offset)
+	}
+
+	// Nor is anything, unless asked to
+	m.common.cfg.RememberPosition = false
+	m.viewport.SetYOffset(42)
+	m.savePosition()
+	m.common.cfg.RememberPosition = true
+	if offset, ok := m.savedPosition(); ok {
+		t.Errorf("expected no saved position, got %d", offset)
+	}
+}
+
+func TestFlashScrollPosition(t *testing.T) {
+	m := newPagerModel(&commonModel{})
+	m.setSize(80, 10)
+	m.setContent(strings.Repeat("line\n", 100))
+
+	m.viewport.HalfViewDown()
+	m.flashScrollPosition(0)
+	if m.state != pagerStateStatusMessage || m.statusMessage != fmt.Sprintf("↓ %d%%", m.scrollPercent()) {
+		t.Errorf("expected the jump to be flashed, got %q", m.statusMessage)
+	}
+
+	// Flashes replace each other
+	m.viewport.HalfViewUp()
+	m.flashScrollPosition(m.viewport.Height / 2)
+	if m.statusMessage != "↑ 0%" {
+		t.Errorf("expected the jump back to be flashed, got %q", m.statusMessage)
+	}
+
+	// But not other messages
+	m.showStatusMessage(pagerStatusMessage{message: "Copied contents"})
+	m.viewport.HalfViewDown()
+	m.flashScrollPosition(0)
+	if m.statusMessage != "Copied contents" {
+		t.Errorf("expected the status message to be kept, got %q", m.statusMessage)
+	}
+}
+
+func TestExtractSlideNotes(t *testing.T) {
+	slides := []string{
+		"# One\n\nHello\n\n<!-- notes: Say hi -->",
+		"# Two\n\n<!-- notes:\n    Mention the benchmarks\n      and the caveats\n-->\nBye\n\n```\n<!-- notes: code -->\n```",
+		"# Three",
+	}
+	slides, notes := extractSlideNotes(slides)
+
+	expectedSlides := []string{
+		"# One\n\nHello\n",
+		"# Two\n\nBye\n\n```\n<!-- notes: code -->\n```",
+		"# Three",
+	}
+	expectedNotes := []string{"Say hi", "Mention the benchmarks\n  and the caveats", ""}
+	for i := range slides {
+		if slides[i] != expectedSlides[i] {
+			t.Errorf("slide %d: expected %q, got %q", i+1, expectedSlides[i], slides[i])
+		}
+		if notes[i] != expectedNotes[i] {
+			t.Errorf("notes %d: expected %q, got %q", i+1, expectedNotes[i], notes[i])
+		}
+	}
+}
+
+func TestSlideProgressBar(t *testing.T) {
+	m := newPagerModel(&commonModel{cfg: Config{PresentationMode: true, SlideProgressBar: true, SlideSeparator: slideSeparatorH1}})
+	m.common.width, m.common.height = 40, 10
+	m.currentDocument.Body = "# One\n\n# Two\n\n# Three\n"
+	m.parseSlides()
+
+	if m.viewport.Height != 10-statusBarHeight-1 {
+		t.Errorf("expected the bar to take up a line, got a viewport of %d", m.viewport.Height)
+	}
+	for slide, filled := range []int{0, 20, 40} {
+		m.currentSlide = slide
+		bar := ansi.Strip(m.progressBarView())
+		if n := strings.Count(bar, "█"); n != filled || stringWidth(bar) != 40 {
+			t.Errorf("slide %d: expected %d of 40 filled, got %q", slide+1, filled, bar)
+		}
+	}
+}
+
+func TestCountPrefix(t *testing.T) {
+	m := newPagerModel(&commonModel{})
+	m.setSize(80, 10)
+	m.setContent(strings.Repeat("line\n", 100))
+
+	for _, k := range "042" {
+		m.handleCount(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{k}})
+	}
+	if m.count != "42" {
+		t.Errorf("expected a count of 42, got %q", m.count)
+	}
+	if _, ok := m.handleCount(tea.KeyMsg{Type: tea.KeyEsc}); !ok || m.count != "" {
+		t.Errorf("expected esc to drop the count, got %q", m.count)
+	}
+
+	m.handleCount(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'7'}})
+	m.handleCount(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.count != "" || m.viewport.YOffset != 6 {
+		t.Errorf("expected to jump to line 7, got offset %d", m.viewport.YOffset)
+	}
+}
+
+func TestLinkAt(t *testing.T) {
+	m := newPagerModel(&commonModel{})
+	m.setSize(80, 10)
+	m.currentDocument.Body = "# Hi\n\nSee [the docs](https://example.com) and [more](b.md) here.\n"
+	m.setContent("\n  Hi\n\n  See the docs https://example.com and more /b.md here.\n")
+
+	tt := []struct {
+		x, y int
+		want string
+	}{
+		{2, 3, ""},
+		{6, 3, "https://example.com"},
+		{20, 3, "https://example.com"},
+		{35, 3, ""},
+		{40, 3, "b.md"},
+		{6, 1, ""},
+	}
+	for _, tc := range tt {
+		l, _ := m.linkAt(tc.x, tc.y)
+		if l.url != tc.want {
+			t.Errorf("at %d,%d: expected link %q, got %q", tc.x, tc.y, tc.want, l.url)
+		}
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	md := "# Title\n\nSome *emph*, **bold**, ~~struck~~ and `code`.\n\n> Quoted\n\n```go\nfunc main() {}\n```\n"
+	got, err := plainText(md)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Title\n\nSome emph, bold, struck and code.\n\n> Quoted\n\nfunc main() {}\n"
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestKeyBindings(t *testing.T) {
+	tt := []struct {
+		name     string
+		bindings map[string][]string
+		err      string
+	}{
+		{"defaults", nil, ""},
+		{"rebound", map[string][]string{"search": {"x"}, "pagedown": {"space"}}, ""},
+		{"unknown action", map[string][]string{"fly": {"x"}}, `unknown action "fly"`},
+		{"conflict", map[string][]string{"search": {"q"}}, "key q is bound to both search and quit"},
+		{"reserved", map[string][]string{"copy": {"esc"}}, "key esc is reserved, and can't be bound to copy"},
+		{"readme example", map[string][]string{"pageDown": {"space", "ctrl+f"}, "toggleFold": {"zc"}}, ""},
+		{"sequence", map[string][]string{"top": {"gg"}}, ""},
+		{"sequence prefix", map[string][]string{"quit": {"z"}}, "key z is bound to quit, and begins za, bound to toggleFold"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newPagerKeyMap(tc.bindings)
+			if tc.err == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.err != "" && (err == nil || err.Error() != tc.err) {
+				t.Fatalf("expected error %q, got %v", tc.err, err)
+			}
+		})
+	}
+
+	m := newPagerModel(&commonModel{cfg: Config{KeyBindings: map[string][]string{"pageDown": {"space"}}}})
+	m.setSize(80, 12)
+	m.setContent(strings.Repeat("line\n", 100))
+	m, _ = m.update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	if m.viewport.YOffset != m.viewport.Height {
+		t.Errorf("expected space to page down, got offset %d", m.viewport.YOffset)
+	}
+	m, _ = m.update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'f'}})
+	if m.viewport.YOffset != m.viewport.Height {
+		t.Errorf("expected f to no longer page down, got offset %d", m.viewport.YOffset)
+	}
+	help := m.helpView()
+	if !strings.Contains(help, "space") || strings.Contains(help, "f/pgdn") {
+		t.Errorf("expected help to show the rebound keys, got:\n%s", help)
+	}
+	if !strings.Contains(help, "h/l ←/→") || !strings.Contains(help, "n/p") {
+		t.Errorf("expected help to show the arrows scrolling and slides together, got:\n%s", help)
+	}
+}
+
+func TestNextHeading(t *testing.T) {
+	m := newPagerModel(&commonModel{})
+	m.setSize(80, 5)
+	m.currentDocument.Note = "doc.md"
+	m.currentDocument.Body = "# One\n\n" + strings.Repeat("text\n\n", 5) + "## Two\n\n" + strings.Repeat("text\n\n", 10)
+	m.setContent("  # One\n\n" + strings.Repeat("  text\n\n", 5) + "  ## Two\n\n" + strings.Repeat("  text\n\n", 10))
+
+	m.nextHeading(false)
+	if m.viewport.YOffset != 12 {
+		t.Errorf("expected to scroll to the second heading, got offset %d", m.viewport.YOffset)
+	}
+	m.nextHeading(false)
+	if m.viewport.YOffset != 12 || m.statusMessage != "Last heading" {
+		t.Errorf("expected to stay on the last heading, got offset %d and %q", m.viewport.YOffset, m.statusMessage)
+	}
+	m.nextHeading(true)
+	if m.viewport.YOffset != 0 {
+		t.Errorf("expected to scroll back to the first heading, got offset %d", m.viewport.YOffset)
+	}
+
+	m.slideMode = true
+	if m.nextHeading(false); m.viewport.YOffset != 0 {
+		t.Errorf("expected no heading navigation in slides, got offset %d", m.viewport.YOffset)
+	}
+}
+
+func TestMirrorSlide(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "slide.md")
+	m := newPagerModel(&commonModel{cfg: Config{SlideMirrorPath: path}})
+	m.slideMode = true
+	m.slides = []string{"# 1 One", "# 2 Two"}
+
+	for _, slide := range []int{0, 1} {
+		m.currentSlide = slide
+		if cmd := m.mirrorSlide(); cmd != nil {
+			cmd()
+		}
+		if data, err := os.ReadFile(path); err != nil || string(data) != m.slides[slide] {
+			t.Fatalf("expected slide %d to be mirrored, got %q (%v)", slide+1, data, err)
+		}
+	}
+	if cmd := m.mirrorSlide(); cmd != nil {
+		t.Error("expected an unchanged slide not to be written again")
+	}
+
+	// Writes still on their way when the deck's closed are dropped
+	m.currentSlide = 0
+	cmd := m.mirrorSlide()
+	m.clearSlideMirror()
+	cmd()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the mirror to be removed, got %v", err)
+	}
+}
+
+func TestToggleFootnote(t *testing.T) {
+	m := newPagerModel(&commonModel{})
+	m.setSize(80, 4)
+	m.currentDocument.Note = "doc.md"
+	m.currentDocument.Body = "Text[^1] and[^x].\n\n" + strings.Repeat("More\n\n", 5) + "[^1]: A note[^2].\n[^2]: Another.\n"
+	m.setContent("  Text[^1] and[^x].\n\n" + strings.Repeat("  More\n\n", 5) + "  [^1]: A note[^2]. [^2]: Another.\n\n\n\n")
+
+	m.toggleFootnote()
+	if m.viewport.YOffset != 12 {
+		t.Fatalf("expected to jump to the definition, got offset %d", m.viewport.YOffset)
+	}
+	m.toggleFootnote()
+	if m.viewport.YOffset != 0 {
+		t.Fatalf("expected to jump back to the reference, got offset %d", m.viewport.YOffset)
+	}
+
+	m.setContent("  Text[^x].\n\n  [^1]: A note.\n")
+	m.footnoteJumps = nil
+	m.toggleFootnote()
+	if m.statusMessage != "No definition for footnote [^x]" {
+		t.Errorf("expected a missing definition to be reported, got %q", m.statusMessage)
+	}
+}
+
+func TestWordCountNote(t *testing.T) {
+	body := strings.Repeat("one two three four five\n", 250) + "```\nnot counted\n```\n"
+	s := newDocumentStats(body)
+	if got, want := s.note(200), "1,250 words · 5,750 chars · ~7 min read"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if got := s.readingTime(500); got != 3 {
+		t.Errorf("expected 3 minutes at 500 wpm, got %d", got)
+	}
+
+	m := newPagerModel(&commonModel{width: 60, cfg: Config{ReadingWPM: 200}})
+	m.stats = s
+	m.showWordCount = true
+	var b strings.Builder
+	m.statusBarView(&b)
+	if bar := ansi.Strip(b.String()); !strings.Contains(bar, "1,250 words · 5,750 chars") || stringWidth(bar) != 60 {
+		t.Errorf("expected the word count to fit the status bar, got %q", bar)
+	}
+}
+
+func TestAutoAdvance(t *testing.T) {
+	m := newPagerModel(&commonModel{cfg: Config{SlideAutoAdvance: time.Second, SlideLoop: true}})
+	m.setSize(80, 10)
+	m.slideMode = true
+	m.slides = []string{"# 1 One", "# 2 Two"}
+
+	if m.scheduleAutoAdvance() == nil {
+		t.Fatal("expected a countdown to the next slide")
+	}
+	stale := autoAdvanceMsg{m.autoAdvanceGen - 1}
+	if m.handleAutoAdvance(stale); m.currentSlide != 0 {
+		t.Errorf("expected an outdated countdown to be ignored, got slide %d", m.currentSlide+1)
+	}
+	if m.handleAutoAdvance(autoAdvanceMsg{m.autoAdvanceGen}); m.currentSlide != 1 {
+		t.Errorf("expected to move on to slide 2, got slide %d", m.currentSlide+1)
+	}
+	if m.handleAutoAdvance(autoAdvanceMsg{m.autoAdvanceGen}); m.currentSlide != 0 {
+		t.Errorf("expected to loop back to slide 1, got slide %d", m.currentSlide+1)
+	}
+
+	m.toggleAutoAdvance()
+	if m.handleAutoAdvance(autoAdvanceMsg{m.autoAdvanceGen}); m.currentSlide != 0 || m.autoAdvanceView() != "[auto: paused]" {
+		t.Errorf("expected no moving on while paused, got slide %d", m.currentSlide+1)
+	}
+}
+
+func TestFoldSections(t *testing.T) {
+	md := "# One\n\ntext\n\n## Two\n\nmore\ntext\n\n# Three\n\nend\n"
+
+	if got := foldSections(md, nil); got != md {
+		t.Errorf("expected nothing folded, got %q", got)
+	}
+	if got, want := foldSections(md, map[int]bool{4: true}),
+		"# One\n\ntext\n\n## ▸ Two (2 lines hidden)\n\n# Three\n\nend\n"; got != want {
+		t.Errorf("expected the section to be folded up to the next heading, got %q", got)
+	}
+	if got, want := foldSections(md, map[int]bool{0: true, 4: true}),
+		"# ▸ One (6 lines hidden)\n\n# Three\n\nend\n"; got != want {
+		t.Errorf("expected subsections to be folded away with their parent, got %q", got)
+	}
+
+	m := newPagerModel(&commonModel{})
+	m.setSize(80, 5)
+	m.currentDocument.Note = "doc.md"
+	m.currentDocument.Body = md
+	m.setContent("  # One\n\n  text\n\n  ## Two\n\n  more\n  text\n\n  # Three\n\n  end\n")
+	m.viewport.SetYOffset(4)
+
+	for _, r := range "za" {
+		m, _ = m.update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if !m.folded[4] || m.pendingKeys != "" {
+		t.Errorf("expected za to fold the section in view, got %v", m.folded)
+	}
+	for _, r := range "zR" {
+		m, _ = m.update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if len(m.folded) != 0 {
+		t.Errorf("expected zR to unfold everything, got %v", m.folded)
+	}
+
+	// Keys that don't carry on a sequence are handled as usual
+	m.viewport.SetYOffset(0)
+	for _, r := range "zj" {
+		m, _ = m.update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if m.viewport.YOffset != 1 || m.pendingKeys != "" {
+		t.Errorf("expected j to scroll down after z, got offset %d", m.viewport.YOffset)
+	}
+}
+
+func TestStatusBarSegments(t *testing.T) {
+	segments := []string{"filename", "Note", "bogus", "percent"}
+	m := newPagerModel(&commonModel{width: 40, cfg: Config{StatusBarSegments: segments}})
+	if got, want := m.statusBarSegments, []string{"filename", "note", "percent"}; !slices.Equal(got, want) {
+		t.Fatalf("expected unknown segments to be left out, got %v", got)
+	}
+	m.currentDocument = markdown{localPath: "/docs/guide.md", Note: "docs/guide.md"}
+
+	var b strings.Builder
+	m.statusBarView(&b)
+	bar := ansi.Strip(b.String())
+	if !strings.HasPrefix(bar, " guide.md  docs/guide.md ") || !strings.HasSuffix(bar, " 100% ") || stringWidth(bar) != 40 {
+		t.Errorf("expected the segments in order, filling the status bar, got %q", bar)
+	}
+
+	// Without a note, the room's left empty
+	m.statusBarSegments = []string{"percent"}
+	b.Reset()
+	m.statusBarView(&b)
+	if bar := ansi.Strip(b.String()); bar != " 100% "+strings.Repeat(" ", 34) {
+		t.Errorf("expected the status bar to be filled, got %q", bar)
+	}
+}
+
+func TestCycleStyle(t *testing.T) {
+	cfg := Config{GlamourEnabled: true, GlamourStyle: "dark", CodeStyle: "monokai"}
+	config = cfg
+	t.Cleanup(func() { config = Config{} })
+	m := newPagerModel(&commonModel{cfg: cfg})
+
+	names := pickableStyles("dark")
+	m.cycleStyle()
+	if want := names[slices.Index(names, "dark")+1]; m.common.cfg.GlamourStyle != want {
+		t.Errorf("expected to move on to %s, got %s", want, m.common.cfg.GlamourStyle)
+	}
+	if want := "Style: " + styleName(m.common.cfg.GlamourStyle) + " (code: monokai)"; m.statusMessage != want {
+		t.Errorf("expected %q, got %q", want, m.statusMessage)
+	}
+
+	m.common.cfg.GlamourStyle = names[len(names)-1]
+	if m.cycleStyle(); m.common.cfg.GlamourStyle != names[0] {
+		t.Errorf("expected to go back to the first style, got %s", m.common.cfg.GlamourStyle)
+	}
+}
+
+func TestSpotlight(t *testing.T) {
+	m := newPagerModel(&commonModel{})
+	m.setSize(80, 5)
+	m.currentDocument.Note = "doc.md"
+	m.currentDocument.Body = "one\n\ntwo\n\nthree\n\nfour\n\nfive\n\nsix\n"
+	rendered := "  \x1b[1mone\x1b[0m\n\n  \x1b[1mtwo\x1b[0m\n\n  \x1b[1mthree\x1b[0m\n\n  \x1b[1mfour\x1b[0m\n\n  \x1b[1mfive\x1b[0m\n\n  \x1b[1msix\x1b[0m\n"
+	m.setContent(rendered)
+
+	m.toggleSpotlight()
+	if m.spotlit != [2]int{2, 3} || m.statusMessage != "Spotlight: on" {
+		t.Fatalf("expected the paragraph in the middle to be in the spotlight, got %v and %q", m.spotlit, m.statusMessage)
+	}
+	lines := strings.Split(m.displayedContent(), "\n")
+	if lines[2] != "  \x1b[1mtwo\x1b[0m" || strings.Contains(lines[0], "\x1b[1m") {
+		t.Errorf("expected only the paragraph in the spotlight to keep its style, got %q", lines[:3])
+	}
+
+	// Scrolling moves the spotlight along
+	m.viewport.SetYOffset(2)
+	m.followSpotlight()
+	if m.spotlit != [2]int{4, 5} {
+		t.Errorf("expected the spotlight to follow scrolling, got %v", m.spotlit)
+	}
+
+	m.toggleSpotlight()
+	if m.spotlit != [2]int{} || m.displayedContent() != rendered {
+		t.Errorf("expected the content to be restored, got %v", m.spotlit)
+	}
+
+	m.slideMode = true
+	if m.toggleSpotlight(); m.spotlight {
+		t.Error("expected no spotlight in slides")
+	}
+}
+
+func TestScrollToAnchor(t *testing.T) {
+	m := newPagerModel(&commonModel{cfg: Config{Anchor: "getting-started"}})
+	m.setSize(80, 5)
+	m.currentDocument.Note = "doc.md"
+	m.currentDocument.Body = "# Intro\n\n" + strings.Repeat("text\n\n", 5) + "## Getting Started\n\n" + strings.Repeat("text\n\n", 10)
+	m.setContent("  # Intro\n\n" + strings.Repeat("  text\n\n", 5) + "  ## Getting Started\n\n" + strings.Repeat("  text\n\n", 10))
+
+	if m.scrollToAnchor(); m.viewport.YOffset != 12 || m.anchor != "" {
+		t.Errorf("expected to open at the heading, got offset %d", m.viewport.YOffset)
+	}
+
+	m.anchor = "usage"
+	if m.scrollToAnchor(); m.viewport.YOffset != 0 || m.statusMessage != "No heading #usage, starting at the top" {
+		t.Errorf("expected to start at the top, got offset %d and %q", m.viewport.YOffset, m.statusMessage)
+	}
+}
+
+func TestOpenLinkRefusesPrograms(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "run.sh"), []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	m := newPagerModel(&commonModel{})
+	m.currentDocument = markdown{localPath: filepath.Join(dir, "doc.md"), Note: "doc.md"}
+
+	for dest, want := range map[string]string{
+		"run.sh":              "Only markdown files are opened: " + filepath.Join(dir, "run.sh"),
+		"file:///bin/sh":      "Not opening file: links",
+		"javascript:alert(1)": "Not opening javascript: links",
+		"missing.md":          "No such file: " + filepath.Join(dir, "missing.md"),
+	} {
+		if cmd := m.openLink(link{url: dest}); cmd == nil || m.statusMessage != want {
+			t.Errorf("expected %q for %s, got %q", want, dest, m.statusMessage)
+		}
+	}
+}
+
+func TestShowLineNumbersAlias(t *testing.T) {
+	config = Config{GlamourEnabled: true}
+	t.Cleanup(func() { config = Config{} })
+
+	m := newPagerModel(&commonModel{cfg: Config{ShowLineNumbers: true}})
+	for _, note := range []string{"doc.md", "main.go"} {
+		if m.currentDocument.Note = note; !m.showsLineNumbers() {
+			t.Errorf("expected line numbers for %s", note)
+		}
+	}
+}
+
+func TestFindSearchMatches(t *testing.T) {
+	m := newPagerModel(&commonModel{})
+	m.setSize(80, 10)
+	m.currentDocument.Note = "doc.md"
+	m.currentDocument.Body = "# Intro\n\nThe quick brown fox jumps over the lazy dog, the brown\nfox again.\n"
+	m.setContent("  # Intro\n\n  The quick brown\n  fox jumps over the lazy dog, the brown fox\n  again.\n")
+
+	m.searchQuery = "Brown fox"
+	m.findSearchMatches()
+	if len(m.searchMatches) != 2 {
+		t.Fatalf("expected the phrase to be found across wrapped lines, got %d matches", len(m.searchMatches))
+	}
+	first, second := m.searchMatches[0], m.searchMatches[1]
+	want := []searchMatchRun{{line: 2, col: 12, width: 5}, {line: 3, col: 2, width: 3}}
+	if first.line != 2 || !slices.Equal(first.spans, want) {
+		t.Errorf("expected the first match to be highlighted over two lines, got %+v", first)
+	}
+	if second.sourceLine != 2 || !slices.Equal(second.spans, []searchMatchRun{{line: 3, col: 35, width: 9}}) {
+		t.Errorf("expected the second match on the rendered line it's on, got %+v", second)
+	}
+
+	// Rendered anew, at another width, the matches stay the same
+	m.searchIndex = 1
+	m.setContent("  # Intro\n\n  The quick brown fox jumps over the lazy dog, the\n  brown fox again.\n")
+	m.findSearchMatches()
+	if len(m.searchMatches) != 2 || m.searchIndex != 1 || m.searchMatches[1].line != 3 {
+		t.Errorf("expected the same matches after rendering anew, got %+v", m.searchMatches)
+	}
+}
+
+func TestParseCodeBlocks(t *testing.T) {
+	const md = "Text\n\n  ```yaml title\n\n  a: 1\n  ```\n\n```json\n{\"a\":\n```\n\n```go\nfunc main() {}\n"
+
+	blocks := parseCodeBlocks(md)
+	if len(blocks) != 3 {
+		t.Fatalf("expected 3 blocks, got %d", len(blocks))
+	}
+	if b := blocks[0]; b.lang != "yaml" || b.info != "yaml title" || !slices.Equal(b.code, []string{"", "a: 1"}) || b.first != "a: 1" || b.skipped != 1 || !b.closed {
+		t.Errorf("expected the indented yaml block, got %+v", b)
+	}
+	if b := blocks[2]; b.lang != "go" || b.closed || !slices.Equal(b.code, []string{"func main() {}", ""}) {
+		t.Errorf("expected the unclosed block to run to the end, got %+v", b)
+	}
+
+	configs := parseConfigBlocks(md)
+	if len(configs) != 2 || configs[0].err != nil || configs[1].lang != "json" || configs[1].err == nil {
+		t.Errorf("expected the valid yaml and invalid json blocks, got %+v", configs)
+	}
+
+	m := newPagerModel(&commonModel{})
+	m.currentDocument.Note = "main.go"
+	if m.copyCodeBlock(); m.statusMessage != "Only markdown files have code blocks to copy" {
+		t.Errorf("expected to be told code files have no code blocks, got %q", m.statusMessage)
+	}
+}
+
+func TestReloadStyle(t *testing.T) {
+	cfg := Config{GlamourStyle: "dark", LoadStyle: func() (string, error) { return "light", nil }}
+	m := newPagerModel(&commonModel{cfg: cfg})
+
+	cmd := m.reloadStyle()
+	if m.common.cfg.GlamourStyle != "dark" {
+		t.Fatal("expected the style to be loaded in the background")
+	}
+	if m, _ = m.update(cmd()); m.common.cfg.GlamourStyle != "light" || m.statusMessage != "Reloaded style: light" {
+		t.Errorf("expected the style to be reloaded, got %s and %q", m.common.cfg.GlamourStyle, m.statusMessage)
+	}
+
+	m.common.cfg.LoadStyle = func() (string, error) { return "", fmt.Errorf("no config") }
+	if m, _ = m.update(m.reloadStyle()()); m.common.cfg.GlamourStyle != "light" || m.statusMessage != "Unable to reload style: no config" {
+		t.Errorf("expected the style to be kept, got %s and %q", m.common.cfg.GlamourStyle, m.statusMessage)
+	}
+}
+
+func TestFileWatchReplaced(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doc.md")
+	if err := os.WriteFile(path, []byte("# One\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Skip("no fsnotify:", err)
+	}
+	t.Cleanup(func() { _ = watcher.Close() })
+	fanout := newWatchFanout(watcher)
+
+	// A watch replaced by another leaves the file's changes to it
+	old := newFileWatch(fanout, path)
+	oldDone := make(chan tea.Msg, 1)
+	go func() { oldDone <- old.wait(0) }()
+	w := newFileWatch(fanout, path)
+	if err := w.watch(path); err != nil {
+		t.Fatal(err)
+	}
+	old.stop()
+	if msg := <-oldDone; msg != nil {
+		t.Errorf("expected the replaced watch to stop, got %#v", msg)
+	}
+
+	if err := os.WriteFile(path, []byte("# Two\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan tea.Msg, 1)
+	go func() { done <- w.wait(0) }()
+	select {
+	case msg := <-done:
+		if _, ok := msg.(fileChangedMsg); !ok {
+			t.Errorf("expected the change to be reported, got %#v", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the change wasn't reported")
+	}
+	w.stop()
+}
+
+func TestJumpToTag(t *testing.T) {
+	const src = "package main\n\n// Tagless words\nfunc helper() {}\n\nfunc main() {\n\thelper()\n}\n"
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(path, []byte(src), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tags := "helper\tmain.go\t4;\"\tf\nmain\tmain.go\t/^func main() {$/;\"\tf\n"
+	if err := os.WriteFile(filepath.Join(dir, "tags"), []byte(tags), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	m := newPagerModel(&commonModel{})
+	m.setSize(80, 3)
+	m.currentDocument = markdown{localPath: path, Note: "main.go", Body: src}
+	m.renderedContent = src
+	m.setContent(src)
+
+	m.viewport.SetYOffset(2)
+	if m.jumpToTag(); m.statusMessage != "No tags found for the top line" || len(m.jumps) != 0 {
+		t.Errorf("expected no tags for the top line, got %q", m.statusMessage)
+	}
+
+	// The search match in view is a better guess than the top line
+	m.searchQuery = "elp"
+	m.findSearchMatches()
+	if m.jumpToTag(); len(m.jumps) != 1 || m.viewport.YOffset != 3 {
+		t.Errorf("expected to jump to helper, got offset %d", m.viewport.YOffset)
+	}
+
+	// Otherwise it's the first word on the top line with a tag
+	m.clearSearch()
+	m.viewport.SetYOffset(5)
+	if m.jumpToTag(); len(m.jumps) != 2 || m.viewport.YOffset != 5 {
+		t.Errorf("expected to jump to main, got offset %d", m.viewport.YOffset)
+	}
+	m.viewport.SetYOffset(0)
+	if m.jumpToTag(); len(m.jumps) != 3 || m.viewport.YOffset != 5 {
+		t.Errorf("expected to skip package and jump to main, got offset %d", m.viewport.YOffset)
+	}
+}
+
+func TestSlidesMatching(t *testing.T) {
+	m := newPagerModel(&commonModel{})
+	m.setSize(80, 10)
+	m.currentDocument.Note = "deck.md"
+	m.slideMode = true
+	m.slides = []string{
+		"# 1 One\n\nThe quick brown\nfox",
+		"# 2 Two\n\nNo foxes here",
+		"# 3 Three\n\nA brown   Fox",
+		"# 4 Four\n\nA **brown** fox",
+	}
+	m.searchQuery = "brown fox"
+
+	slides := m.slidesMatching()
+	if !slices.Equal(slides, []int{0, 2}) {
+		t.Errorf("expected the slides with the phrase, got %v", slides)
+	}
+	for _, i := range []int{0, 2, 3} {
+		m.currentSlide = i
+		m.setContent(m.slides[i])
+		m.findSearchMatches()
+		if found := len(m.searchMatches) > 0; found != slices.Contains(slides, i) {
+			t.Errorf("expected slide %d to be listed only if it has matches, got %d", i+1, len(m.searchMatches))
+		}
+	}
+}
+
+func TestRecordRecentDocument(t *testing.T) {
+	// Temporary files aren't recorded, so these are in the working directory
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	recordRecentDocument("one.md", 20)()
+	recordRecentDocument("two.md", 0)()
+	abs, _ := filepath.Abs("two.md")
+	if got := MostRecentDocument(); got != abs {
+		t.Errorf("expected the last document to be remembered with the list disabled, got %q", got)
+	}
+	if docs := loadRecentDocuments(); len(docs) != 1 || !strings.HasSuffix(docs[0], "one.md") {
+		t.Errorf("expected the list to be left alone, got %v", docs)
+	}
+}
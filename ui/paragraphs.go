@@ -0,0 +1,93 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Block markers in front of a paragraph's text, which don't appear as such
+// once rendered.
+var blockMarkerPattern = regexp.MustCompile(`^\s*(?:>\s*)*(?:#{1,6}\s+|[-*+]\s+|\d+[.)]\s+)?(?:\[[ xX]\]\s*)?`)
+
+// paragraph is a run of non-blank lines in a document.
+type paragraph struct {
+	text string // plain text of the paragraph's first line
+	line int    // 0-based line in the source document
+}
+
+// parseParagraphs returns the runs of non-blank lines in the given document.
+// Fenced code blocks count as a single paragraph, blank lines and all.
+func parseParagraphs(md string) []paragraph {
+	var (
+		paragraphs []paragraph
+		inFence    bool
+		inRun      bool
+	)
+
+	for i, line := range strings.Split(md, "\n") {
+		trimmed := strings.TrimSpace(line)
+		fence := strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")
+
+		switch {
+		case trimmed == "" && !inFence:
+			inRun = false
+		case !inRun:
+			inRun = true
+			paragraphs = append(paragraphs, paragraph{line: i})
+		}
+		if fence {
+			inFence = !inFence
+			continue
+		}
+
+		if trimmed == "" {
+			continue
+		}
+
+		// Fences aren't rendered, so go by the first line of code instead
+		if p := &paragraphs[len(paragraphs)-1]; p.text == "" {
+			text := blockMarkerPattern.ReplaceAllString(line, "")
+			text = inlineLinkPattern.ReplaceAllString(text, "$1")
+			p.text = strings.Join(strings.Fields(inlineMarkupReplacer.Replace(text)), " ")
+		}
+	}
+
+	return paragraphs
+}
+
+// nextParagraph scrolls to the next paragraph below the top of the viewport
+// or, going backward, the previous one above it.
+func (m *pagerModel) nextParagraph(backward bool) tea.Cmd {
+	paragraphs := parseParagraphs(m.currentMarkdown())
+	texts := make([]string, len(paragraphs))
+	for i, p := range paragraphs {
+		texts[i] = p.text
+	}
+	offsets := findTextLines(texts, m.renderedLines())
+
+	target := -1
+	for _, line := range offsets {
+		if line < 0 {
+			continue
+		}
+		if backward && line < m.viewport.YOffset {
+			target = line
+		}
+		if !backward && line > m.viewport.YOffset {
+			target = line
+			break
+		}
+	}
+	// Paragraphs below the last page can't be scrolled to the top
+	if target < 0 || !backward && m.viewport.AtBottom() {
+		message := "Last paragraph"
+		if backward {
+			message = "First paragraph"
+		}
+		return m.showStatusMessage(pagerStatusMessage{message: message})
+	}
+
+	return m.scrollTo(target)
+}
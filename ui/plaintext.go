@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/ansi"
+	"github.com/charmbracelet/glamour/styles"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/log"
+	xansi "github.com/charmbracelet/x/ansi"
+)
+
+// plainTextStyle returns the glamour style markdown is rendered as plain
+// text in: the ASCII style, without the markup it keeps, like # before
+// headings and * around emphasis, or the margins around the document.
+func plainTextStyle() ansi.StyleConfig {
+	var (
+		s        = styles.ASCIIStyleConfig
+		noMargin uint
+		quote    = "> "
+	)
+	s.Document.BlockPrefix, s.Document.BlockSuffix = "", ""
+	s.Document.Margin = &noMargin
+	s.CodeBlock.Margin = &noMargin
+	s.BlockQuote.IndentToken = &quote
+	for _, h := range []*ansi.StyleBlock{&s.H1, &s.H2, &s.H3, &s.H4, &s.H5, &s.H6} {
+		h.Prefix = ""
+	}
+	for _, p := range []*ansi.StylePrimitive{&s.Emph, &s.Strong, &s.Strikethrough, &s.Code.StylePrimitive} {
+		p.BlockPrefix, p.BlockSuffix = "", ""
+	}
+	return s
+}
+
+// plainText renders markdown as plain text, without markup or styling.
+func plainText(md string) (string, error) {
+	r, err := glamour.NewTermRenderer(glamour.WithStyles(plainTextStyle()), glamour.WithWordWrap(0))
+	if err != nil {
+		return "", fmt.Errorf("error creating glamour renderer: %w", err)
+	}
+	out, err := r.Render(md)
+	if err != nil {
+		return "", fmt.Errorf("error rendering markdown: %w", err)
+	}
+
+	lines := strings.Split(xansi.Strip(out), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n") + "\n", nil
+}
+
+// copyPlainText copies the document as plain text: rendered, but without
+// markup or styling. Code is copied as it is.
+func (m *pagerModel) copyPlainText() tea.Cmd {
+	text := m.currentDocument.Body
+	if utils.IsMarkdownFile(m.currentDocument.Note) {
+		var err error
+		if text, err = plainText(text); err != nil {
+			log.Error("unable to render plain text", "error", err)
+			return m.showStatusMessage(pagerStatusMessage{"Unable to copy as plain text: " + err.Error(), true})
+		}
+	}
+	return m.copyToClipboard(text, "Copied contents as plain text")
+}
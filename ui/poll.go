@@ -0,0 +1,89 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+)
+
+type (
+	// watchFailedMsg is sent when the file watcher can't watch the
+	// document, so that we can poll for changes instead.
+	watchFailedMsg struct{}
+
+	// filePolledMsg is sent when it's time to check the document for
+	// changes again.
+	filePolledMsg struct{ gen int }
+)
+
+// fileStamp identifies a version of a file, as far as polling can tell.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func statFile(path string) (fileStamp, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}, fmt.Errorf("unable to stat file: %w", err)
+	}
+	return fileStamp{info.ModTime(), info.Size()}, nil
+}
+
+// startPolling starts checking the document for changes every
+// Config.PollInterval, if enabled and not already polling.
+func (m *pagerModel) startPolling() tea.Cmd {
+	interval := m.common.cfg.PollInterval
+	if interval <= 0 || m.polling || m.currentDocument.localPath == "" {
+		return nil
+	}
+
+	stamp, err := statFile(m.currentDocument.localPath)
+	if err != nil {
+		log.Error("unable to poll file", "file", m.currentDocument.localPath, "error", err)
+		return nil
+	}
+	log.Info("polling file for changes", "file", m.currentDocument.localPath, "interval", interval)
+
+	m.polling = true
+	m.pollStamp = stamp
+	m.pollGen++
+	return m.pollFile()
+}
+
+// pollFile waits for the next check for changes.
+func (m pagerModel) pollFile() tea.Cmd {
+	gen := m.pollGen
+	return tea.Tick(m.common.cfg.PollInterval, func(time.Time) tea.Msg {
+		return filePolledMsg{gen}
+	})
+}
+
+// checkPolledFile reloads the document if its file changed since it was
+// last polled, and keeps polling either way.
+func (m *pagerModel) checkPolledFile(msg filePolledMsg) tea.Cmd {
+	if !m.polling || msg.gen != m.pollGen {
+		return nil // polling stopped, or restarted since
+	}
+
+	stamp, err := statFile(m.currentDocument.localPath)
+	if err != nil {
+		log.Debug("unable to poll file", "file", m.currentDocument.localPath, "error", err)
+		return m.pollFile()
+	}
+	if stamp == m.pollStamp {
+		return m.pollFile()
+	}
+
+	log.Debug("polled file changed", "file", m.currentDocument.localPath)
+	m.pollStamp = stamp
+	return tea.Batch(m.pollFile(), func() tea.Msg { return fileChangedMsg{} })
+}
+
+// stopPolling stops checking the document for changes.
+func (m *pagerModel) stopPolling() {
+	m.polling = false
+}
@@ -0,0 +1,127 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/charmbracelet/log"
+	gap "github.com/muesli/go-app-paths"
+)
+
+// positionsMu serializes updates to the reading positions file, which
+// several glow instances may share.
+var positionsMu sync.Mutex
+
+// positionsState is the contents of the reading positions file.
+type positionsState struct {
+	// Lines scrolled past, by absolute document path. Documents read from
+	// the top aren't kept.
+	Offsets map[string]int `json:"offsets"`
+}
+
+// positionsFilePath returns where reading positions are kept.
+func positionsFilePath() (string, error) {
+	dir, err := gap.NewScope(gap.User, "glow").CacheDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to get cache dir: %w", err)
+	}
+	return filepath.Join(dir, "positions.json"), nil
+}
+
+// loadPositions returns the saved reading positions. A missing or
+// unreadable file is as good as an empty one.
+func loadPositions() map[string]int {
+	path, err := positionsFilePath()
+	if err != nil {
+		log.Debug("no reading positions", "error", err)
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Debug("unable to read reading positions", "file", path, "error", err)
+		}
+		return nil
+	}
+	var state positionsState
+	if err := json.Unmarshal(data, &state); err != nil {
+		log.Warn("ignoring corrupt reading positions file", "file", path, "error", err)
+		return nil
+	}
+	return state.Offsets
+}
+
+// savePositions replaces the reading positions file.
+func savePositions(offsets map[string]int) error {
+	path, err := positionsFilePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:gosec
+		return fmt.Errorf("unable to create cache dir: %w", err)
+	}
+	data, err := json.MarshalIndent(positionsState{offsets}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode reading positions: %w", err)
+	}
+	if err := replaceFile(path, data); err != nil {
+		return fmt.Errorf("unable to save reading positions: %w", err)
+	}
+	return nil
+}
+
+// positionKey returns what the reading position of the current document is
+// kept under, or "" if it isn't kept. Only files are, and not slide decks,
+// which open on their first slide.
+func (m pagerModel) positionKey() string {
+	if !m.common.cfg.RememberPosition || m.currentDocument.localPath == "" || m.slideMode {
+		return ""
+	}
+	abs, err := filepath.Abs(m.currentDocument.localPath)
+	if err != nil {
+		return ""
+	}
+	return abs
+}
+
+// savePosition saves how far the current document has been scrolled, for
+// reading to pick up there when it's opened again.
+func (m pagerModel) savePosition() {
+	key := m.positionKey()
+	if key == "" || m.renderedContent == "" {
+		return
+	}
+
+	positionsMu.Lock()
+	defer positionsMu.Unlock()
+
+	offsets := loadPositions()
+	if offsets[key] == m.viewport.YOffset {
+		return
+	}
+	if offsets == nil {
+		offsets = map[string]int{}
+	}
+	if m.viewport.YOffset == 0 {
+		delete(offsets, key)
+	} else {
+		offsets[key] = m.viewport.YOffset
+	}
+	if err := savePositions(offsets); err != nil {
+		log.Error("unable to save reading position", "error", err)
+	}
+}
+
+// savedPosition returns the saved reading position of the current
+// document, if any.
+func (m pagerModel) savedPosition() (int, bool) {
+	key := m.positionKey()
+	if key == "" {
+		return 0, false
+	}
+	offset, ok := loadPositions()[key]
+	return offset, ok
+}
@@ -0,0 +1,336 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/log"
+)
+
+// prettifyChanges counts the changes made by prettifyMarkdown.
+type prettifyChanges struct {
+	trailingSpace int
+	headings      int
+	listMarkers   int
+	fences        int
+	blankLines    int
+}
+
+func (c prettifyChanges) total() int {
+	return c.trailingSpace + c.headings + c.listMarkers + c.fences + c.blankLines
+}
+
+// String describes the changes, like "2 headings, 1 fence".
+func (c prettifyChanges) String() string {
+	var parts []string
+	for _, p := range []struct {
+		n    int
+		what string
+	}{
+		{c.headings, "heading"},
+		{c.listMarkers, "list marker"},
+		{c.fences, "fence"},
+		{c.trailingSpace, "trailing space"},
+		{c.blankLines, "blank line"},
+	} {
+		switch {
+		case p.n == 1:
+			parts = append(parts, "1 "+p.what)
+		case p.n > 1:
+			parts = append(parts, fmt.Sprintf("%d %ss", p.n, p.what))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// prettifyMarkdown normalizes the formatting of the given markdown, without
+// changing what it renders to:
+//
+//   - trailing whitespace is trimmed, leaving two spaces for line breaks
+//   - ATX headings get a single space after their markers, no closing
+//     markers, and blank lines around them
+//   - lists that use * or + bullets throughout use - instead
+//   - ~~~ fences and fences longer than needed become ```
+//   - runs of blank lines are collapsed, and the document ends with a single
+//     newline
+//
+// Anything it can't be sure about, like indented headings that may belong
+// to a list item, is left alone. So is the contents of code blocks.
+func prettifyMarkdown(md string) (string, prettifyChanges) {
+	var (
+		c       prettifyChanges
+		lines   = strings.Split(md, "\n")
+		bullets = convertibleBullets(lines)
+		out     = make([]string, 0, len(lines))
+	)
+
+	// blankBefore adds a blank line before the next line, if there isn't
+	// one already
+	blankBefore := func() {
+		if len(out) > 0 && strings.TrimSpace(out[len(out)-1]) != "" &&
+			!strings.HasPrefix(strings.TrimSpace(out[len(out)-1]), "<") {
+			out = append(out, "")
+			c.headings++
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		// Code blocks are copied as they are, fences aside
+		if m := fenceOpenPattern.FindStringSubmatch(line); m != nil {
+			end := closingFence(lines, i, m[2])
+			if end < 0 {
+				out = append(out, lines[i:]...)
+				break
+			}
+			open, closing := line, lines[end]
+			if fence := normalizedFence(lines[i+1:end], m[2], m[3]); fence != m[2] {
+				open = m[1] + fence + m[3]
+				closing = m[1] + fence
+				c.fences++
+			}
+			out = append(out, open)
+			out = append(out, lines[i+1:end]...)
+			out = append(out, closing)
+			i = end
+			continue
+		}
+
+		// Trailing whitespace, keeping line breaks within paragraphs
+		trimmed := strings.TrimRight(line, " \t")
+		if trimmed != line {
+			next := i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != ""
+			if strings.HasSuffix(line, "  ") && strings.TrimSpace(line) != "" && next {
+				if line != trimmed+"  " {
+					c.trailingSpace++
+				}
+				line = trimmed + "  "
+			} else {
+				line = trimmed
+				c.trailingSpace++
+			}
+		}
+
+		// Runs of blank lines, unless they're part of an indented code
+		// block
+		if line == "" && len(out) > 0 && out[len(out)-1] == "" && !nextIsIndented(lines, i) {
+			c.blankLines++
+			continue
+		}
+
+		if m := atxHeadingPattern.FindStringSubmatch(line); m != nil && strings.HasPrefix(line, "#") {
+			heading := m[1]
+			if text := strings.TrimSpace(m[2]); text != "" {
+				heading += " " + text
+			}
+			if heading != line {
+				c.headings++
+			}
+			blankBefore()
+			out = append(out, heading)
+			if i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+				out = append(out, "")
+				c.headings++
+			}
+			continue
+		}
+
+		if bullets[i] {
+			idx := strings.IndexAny(line, "*+")
+			line = line[:idx] + "-" + line[idx+1:]
+			c.listMarkers++
+		}
+		out = append(out, line)
+	}
+
+	// A single newline at the end
+	for len(out) > 0 && out[len(out)-1] == "" {
+		out = out[:len(out)-1]
+		c.blankLines++
+	}
+	if c.blankLines > 0 && strings.HasSuffix(md, "\n") {
+		c.blankLines-- // the newline we add back
+	}
+
+	return strings.Join(out, "\n") + "\n", c
+}
+
+// closingFence returns the line of the fence closing the code block opened
+// at the given line, or -1 if it isn't closed.
+func closingFence(lines []string, open int, fence string) int {
+	for j := open + 1; j < len(lines); j++ {
+		trimmed := strings.TrimSpace(lines[j])
+		if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			return j
+		}
+	}
+	return -1
+}
+
+// normalizedFence returns the fence a code block with the given contents and
+// info string should use: ``` when that doesn't close it early, and the
+// given fence otherwise.
+func normalizedFence(code []string, fence, info string) string {
+	if strings.Contains(info, "`") {
+		return fence // backtick fences can't have backticks in their info
+	}
+	for _, line := range code {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			return fence
+		}
+	}
+	return "```"
+}
+
+// nextIsIndented returns whether the next non-blank line after the given
+// one is indented enough to be code.
+func nextIsIndented(lines []string, i int) bool {
+	for _, line := range lines[i+1:] {
+		if strings.TrimSpace(line) != "" {
+			return strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t")
+		}
+	}
+	return false
+}
+
+// convertibleBullets returns the lines of list items whose * or + bullet
+// can be changed to - without changing how lists are split up. That's the
+// case for lists using the same bullet throughout: in markdown, a change of
+// bullet starts a new list.
+func convertibleBullets(lines []string) map[int]bool {
+	type level struct {
+		bullets map[byte]bool
+		lines   []int
+		unsafe  bool
+	}
+	var (
+		convertible = make(map[int]bool)
+		levels      = make(map[int]*level) // by indentation, in the current list
+		inFence     bool
+	)
+
+	flush := func() {
+		for _, l := range levels {
+			if len(l.bullets) == 1 && !l.bullets['-'] && !l.unsafe {
+				for _, i := range l.lines {
+					convertible[i] = true
+				}
+			}
+		}
+		levels = make(map[int]*level)
+	}
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence || trimmed == "" {
+			continue
+		}
+
+		m := listItemPattern.FindStringSubmatch(line)
+		if m == nil || thematicBreakPattern.MatchString(line) {
+			// Lists end at the first unindented line that's not an item
+			if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+				flush()
+			}
+			continue
+		}
+		if m[1][0] >= '0' && m[1][0] <= '9' {
+			continue
+		}
+
+		indent := strings.Index(line, m[1])
+		l, ok := levels[indent]
+		if !ok {
+			l = &level{bullets: make(map[byte]bool)}
+			levels[indent] = l
+		}
+		l.bullets[m[1][0]] = true
+		l.lines = append(l.lines, i)
+
+		// An empty - item could be taken for a Setext heading underline
+		if strings.TrimSpace(line[indent+1:]) == "" {
+			l.unsafe = true
+		}
+	}
+	flush()
+
+	return convertible
+}
+
+// startPrettify works out what prettifying the document would change, and
+// asks for confirmation before saving it.
+func (m *pagerModel) startPrettify() tea.Cmd {
+	path := m.currentDocument.localPath
+	if path == "" || !utils.IsMarkdownFile(m.currentDocument.Note) {
+		return m.showStatusMessage(pagerStatusMessage{"Only markdown files can be prettified", true})
+	}
+	if utils.IsCompressed(path) {
+		return m.showStatusMessage(pagerStatusMessage{"Compressed documents can't be prettified", true})
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return m.showStatusMessage(pagerStatusMessage{"Can't prettify a read-only file", true})
+	}
+	_ = f.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return m.showStatusMessage(pagerStatusMessage{"Unable to read file: " + err.Error(), true})
+	}
+	body := utils.RemoveFrontmatter(data)
+	frontmatter := data[:len(data)-len(body)]
+
+	pretty, changes := prettifyMarkdown(string(body))
+	if changes.total() == 0 {
+		return m.showStatusMessage(pagerStatusMessage{message: "Already pretty"})
+	}
+
+	m.prettified = string(frontmatter) + pretty
+	m.prettifyChanges = changes
+	m.confirmingPrettify = true
+	return nil
+}
+
+// handlePrettifyInput saves the prettified document if the user confirms.
+func (m pagerModel) handlePrettifyInput(msg tea.KeyMsg) (pagerModel, tea.Cmd) {
+	m.confirmingPrettify = false
+	pretty := m.prettified
+	m.prettified = ""
+	if msg.String() != "y" {
+		return m, m.showStatusMessage(pagerStatusMessage{message: "Prettify cancelled"})
+	}
+
+	path := m.currentDocument.localPath
+	info, err := os.Stat(path)
+	if err == nil {
+		err = os.WriteFile(path, []byte(pretty), info.Mode().Perm())
+	}
+	if err != nil {
+		log.Error("unable to save prettified document", "file", path, "error", err)
+		return m, m.showStatusMessage(pagerStatusMessage{"Unable to save: " + err.Error(), true})
+	}
+
+	log.Info("prettified document", "file", path, "changes", m.prettifyChanges.String())
+	m.slides = nil
+	m.slideMode = false
+	m.currentSlide = 0
+	return m, tea.Batch(
+		loadLocalMarkdown(&m.currentDocument),
+		m.showStatusMessage(pagerStatusMessage{message: "Prettified: " + m.prettifyChanges.String()}),
+	)
+}
+
+// prettifyPromptView renders the prompt asking to confirm prettifying.
+func (m pagerModel) prettifyPromptView() string {
+	prompt := fmt.Sprintf(" Prettify and save? Changes %s. y/n ", m.prettifyChanges)
+	return statusBarMessageStyle(truncateWidth(prompt, m.width(), ellipsis) +
+		strings.Repeat(" ", max(0, m.width()-stringWidth(prompt))))
+}
@@ -0,0 +1,102 @@
+package ui
+
+import "testing"
+
+func TestPrettifyMarkdown(t *testing.T) {
+	tt := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "trailing whitespace",
+			in:   "Some text \t\nline break   \nend  \n",
+			want: "Some text\nline break  \nend\n",
+		},
+		{
+			name: "headings",
+			in:   "#   Title ##\nText\n## Next\n\n#hashtag\n",
+			want: "# Title\n\nText\n\n## Next\n\n#hashtag\n",
+		},
+		{
+			name: "indented headings",
+			in:   "- item\n  # heading\n",
+			want: "- item\n  # heading\n",
+		},
+		{
+			name: "list markers",
+			in:   "* one\n* two\n  + nested\n\n1. first\n",
+			want: "- one\n- two\n  - nested\n\n1. first\n",
+		},
+		{
+			name: "mixed list markers",
+			in:   "* one\n+ two\n\n- three\n\n* four\n",
+			want: "* one\n+ two\n\n- three\n\n* four\n",
+		},
+		{
+			name: "fences",
+			in:   "~~~go\nx  \n\n\n~~~\n\n````\n```\n````\n",
+			want: "```go\nx  \n\n\n```\n\n````\n```\n````\n",
+		},
+		{
+			name: "blank lines",
+			in:   "one\n\n\n\ntwo\n\n    code\n\n\n    more code\n\n\n",
+			want: "one\n\ntwo\n\n    code\n\n\n    more code\n",
+		},
+		{
+			name: "thematic breaks",
+			in:   "* * *\n\n*\n",
+			want: "* * *\n\n*\n",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, _ := prettifyMarkdown(tc.in)
+			if got != tc.want {
+				t.Errorf("unexpected result\nwant: %q\ngot:  %q", tc.want, got)
+			}
+			if again, changes := prettifyMarkdown(got); again != got || changes.total() != 0 {
+				t.Errorf("expected prettifying to be idempotent, got %q (%s)", again, changes)
+			}
+		})
+	}
+}
+
+func TestLabelCodeFences(t *testing.T) {
+	const script = "#!/usr/bin/env python3\nprint('hi')"
+
+	tests := []struct {
+		name string
+		md   string
+		want string
+	}{
+		{
+			name: "unlabeled",
+			md:   "```\n" + script + "\n```\n",
+			want: "```python\n" + script + "\n```\n",
+		},
+		{
+			name: "labeled",
+			md:   "```text\n" + script + "\n```\n",
+			want: "```text\n" + script + "\n```\n",
+		},
+		{
+			name: "closed by a longer fence only",
+			md:   "````\n" + script + "\n```\n````\n```\n" + script + "\n```\n",
+			want: "````python\n" + script + "\n```\n````\n```python\n" + script + "\n```\n",
+		},
+		{
+			name: "unclosed",
+			md:   "~~~\n" + script + "\n```\n",
+			want: "~~~python\n" + script + "\n```\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := labelCodeFences(tt.md); got != tt.want {
+				t.Errorf("expected\n%q\ngot\n%q", tt.want, got)
+			}
+		})
+	}
+}
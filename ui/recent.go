@@ -0,0 +1,246 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/x/ansi"
+	gap "github.com/muesli/go-app-paths"
+)
+
+// recentMu serializes updates to the recent documents file, as documents
+// are recorded in the background.
+var recentMu sync.Mutex
+
+// recentState is the contents of the recent documents file. The document
+// opened last is kept apart from the others, for --resume, since keeping
+// track of those can be turned off.
+type recentState struct {
+	Documents []string `json:"documents"` // most recent first
+	Last      string   `json:"last,omitempty"`
+}
+
+// recentFilePath returns where the recent documents are kept.
+func recentFilePath() (string, error) {
+	path, err := gap.NewScope(gap.User, "glow").DataPath("recent.json")
+	if err != nil {
+		return "", fmt.Errorf("unable to get data dir: %w", err)
+	}
+	return path, nil
+}
+
+// loadRecentState returns the contents of the recent documents file. A
+// missing or unreadable file is as good as an empty one.
+func loadRecentState() recentState {
+	path, err := recentFilePath()
+	if err != nil {
+		log.Debug("no recent documents", "error", err)
+		return recentState{}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Debug("unable to read recent documents", "file", path, "error", err)
+		}
+		return recentState{}
+	}
+	var state recentState
+	if err := json.Unmarshal(data, &state); err != nil {
+		log.Warn("ignoring corrupt recent documents file", "file", path, "error", err)
+		return recentState{}
+	}
+	return state
+}
+
+// loadRecentDocuments returns the paths of the documents opened most
+// recently, most recent first.
+func loadRecentDocuments() []string {
+	return loadRecentState().Documents
+}
+
+// MostRecentDocument returns the path of the document opened most recently,
+// or "" if none has been.
+func MostRecentDocument() string {
+	state := loadRecentState()
+	if state.Last == "" && len(state.Documents) > 0 {
+		return state.Documents[0]
+	}
+	return state.Last
+}
+
+// recordRecentDocument records the given document as the one opened last
+// and adds it to the top of the recent documents, which are capped at the
+// given number, or left alone if it's 0. Temporary files, which won't be
+// around for long, aren't recorded.
+func recordRecentDocument(doc string, limit int) tea.Cmd {
+	if doc == "" {
+		return nil
+	}
+	return func() tea.Msg {
+		abs, err := filepath.Abs(doc)
+		if err != nil || strings.HasPrefix(abs, filepath.Clean(os.TempDir())+string(os.PathSeparator)) {
+			return nil
+		}
+
+		recentMu.Lock()
+		defer recentMu.Unlock()
+
+		state := loadRecentState()
+		state.Last = abs
+		if limit > 0 {
+			docs := []string{abs}
+			for _, d := range state.Documents {
+				if d != abs && len(docs) < limit {
+					docs = append(docs, d)
+				}
+			}
+			state.Documents = docs
+		}
+		if err := saveRecentState(state); err != nil {
+			log.Error("unable to save recent documents", "error", err)
+		}
+		return nil
+	}
+}
+
+// saveRecentState replaces the recent documents file, without leaving it
+// half written should saving fail.
+func saveRecentState(state recentState) error {
+	path, err := recentFilePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:gosec
+		return fmt.Errorf("unable to create data dir: %w", err)
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode recent documents: %w", err)
+	}
+	if err := replaceFile(path, data); err != nil {
+		return fmt.Errorf("unable to save recent documents: %w", err)
+	}
+	return nil
+}
+
+// replaceFile replaces the contents of a file by way of a temporary file,
+// so that it's never left half written.
+func replaceFile(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary file: %w", err)
+	}
+	defer os.Remove(f.Name()) //nolint:errcheck
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("unable to write temporary file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("unable to write temporary file: %w", err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("unable to replace file: %w", err)
+	}
+	return nil
+}
+
+// openRecent opens the list of recent documents, leaving out the current
+// one and any that have since gone missing.
+func (m *pagerModel) openRecent() tea.Cmd {
+	m.recent = nil
+	for _, doc := range loadRecentDocuments() {
+		if sameFile(doc, m.currentDocument.localPath) {
+			continue
+		}
+		if _, err := os.Stat(doc); err == nil {
+			m.recent = append(m.recent, doc)
+		}
+	}
+	if len(m.recent) == 0 {
+		return m.showStatusMessage(pagerStatusMessage{"No recent documents", true})
+	}
+
+	m.showRecent = true
+	m.recentIndex = 0
+	if m.viewport.HighPerformanceRendering {
+		return tea.ClearScrollArea //nolint:staticcheck
+	}
+	return nil
+}
+
+// closeRecent closes the list of recent documents.
+func (m *pagerModel) closeRecent() tea.Cmd {
+	m.showRecent = false
+	m.recent = nil
+	if m.viewport.HighPerformanceRendering {
+		return viewport.Sync(m.viewport)
+	}
+	return nil
+}
+
+// handleRecentInput handles key presses while the list of recent documents
+// is open.
+func (m pagerModel) handleRecentInput(msg tea.KeyMsg) (pagerModel, tea.Cmd) {
+	switch msg.String() {
+	case keyEsc, "q", "O":
+		return m, m.closeRecent()
+
+	case "k", "up":
+		m.recentIndex = max(0, m.recentIndex-1)
+
+	case "j", "down":
+		m.recentIndex = min(len(m.recent)-1, m.recentIndex+1)
+
+	case "g", "home":
+		m.recentIndex = 0
+
+	case "G", "end":
+		m.recentIndex = len(m.recent) - 1
+
+	case keyEnter:
+		doc := m.recent[m.recentIndex]
+		cmd := m.closeRecent()
+		m.recordJump()
+		return m, tea.Batch(cmd, m.openFileAt(doc, 0))
+	}
+
+	return m, nil
+}
+
+// recentView renders the list of recent documents, scrolled to keep the
+// selected one in view.
+func (m pagerModel) recentView() string {
+	const chrome = 4 // border, title and the blank line below it
+
+	height := max(1, min(len(m.recent), m.viewport.Height-chrome))
+	width := max(1, m.viewport.Width-tocViewStyle.GetHorizontalFrameSize())
+	top := max(0, min(m.recentIndex-height/2, len(m.recent)-height))
+
+	home, _ := os.UserHomeDir()
+	cwd, _ := os.Getwd()
+	var b strings.Builder
+	b.WriteString(fuchsiaFg("Recent documents") + "\n")
+	for i, doc := range m.recent[top : top+height] {
+		entry := stripAbsolutePath(doc, cwd)
+		if home != "" && strings.HasPrefix(entry, home+string(os.PathSeparator)) {
+			entry = "~" + strings.TrimPrefix(entry, home)
+		}
+		// Keep the end of long paths, where the file name is
+		if w := stringWidth(entry); w > width {
+			entry = ansi.TruncateLeft(entry, w-width+1, "…")
+		}
+		if top+i == m.recentIndex {
+			entry = fuchsiaFg(entry)
+		}
+		b.WriteString("\n" + entry)
+	}
+
+	return tocViewStyle.Render(b.String())
+}
@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	listItemPattern       = regexp.MustCompile(`^\s*([-*+]|\d+[.)])(\s|$)`)
+	thematicBreakPattern  = regexp.MustCompile(`^ {0,3}(-\s*){3,}$|^ {0,3}(\*\s*){3,}$|^ {0,3}(_\s*){3,}$`)
+	blockStartPrefixes    = []string{">", "|", "<", "```", "~~~"}
+	hardLineBreakSuffixes = []string{"  ", "\\"}
+)
+
+// reflowHardWraps joins the lines of paragraphs that were hard-wrapped in
+// the source, so that they can be wrapped to the viewport width instead.
+//
+// Only plain paragraphs are reflowed. Code, lists, block quotes, tables and
+// HTML are left as they are, and so are intentional line breaks, marked by
+// two trailing spaces or a backslash.
+func reflowHardWraps(md string) string {
+	lines := strings.Split(md, "\n")
+	out := make([]string, 0, len(lines))
+
+	var (
+		inFence      bool
+		inParagraph  bool // whether the last line is part of a reflowable paragraph
+		inOtherBlock bool // whether we're in a block that's left alone
+	)
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			inParagraph = false
+			out = append(out, line)
+			continue
+		}
+		if inFence {
+			out = append(out, line)
+			continue
+		}
+
+		if trimmed == "" {
+			inParagraph, inOtherBlock = false, false
+			out = append(out, line)
+			continue
+		}
+
+		// Headings and breaks are single lines, after which a paragraph
+		// may follow right away
+		if isSingleLineBlock(line) {
+			inParagraph, inOtherBlock = false, false
+			out = append(out, line)
+			continue
+		}
+		if startsBlock(line) {
+			inParagraph, inOtherBlock = false, true
+			out = append(out, line)
+			continue
+		}
+
+		if inParagraph {
+			prev := out[len(out)-1]
+			if !hasHardLineBreak(prev) {
+				out[len(out)-1] = strings.TrimRight(prev, " \t") + " " + trimmed
+				continue
+			}
+			out = append(out, line)
+			continue
+		}
+
+		// A new paragraph can only start after a blank line, or we'd be
+		// joining lazy continuation lines of lists and quotes.
+		if !inOtherBlock && !strings.HasPrefix(line, "    ") && !strings.HasPrefix(line, "\t") {
+			inParagraph = true
+		}
+		out = append(out, line)
+	}
+
+	return strings.Join(out, "\n")
+}
+
+// isSingleLineBlock returns whether the line is a heading, a Setext heading
+// underline or a thematic break.
+func isSingleLineBlock(line string) bool {
+	return atxHeadingPattern.MatchString(line) ||
+		setextH1Pattern.MatchString(line) ||
+		setextH2Pattern.MatchString(line) ||
+		thematicBreakPattern.MatchString(line)
+}
+
+// startsBlock returns whether the line starts a block that spans multiple
+// lines and isn't a paragraph.
+func startsBlock(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	for _, prefix := range blockStartPrefixes {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	return listItemPattern.MatchString(line)
+}
+
+// hasHardLineBreak returns whether the line ends with an explicit line break.
+func hasHardLineBreak(line string) bool {
+	for _, suffix := range hardLineBreakSuffixes {
+		if strings.HasSuffix(line, suffix) {
+			return true
+		}
+	}
+	return false
+}
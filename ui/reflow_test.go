@@ -0,0 +1,55 @@
+package ui
+
+import "testing"
+
+func TestReflowHardWraps(t *testing.T) {
+	tt := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "paragraphs",
+			in:   "This paragraph was\nhard-wrapped at a\nnarrow width.\n\nAnd so was\nthis one.",
+			want: "This paragraph was hard-wrapped at a narrow width.\n\nAnd so was this one.",
+		},
+		{
+			name: "intentional line breaks",
+			in:   "Roses are red,  \nviolets are blue\\\nthis line breaks\nbut this doesn't.",
+			want: "Roses are red,  \nviolets are blue\\\nthis line breaks but this doesn't.",
+		},
+		{
+			name: "headings",
+			in:   "# Heading\nText right\nafter it.\n\nSetext\n------",
+			want: "# Heading\nText right after it.\n\nSetext\n------",
+		},
+		{
+			name: "lists",
+			in:   "- first item\n  continues here\n- second item\n\n1. numbered\n   item",
+			want: "- first item\n  continues here\n- second item\n\n1. numbered\n   item",
+		},
+		{
+			name: "code",
+			in:   "```go\nfunc main() {\n}\n```\n\n    indented\n    code",
+			want: "```go\nfunc main() {\n}\n```\n\n    indented\n    code",
+		},
+		{
+			name: "quotes and tables",
+			in:   "> quoted\n> lines\n\n| a | b |\n| - | - |\n| 1 | 2 |",
+			want: "> quoted\n> lines\n\n| a | b |\n| - | - |\n| 1 | 2 |",
+		},
+		{
+			name: "paragraph followed by a list",
+			in:   "Some text\nwrapped.\n- a list\n  item",
+			want: "Some text wrapped.\n- a list\n  item",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := reflowHardWraps(tc.in); got != tc.want {
+				t.Errorf("unexpected result\nwant: %q\ngot:  %q", tc.want, got)
+			}
+		})
+	}
+}
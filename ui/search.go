@@ -0,0 +1,420 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+var (
+	searchInputPromptStyle = lipgloss.NewStyle().
+				Foreground(yellowGreen)
+	searchInputCursorStyle = lipgloss.NewStyle().
+				Foreground(fuchsia)
+
+	searchMatchStyle = lipgloss.NewStyle().
+				Foreground(mintGreen).
+				Background(darkGreen)
+	currentSearchMatchStyle = lipgloss.NewStyle().
+				Foreground(cream).
+				Background(fuchsia)
+)
+
+// searchMatch is an occurrence of the search query in the document. It's
+// found in the source, so that it stays the same match however the document
+// is rendered, and located in rendered content anew with every render.
+type searchMatch struct {
+	source     int              // byte offset in the source
+	sourceLine int              // 0-based line of the source
+	line       int              // rendered line it starts on
+	spans      []searchMatchRun // where it's highlighted, if it could be found
+}
+
+// searchMatchRun is the part of a search match on one rendered line.
+type searchMatchRun struct {
+	line  int // rendered line
+	col   int // cell the match starts at
+	width int // in cells
+}
+
+func newSearchInput() textinput.Model {
+	si := textinput.New()
+	si.Prompt = "/"
+	si.PromptStyle = searchInputPromptStyle
+	si.Cursor.Style = searchInputCursorStyle
+	return si
+}
+
+// searchActive returns whether there's a search whose matches can be
+// navigated.
+func (m pagerModel) searchActive() bool {
+	return m.searchQuery != ""
+}
+
+// startSearch opens the search prompt. Backward searches look for matches
+// above the current position first.
+func (m *pagerModel) startSearch(backward bool) tea.Cmd {
+	m.searching = true
+	m.searchBackward = backward
+	m.searchInput.Prompt = "/"
+	if backward {
+		m.searchInput.Prompt = "?"
+	}
+	m.searchInput.Reset()
+
+	// Refining a search keeps where the first one started
+	if m.preSearch == nil {
+		m.preSearch = &jumpPosition{slide: m.currentSlide, offset: m.viewport.YOffset}
+	}
+	m.searchInput.Width = m.viewport.Width - len(m.searchInput.Prompt) - 1
+	return m.searchInput.Focus()
+}
+
+// clearSearch forgets the current search.
+func (m *pagerModel) clearSearch() {
+	m.searching = false
+	m.searchInput.Blur()
+	m.searchQuery = ""
+	m.searchMatches = nil
+	m.searchIndex = 0
+	m.slideSearch = false
+	m.preSearch = nil
+	m.setContent(m.renderedContent)
+}
+
+// returnFromSearch returns to where the search started, before going
+// through its matches.
+func (m *pagerModel) returnFromSearch() tea.Cmd {
+	if m.preSearch == nil {
+		return m.showStatusMessage(pagerStatusMessage{"No search to return from", true})
+	}
+	pos := *m.preSearch
+
+	status := m.showStatusMessage(pagerStatusMessage{message: "Returned to where the search started"})
+	if m.slideMode && pos.slide != m.currentSlide {
+		cmd := m.gotoSlide(pos.slide)
+		m.pendingYOffset = &pos.offset
+		return tea.Batch(cmd, status)
+	}
+	return tea.Batch(m.scrollTo(pos.offset), status)
+}
+
+// handleSearchInput handles key presses while the search prompt is open.
+func (m pagerModel) handleSearchInput(msg tea.KeyMsg) (pagerModel, tea.Cmd) {
+	switch msg.String() {
+	case keyEsc:
+		m.searching = false
+		m.searchInput.Blur()
+		m.preSearch = nil
+		return m, nil
+
+	case keyEnter:
+		m.searching = false
+		m.searchInput.Blur()
+		m.searchQuery = m.searchInput.Value()
+		if m.searchQuery == "" {
+			m.clearSearch()
+			return m, nil
+		}
+		m.findSearchMatches()
+		m.recordJump()
+		m.searchIndex = -1
+		// Decks are searched as a whole, slide by slide
+		m.slideSearch = m.slideMode
+		if m.slideSearch {
+			return m, m.nextSearchSlide(m.searchBackward, true)
+		}
+		return m, m.nextSearchMatch(m.searchBackward)
+	}
+
+	var cmd tea.Cmd
+	m.searchInput, cmd = m.searchInput.Update(msg)
+	return m, cmd
+}
+
+// searchPattern returns the pattern the search query is looked for with:
+// its words in order, ignoring case, separated by any whitespace, so that
+// it's found across wrapped lines.
+func searchPattern(query string) *regexp.Regexp {
+	words := strings.Fields(query)
+	for i, w := range words {
+		words[i] = regexp.QuoteMeta(w)
+	}
+	return regexp.MustCompile(`(?i)` + strings.Join(words, `\s+`))
+}
+
+// findSearchMatches finds the occurrences of the search query in the
+// source of the document, and where they are in rendered content, and
+// highlights them. Matches are located anew whenever the content is
+// rendered, so the current match is kept by its index.
+func (m *pagerModel) findSearchMatches() {
+	m.searchMatches = nil
+	if strings.TrimSpace(m.searchQuery) != "" {
+		md := m.currentMarkdown()
+		pattern := searchPattern(m.searchQuery)
+		for _, loc := range pattern.FindAllStringIndex(md, -1) {
+			m.searchMatches = append(m.searchMatches, searchMatch{
+				source:     loc[0],
+				sourceLine: strings.Count(md[:loc[0]], "\n"),
+			})
+		}
+		m.locateSearchMatches(md, pattern)
+	}
+
+	m.searchIndex = min(m.searchIndex, len(m.searchMatches)-1)
+	m.setContent(m.renderedContent)
+}
+
+// locateSearchMatches finds the search matches in rendered content, among
+// the lines rendered from the source line each starts on. Matches the
+// rendering leaves out, like those in link destinations, are scrolled to
+// without being highlighted.
+func (m *pagerModel) locateSearchMatches(md string, pattern *regexp.Regexp) {
+	rendered := m.renderedLines()
+	if len(rendered) == 0 {
+		return
+	}
+	sources := m.renderedLineMap(md, rendered)
+	gutter := 0
+	if m.showsLineNumbers() {
+		gutter = m.lineNumberWidth
+	}
+
+	for i := 0; i < len(m.searchMatches); {
+		// The matches starting on the same source line are found in order
+		// in the lines rendered from it and the lines after it they run on to
+		src := m.searchMatches[i].sourceLine
+		end := i
+		for end < len(m.searchMatches) && m.searchMatches[end].sourceLine == src {
+			end++
+		}
+		first := len(rendered) - 1
+		for r, s := range sources {
+			if s >= src {
+				first = r
+				break
+			}
+		}
+		last := first
+		for last+1 < len(rendered) && sources[last+1] <= src+1 {
+			last++
+		}
+
+		plain := make([]string, last-first+1)
+		for j := range plain {
+			plain[j] = ansi.Strip(rendered[first+j])
+		}
+		text := strings.Join(plain, "\n")
+		locs := pattern.FindAllStringIndex(text, end-i)
+
+		for k := i; k < end; k++ {
+			m.searchMatches[k].line = first
+			m.searchMatches[k].spans = nil
+			if k-i >= len(locs) {
+				continue
+			}
+			start, stop := locs[k-i][0], locs[k-i][1]
+			offset := 0
+			for j, l := range plain {
+				from, to := max(start, offset), min(stop, offset+len(l))
+				// Indentation and trailing space aren't part of a wrapped match
+				for from < to && l[from-offset] == ' ' {
+					from++
+				}
+				for from < to && l[to-offset-1] == ' ' {
+					to--
+				}
+				if from < to {
+					run := searchMatchRun{
+						line:  first + j,
+						col:   gutter + ansi.StringWidth(l[:from-offset]),
+						width: ansi.StringWidth(l[from-offset : to-offset]),
+					}
+					if len(m.searchMatches[k].spans) == 0 {
+						m.searchMatches[k].line = run.line
+					}
+					m.searchMatches[k].spans = append(m.searchMatches[k].spans, run)
+				}
+				offset += len(l) + 1
+			}
+		}
+		i = end
+	}
+}
+
+// highlightSearchMatches returns the rendered content with search matches
+// highlighted, the current one standing out.
+func (m pagerModel) highlightSearchMatches() string {
+	if len(m.searchMatches) == 0 {
+		return m.renderedContent
+	}
+
+	lines := strings.Split(m.renderedContent, "\n")
+	// Right to left, so that highlighting a match doesn't move the next
+	for i := len(m.searchMatches) - 1; i >= 0; i-- {
+		style := searchMatchStyle
+		if i == m.searchIndex {
+			style = currentSearchMatchStyle
+		}
+		spans := m.searchMatches[i].spans
+		for j := len(spans) - 1; j >= 0; j-- {
+			run := spans[j]
+			if run.line >= len(lines) {
+				continue
+			}
+			line := lines[run.line]
+			lines[run.line] = ansi.Truncate(line, run.col, "") +
+				style.Render(ansi.Strip(ansi.Cut(line, run.col, run.col+run.width))) +
+				ansi.TruncateLeft(line, run.col+run.width, "")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// nextSearchMatch scrolls to the next match in the given direction, relative
+// to the current match or, if there is none, to the top of the viewport.
+// Like in vim, searching wraps around the ends of the document.
+func (m *pagerModel) nextSearchMatch(backward bool) tea.Cmd {
+	if m.slideSearch && m.slideMode {
+		return m.nextSearchSlide(backward, false)
+	}
+	if len(m.searchMatches) == 0 {
+		return m.showStatusMessage(pagerStatusMessage{
+			message: "Pattern not found: " + m.searchQuery,
+			isError: true,
+		})
+	}
+
+	// Move on from the current match or, for a new search, start from the
+	// top of the viewport, which is itself a candidate.
+	var wrapped bool
+	switch n := len(m.searchMatches); {
+	case m.searchIndex >= 0 && m.searchIndex < n && backward:
+		wrapped = m.searchIndex == 0
+		m.searchIndex = (m.searchIndex - 1 + n) % n
+	case m.searchIndex >= 0 && m.searchIndex < n:
+		wrapped = m.searchIndex == n-1
+		m.searchIndex = (m.searchIndex + 1) % n
+	case backward:
+		m.searchIndex, wrapped = n-1, true
+		for i := n - 1; i >= 0; i-- {
+			if m.searchMatches[i].line <= m.viewport.YOffset {
+				m.searchIndex, wrapped = i, false
+				break
+			}
+		}
+	default:
+		m.searchIndex, wrapped = 0, true
+		for i, match := range m.searchMatches {
+			if match.line >= m.viewport.YOffset {
+				m.searchIndex, wrapped = i, false
+				break
+			}
+		}
+	}
+
+	m.setContent(m.renderedContent)
+	m.viewport.SetYOffset(m.searchMatches[m.searchIndex].line)
+
+	var cmds []tea.Cmd
+	if m.viewport.HighPerformanceRendering {
+		cmds = append(cmds, viewport.Sync(m.viewport))
+	}
+	if wrapped {
+		msg := "Search hit BOTTOM, continuing at TOP"
+		if backward {
+			msg = "Search hit TOP, continuing at BOTTOM"
+		}
+		cmds = append(cmds, m.showStatusMessage(pagerStatusMessage{message: msg}))
+	}
+	return tea.Batch(cmds...)
+}
+
+// slidesMatching returns the slides with the search query on them, matched
+// the way findSearchMatches matches it on the current slide.
+func (m pagerModel) slidesMatching() []int {
+	var (
+		slides  []int
+		pattern = searchPattern(m.searchQuery)
+	)
+	for i, slide := range m.slides {
+		if pattern.MatchString(slide) {
+			slides = append(slides, i)
+		}
+	}
+	return slides
+}
+
+// nextSearchSlide goes to the next slide with the search query on it or,
+// backward, the previous one, wrapping around the ends of the deck. A new
+// search can find it on the current slide.
+func (m *pagerModel) nextSearchSlide(backward, includeCurrent bool) tea.Cmd {
+	slides := m.slidesMatching()
+	if len(slides) == 0 {
+		return m.showStatusMessage(pagerStatusMessage{
+			message: "Pattern not found in slides: " + m.searchQuery,
+			isError: true,
+		})
+	}
+
+	target, wrapped := slides[0], true
+	if backward {
+		target = slides[len(slides)-1]
+		for i := len(slides) - 1; i >= 0; i-- {
+			if slides[i] < m.currentSlide || (includeCurrent && slides[i] == m.currentSlide) {
+				target, wrapped = slides[i], false
+				break
+			}
+		}
+	} else {
+		for _, i := range slides {
+			if i > m.currentSlide || (includeCurrent && i == m.currentSlide) {
+				target, wrapped = i, false
+				break
+			}
+		}
+	}
+
+	var msg string
+	switch {
+	case includeCurrent:
+		numbers := make([]string, len(slides))
+		for i, n := range slides {
+			numbers[i] = strconv.Itoa(n + 1)
+		}
+		msg = fmt.Sprintf("%q found in slide %s", m.searchQuery, strings.Join(numbers, ", "))
+		if len(slides) > 1 {
+			msg = fmt.Sprintf("%q found in slides %s", m.searchQuery, strings.Join(numbers, ", "))
+		}
+	case wrapped && backward:
+		msg = "Search hit FIRST slide, continuing at LAST"
+	case wrapped:
+		msg = "Search hit LAST slide, continuing at FIRST"
+	}
+
+	var cmds []tea.Cmd
+	if target == m.currentSlide {
+		// Already rendered, with the matches highlighted
+		if len(m.searchMatches) > 0 && m.searchMatches[0].line >= m.viewport.YOffset+m.viewport.Height {
+			cmds = append(cmds, m.scrollTo(m.searchMatches[0].line))
+		}
+	} else {
+		cmds = append(cmds, m.gotoSlide(target))
+	}
+	if msg != "" {
+		cmds = append(cmds, m.showStatusMessage(pagerStatusMessage{message: msg}))
+	}
+	return tea.Batch(cmds...)
+}
+
+// searchInputView renders the search prompt in place of the status bar.
+func (m pagerModel) searchInputView(b *strings.Builder) {
+	fmt.Fprint(b, m.searchInput.View())
+}
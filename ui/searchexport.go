@@ -0,0 +1,182 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/log"
+)
+
+// Shortest text a source line is found by in rendered output.
+const minSourceText = 3
+
+// sourceText returns the beginning of the text of a markdown line, as it
+// would show in rendered output, to find the line by. Markup that would
+// throw that off, like links and table pipes, cuts it short. It returns ""
+// for lines too short to be told apart.
+func sourceText(line string) string {
+	text := strings.TrimLeft(blockMarkerPattern.ReplaceAllString(line, ""), "| ")
+	if i := strings.IndexAny(text, "|[]()<>"); i >= 0 {
+		text = text[:i]
+	}
+	text = strings.TrimSpace(inlineMarkupReplacer.Replace(text))
+	if len(text) < minSourceText {
+		return ""
+	}
+	return text
+}
+
+// sourceLineMap maps each rendered line to the 0-based line of the given
+// markdown it was rendered from. Source lines are found by their text, so
+// lines rendered from markup alone, like a table's borders, are taken to
+// belong to the source line above.
+func sourceLineMap(md string, rendered []string) []int {
+	lines := strings.Split(md, "\n")
+	texts := make([]string, len(lines))
+	for i := 0; i < len(lines); i++ {
+		if m := fenceOpenPattern.FindStringSubmatch(lines[i]); m != nil {
+			end := closingFence(lines, i, m[2])
+			if end < 0 {
+				end = len(lines)
+			}
+			for j := i + 1; j < end; j++ {
+				if t := strings.TrimSpace(lines[j]); len(t) >= minSourceText {
+					texts[j] = t
+				}
+			}
+			i = end
+			continue
+		}
+		texts[i] = sourceText(lines[i])
+	}
+
+	return fillLineMap(findTextLines(texts, rendered), len(rendered))
+}
+
+// renderedLineMap maps each rendered line of the document to the 0-based
+// line of the given source it was rendered from.
+func (m pagerModel) renderedLineMap(md string, rendered []string) []int {
+	if utils.IsMarkdownFile(m.currentDocument.Note) {
+		return sourceLineMap(md, rendered)
+	}
+	return codeLineMap(md, rendered)
+}
+
+// fillLineMap maps each of n rendered lines to the source line it belongs
+// to, given where source lines were found, or -1 where they weren't. Lines
+// belong to the last source line found at or above them.
+func fillLineMap(found []int, n int) []int {
+	sources := make([]int, n)
+	src := 0
+	for r := range sources {
+		for next := src + 1; next < len(found); next++ {
+			if found[next] >= 0 {
+				if found[next] <= r {
+					src = next
+				}
+				break
+			}
+		}
+		sources[r] = src
+	}
+	return sources
+}
+
+// searchReport formats the search matches like grep -n -C would: the
+// source lines with matches, numbered, with the given number of lines of
+// context around them. It returns the report and the number of lines with
+// matches.
+func (m pagerModel) searchReport(context int) (string, int) {
+	md := m.currentMarkdown()
+	source := strings.Split(md, "\n")
+
+	// Source lines with matches, in order
+	var matched []int
+	for _, match := range m.searchMatches {
+		if line := match.sourceLine; len(matched) == 0 || matched[len(matched)-1] < line {
+			matched = append(matched, line)
+		}
+	}
+
+	prefix := filepath.Base(m.currentDocument.Note)
+	if m.currentDocument.Note == "" {
+		prefix = ""
+	}
+	offset := m.currentDocument.lineOffset + 1
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %d matches for %q", len(m.searchMatches), m.searchQuery)
+	if prefix != "" {
+		fmt.Fprintf(&b, " in %s", prefix)
+	}
+	if m.slideMode {
+		fmt.Fprintf(&b, ", slide %d", m.currentSlide+1)
+	}
+	b.WriteString("\n")
+
+	isMatch := make(map[int]bool, len(matched))
+	for _, line := range matched {
+		isMatch[line] = true
+	}
+	last := -1 // last line written
+	for _, line := range matched {
+		from := max(0, line-context, last+1)
+		to := min(len(source)-1, line+context)
+		if from > to {
+			continue
+		}
+		if last >= 0 && from > last+1 {
+			b.WriteString("--\n")
+		}
+		for i := from; i <= to; i++ {
+			sep := "-"
+			if isMatch[i] {
+				sep = ":"
+			}
+			if prefix != "" {
+				b.WriteString(prefix + sep)
+			}
+			fmt.Fprintf(&b, "%d%s%s\n", i+offset, sep, source[i])
+		}
+		last = to
+	}
+	return b.String(), len(matched)
+}
+
+// exportSearch exports the matches of the current search as a grep-like
+// report, to SearchExportPath or, if it isn't set, the clipboard.
+func (m *pagerModel) exportSearch() tea.Cmd {
+	if !m.searchActive() {
+		return m.showStatusMessage(pagerStatusMessage{"Nothing to export, search first", true})
+	}
+	if len(m.searchMatches) == 0 {
+		return m.showStatusMessage(pagerStatusMessage{"No matches to export", true})
+	}
+
+	report, lines := m.searchReport(max(0, m.common.cfg.SearchExportContext))
+	summary := fmt.Sprintf("%d matches on %d lines", len(m.searchMatches), lines)
+	switch {
+	case len(m.searchMatches) == 1:
+		summary = "1 match"
+	case lines == 1:
+		summary = fmt.Sprintf("%d matches on 1 line", len(m.searchMatches))
+	}
+
+	path := utils.ExpandPath(m.common.cfg.SearchExportPath)
+	if path == "" {
+		return m.copyToClipboard(report, "Copied "+summary)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(m.common.cwd, path)
+	}
+	if err := os.WriteFile(path, []byte(report), 0o644); err != nil { //nolint:gosec
+		log.Error("unable to export search", "file", path, "error", err)
+		return m.showStatusMessage(pagerStatusMessage{"Unable to export: " + err.Error(), true})
+	}
+	log.Info("exported search", "query", m.searchQuery, "file", path, "matches", len(m.searchMatches))
+	return m.showStatusMessage(pagerStatusMessage{message: fmt.Sprintf("Exported %s to %s", summary, path)})
+}
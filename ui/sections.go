@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/dustin/go-humanize"
+)
+
+// sectionSource returns the source of the section starting at the i-th of
+// the given headings: the heading itself and everything up to the next
+// heading of the same or a higher level, or the end of the document.
+func sectionSource(md string, headings []heading, i int) string {
+	lines := strings.Split(md, "\n")
+	end := len(lines)
+	for _, h := range headings[i+1:] {
+		if h.level <= headings[i].level {
+			end = h.line
+			break
+		}
+	}
+	section := strings.Join(lines[headings[i].line:end], "\n")
+	return strings.TrimRight(section, " \t\r\n") + "\n"
+}
+
+// copySection copies the section being read, which is the one whose heading
+// is the last at or above the top of the viewport.
+func (m *pagerModel) copySection() tea.Cmd {
+	if !utils.IsMarkdownFile(m.currentDocument.Note) {
+		return nil
+	}
+
+	md := m.currentMarkdown()
+	headings := parseHeadings(md)
+	lines := findHeadingLines(headings, m.renderedLines())
+
+	current := -1
+	for i, line := range lines {
+		if line < 0 {
+			continue
+		}
+		if line > m.viewport.YOffset {
+			break
+		}
+		current = i
+	}
+	if current < 0 {
+		return m.showStatusMessage(pagerStatusMessage{"No section heading above", true})
+	}
+
+	section := sectionSource(md, headings, current)
+	message := fmt.Sprintf("Copied “%s” (%s)", headings[current].plainText(), humanize.Bytes(uint64(len(section))))
+	return m.copyToClipboard(section, message)
+}
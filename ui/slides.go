@@ -0,0 +1,394 @@
+package ui
+
+import (
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/x/ansi"
+	"go.yaml.in/yaml/v3"
+)
+
+// Slide alignments, the default being left-aligned.
+const (
+	slideAlignLeft   = "left"
+	slideAlignCenter = "center"
+)
+
+// Slide separators: headings starting with a number, any H1 heading or
+// horizontal rules.
+const (
+	slideSeparatorNumberedH1 = "numbered-h1"
+	slideSeparatorH1         = "h1"
+	slideSeparatorHR         = "hr"
+)
+
+// Default Config.SlideHeaderPattern, matching headings starting with a
+// digit.
+const defaultSlideHeaderPattern = `^[0-9]`
+
+// slideMetaPattern matches slide metadata comments, like
+// <!-- slide: align=center -->.
+var slideMetaPattern = regexp.MustCompile(`^\s*<!--\s*slide:(.*?)-->\s*$`)
+
+// slideMeta holds the settings of a single slide.
+type slideMeta struct {
+	align string // overrides Config.SlideAlign if set
+}
+
+// extractSlideMetas removes metadata comments from the given slides,
+// returning the slides without them along with each slide's settings.
+// Comments apply to the slide whose heading they directly follow or, since
+// slides run until the next heading, directly precede.
+func extractSlideMetas(slides []string) ([]string, []slideMeta) {
+	metas := make([]slideMeta, len(slides))
+	for i, slide := range slides {
+		lines := strings.Split(slide, "\n")
+
+		// Comments after the heading
+		j := 1
+		for ; j < len(lines); j++ {
+			if m := slideMetaPattern.FindStringSubmatch(lines[j]); m != nil {
+				metas[i].parse(m[1])
+				lines[j] = ""
+			} else if strings.TrimSpace(lines[j]) != "" {
+				break
+			}
+		}
+
+		// Comments before the next slide's heading
+		for k := len(lines) - 1; k >= j && i+1 < len(slides); k-- {
+			if m := slideMetaPattern.FindStringSubmatch(lines[k]); m != nil {
+				metas[i+1].parse(m[1])
+				lines[k] = ""
+			} else if strings.TrimSpace(lines[k]) != "" {
+				break
+			}
+		}
+
+		slides[i] = strings.Join(lines, "\n")
+	}
+	return slides, metas
+}
+
+// compileSlideHeaderPattern compiles the pattern the text of numbered-h1
+// slide headings has to match. An empty or invalid pattern falls back to the
+// default.
+func compileSlideHeaderPattern(pattern string) *regexp.Regexp {
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err == nil {
+			return re
+		}
+		log.Error("invalid slide header pattern, using the default", "pattern", pattern, "error", err)
+	}
+	return regexp.MustCompile(defaultSlideHeaderPattern)
+}
+
+// splitSlides splits a document into slides at the given kind of separator.
+// Numbered H1 headings are those whose text matches the given pattern.
+// Anything before the first heading separating slides is left out. It
+// returns nil for documents without separators.
+func splitSlides(md, separator string, numbered *regexp.Regexp) []string {
+	lines := strings.Split(md, "\n")
+	if separator == slideSeparatorHR {
+		return splitSlidesAtRules(lines)
+	}
+
+	// Slides start at H1 headers, in ATX or Setext style
+	var starts []int
+	for _, h := range parseHeadings(md) {
+		text := strings.TrimSpace(h.text)
+		if h.level != 1 {
+			continue
+		}
+		if separator == slideSeparatorH1 || numbered.MatchString(text) {
+			starts = append(starts, h.line)
+		}
+	}
+
+	var slides []string
+	for i, start := range starts {
+		end := len(lines)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		slides = append(slides, strings.Join(lines[start:end], "\n"))
+	}
+	return slides
+}
+
+// splitSlidesAtRules splits a document into slides at lines that are just
+// ---, outside of code blocks. Front matter, which is delimited by the same,
+// is left out, as are slides with nothing on them.
+func splitSlidesAtRules(lines []string) []string {
+	var (
+		slides []string
+		from   = frontmatterLines(lines)
+		split  bool
+	)
+	add := func(slide []string) {
+		if text := strings.Trim(strings.Join(slide, "\n"), "\n"); strings.TrimSpace(text) != "" {
+			slides = append(slides, text)
+		}
+	}
+	for i := from; i < len(lines); i++ {
+		if m := fenceOpenPattern.FindStringSubmatch(lines[i]); m != nil {
+			if end := closingFence(lines, i, m[2]); end >= 0 {
+				i = end
+			}
+			continue
+		}
+		if strings.TrimSpace(lines[i]) == "---" {
+			add(lines[from:i])
+			from, split = i+1, true
+		}
+	}
+	if !split {
+		return nil
+	}
+	add(lines[from:])
+	return slides
+}
+
+// frontmatterLines returns the number of lines taken up by the YAML front
+// matter at the top of the given document, 0 if it doesn't start with any.
+// Only a --- block holding a YAML mapping counts, so that a deck starting
+// with a rule isn't mistaken for one.
+func frontmatterLines(lines []string) int {
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return 0
+	}
+	for i := 1; i < len(lines); i++ {
+		if l := strings.TrimSpace(lines[i]); l != "---" && l != "..." {
+			continue
+		}
+		var fields map[string]any
+		if err := yaml.Unmarshal([]byte(strings.Join(lines[1:i], "\n")), &fields); err != nil || len(fields) == 0 {
+			return 0
+		}
+		return i + 1
+	}
+	return 0
+}
+
+// parse reads settings like "align=center" into the slide's metadata,
+// ignoring anything it doesn't know.
+func (s *slideMeta) parse(settings string) {
+	for _, field := range strings.Fields(settings) {
+		key, value, _ := strings.Cut(field, "=")
+		value = strings.Trim(value, `"'`)
+		switch {
+		case key == "align" && (value == slideAlignLeft || value == slideAlignCenter):
+			s.align = value
+		default:
+			log.Debug("ignoring unknown slide setting", "setting", field)
+		}
+	}
+}
+
+// slideAlign returns the alignment of the current slide.
+func (m pagerModel) slideAlign() string {
+	if m.currentSlide < len(m.slideMetas) && m.slideMetas[m.currentSlide].align != "" {
+		return m.slideMetas[m.currentSlide].align
+	}
+	return m.common.cfg.SlideAlign
+}
+
+// centerSlide pads rendered slide lines so that the slide's content is
+// centered as a block within the given width. Code blocks are kept
+// left-aligned, as centering them would make them harder to read.
+func centerSlide(md string, lines []string, width int) []string {
+	code := codeBlockLines(md, lines)
+
+	var blockWidth int
+	for i, l := range lines {
+		if !code[i] {
+			blockWidth = max(blockWidth, ansi.StringWidth(trimRightANSI(l)))
+		}
+	}
+	pad := (width - blockWidth) / 2
+	if pad <= 0 {
+		return lines
+	}
+
+	for i, l := range lines {
+		if code[i] || strings.TrimSpace(ansi.Strip(l)) == "" {
+			continue
+		}
+		lines[i] = strings.Repeat(" ", pad) + trimRightANSI(l)
+	}
+	return lines
+}
+
+// codeBlockLines returns which of the rendered lines belong to fenced code
+// blocks in the given markdown.
+func codeBlockLines(md string, lines []string) map[int]bool {
+	var (
+		blocks  [][]string
+		inFence bool
+	)
+	for _, line := range strings.Split(md, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			if !inFence {
+				blocks = append(blocks, nil)
+			}
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			blocks[len(blocks)-1] = append(blocks[len(blocks)-1], line)
+		}
+	}
+
+	// Locate each block by its first non-blank line; code isn't wrapped, so
+	// the rest of the block follows line by line
+	code := make(map[int]bool)
+	firsts := make([]string, len(blocks))
+	skipped := make([]int, len(blocks))
+	for i, block := range blocks {
+		for _, l := range block {
+			if strings.TrimSpace(l) != "" {
+				firsts[i] = strings.TrimSpace(l)
+				break
+			}
+			skipped[i]++
+		}
+	}
+	for i, start := range findTextLines(firsts, lines) {
+		if start < 0 {
+			continue
+		}
+		for j := start - skipped[i]; j < start-skipped[i]+len(blocks[i]) && j < len(lines); j++ {
+			code[max(0, j)] = true
+		}
+	}
+	return code
+}
+
+// Hint shown in fullscreen slides, in place of the status bar.
+const fullscreenHint = "F or esc to exit fullscreen"
+
+// toggleFullscreen gives slides the whole terminal, hiding the status bar
+// and help, or brings them back.
+func (m *pagerModel) toggleFullscreen() tea.Cmd {
+	if !m.fullscreen && !m.slideMode {
+		m.parseSlides()
+	}
+	if !m.slideMode {
+		return m.showStatusMessage(pagerStatusMessage{"Not a slide deck", true})
+	}
+
+	m.fullscreen = !m.fullscreen
+	m.setSize(m.common.width, m.common.height)
+	if m.viewport.PastBottom() {
+		m.viewport.GotoBottom()
+	}
+
+	var cmds []tea.Cmd
+	if m.viewport.HighPerformanceRendering {
+		cmds = append(cmds, tea.ClearScrollArea, viewport.Sync(m.viewport)) //nolint:staticcheck
+	}
+	if m.slideAlign() == slideAlignCenter {
+		// Re-center for the new size, staying where we are
+		offset := m.viewport.YOffset
+		m.pendingYOffset = &offset
+		cmds = append(cmds, renderWithGlamour(*m, m.currentMarkdown()))
+	}
+	return tea.Batch(cmds...)
+}
+
+// fullscreenView renders a fullscreen slide, with a faint hint on how to
+// leave in the bottom right corner when there's room for it.
+func (m pagerModel) fullscreenView() string {
+	lines := strings.Split(m.viewport.View(), "\n")
+	last := len(lines) - 1
+	hintWidth := ansi.StringWidth(fullscreenHint)
+	content := trimRightANSI(lines[last])
+	if w := stringWidth(content); w+hintWidth+1 <= m.viewport.Width {
+		lines[last] = content + strings.Repeat(" ", m.viewport.Width-w-hintWidth) + grayFg(fullscreenHint)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderedListItemPattern matches the start of a list item in rendered
+// output.
+var renderedListItemPattern = regexp.MustCompile(`^(?:•|\d+\.)\s`)
+
+// paginatesSlides returns whether slides too tall for the viewport are split
+// into pages.
+func (m pagerModel) paginatesSlides() bool {
+	return m.slideMode && m.common.cfg.PaginateSlides
+}
+
+// paginateSlide splits a rendered slide taller than the given height into
+// pages, breaking between paragraphs and list items where it can. Pages are
+// padded with blank lines to the full height, so that the n-th page starts n
+// heights down.
+func paginateSlide(lines []string, height int) []string {
+	if height <= 0 || len(lines) <= height {
+		return lines
+	}
+
+	isBreak := func(l string) bool {
+		plain := strings.TrimSpace(ansi.Strip(l))
+		return plain == "" || renderedListItemPattern.MatchString(plain)
+	}
+
+	var pages []string
+	for start := 0; start < len(lines); {
+		// Blank lines glamour ends slides with don't make a page
+		rest := strings.Join(lines[start:], "")
+		if start > 0 && strings.TrimSpace(ansi.Strip(rest)) == "" {
+			break
+		}
+
+		end := min(start+height, len(lines))
+		if end < len(lines) {
+			for i := end; i > start+1; i-- {
+				if isBreak(lines[i]) {
+					end = i
+					break
+				}
+			}
+		}
+		pages = append(pages, lines[start:end]...)
+		for range height - (end - start) {
+			pages = append(pages, "")
+		}
+		start = end
+	}
+	return pages
+}
+
+// slidePage returns the page of the current slide that's shown and the
+// number of pages it has, both counting from 1.
+func (m pagerModel) slidePage() (page, pages int) {
+	height := m.viewport.Height
+	if !m.paginatesSlides() || height <= 0 {
+		return 1, 1
+	}
+	pages = max(1, (m.viewport.TotalLineCount()+height-1)/height)
+	return min(pages, m.viewport.YOffset/height+1), pages
+}
+
+// showsProgressBar returns whether the slide progress bar is shown above the
+// status bar.
+func (m pagerModel) showsProgressBar() bool {
+	return m.common.cfg.SlideProgressBar && m.slideMode && len(m.slides) > 0 && !m.fullscreen
+}
+
+// progressBarView renders how far into the deck the current slide is as a
+// bar across the width of the pager, full on the last slide.
+func (m pagerModel) progressBarView() string {
+	width := m.width()
+	filled := width
+	if len(m.slides) > 1 {
+		filled = int(math.Round(float64(width*m.currentSlide) / float64(len(m.slides)-1)))
+	}
+	return greenFg(strings.Repeat("█", filled)) + darkGrayFg.Render(strings.Repeat("░", width-filled))
+}
@@ -0,0 +1,364 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	goimage "image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glamour/styles"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/x/ansi"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/gomonobold"
+	"golang.org/x/image/font/gofont/gomonobolditalic"
+	"golang.org/x/image/font/gofont/gomonoitalic"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// Size of the font slide images are drawn with, in pixels.
+	snapshotFontSize = 16
+
+	// Placeholder for the slide number in SlideImagePath.
+	slideNumberPlaceholder = "{n}"
+)
+
+// slideExportedMsg reports a slide saved by exportSlide.
+type slideExportedMsg struct {
+	slide  int
+	path   string
+	asText bool // saved as text, as the image couldn't be drawn
+	err    error
+}
+
+// cellStyle is the style of a terminal cell, as set by SGR sequences. Nil
+// colors are the defaults.
+type cellStyle struct {
+	fg, bg                           color.Color
+	bold, italic, underline, reverse bool
+}
+
+// apply updates the style with the parameters of an SGR sequence.
+func (s *cellStyle) apply(params ansi.Params) {
+	if len(params) == 0 {
+		*s = cellStyle{}
+		return
+	}
+	for i := 0; i < len(params); i++ {
+		switch p := params[i].Param(0); {
+		case p == 0:
+			*s = cellStyle{}
+		case p == 1:
+			s.bold = true
+		case p == 3:
+			s.italic = true
+		case p == 4:
+			s.underline = true
+		case p == 7:
+			s.reverse = true
+		case p == 22:
+			s.bold = false
+		case p == 23:
+			s.italic = false
+		case p == 24:
+			s.underline = false
+		case p == 27:
+			s.reverse = false
+		case p >= 30 && p <= 37:
+			s.fg = ansi.BasicColor(p - 30) //nolint:gosec
+		case p >= 90 && p <= 97:
+			s.fg = ansi.BasicColor(p - 90 + 8) //nolint:gosec
+		case p == 39:
+			s.fg = nil
+		case p >= 40 && p <= 47:
+			s.bg = ansi.BasicColor(p - 40) //nolint:gosec
+		case p >= 100 && p <= 107:
+			s.bg = ansi.BasicColor(p - 100 + 8) //nolint:gosec
+		case p == 49:
+			s.bg = nil
+		case p == 38, p == 48:
+			var c color.Color
+			n := ansi.ReadStyleColor(params[i:], &c)
+			if n == 0 {
+				return // can't tell where the color ends
+			}
+			if p == 38 {
+				s.fg = c
+			} else {
+				s.bg = c
+			}
+			i += n - 1
+		}
+	}
+}
+
+// styledCell is a grapheme printed in a terminal, taking up one or more
+// cells.
+type styledCell struct {
+	text  string
+	width int
+	style cellStyle
+}
+
+// parseStyledLines splits ANSI styled text into lines of styled cells.
+// Sequences other than SGR are dropped.
+func parseStyledLines(s string) [][]styledCell {
+	var (
+		lines [][]styledCell
+		line  []styledCell
+		style cellStyle
+		state byte
+		p     = ansi.NewParser()
+	)
+	for len(s) > 0 {
+		seq, width, n, newState := ansi.DecodeSequence(s, state, p)
+		state = newState
+		s = s[n:]
+
+		switch {
+		case seq == "\n":
+			lines = append(lines, line)
+			line = nil
+		case width > 0:
+			line = append(line, styledCell{seq, width, style})
+		case ansi.HasCsiPrefix(seq) && ansi.Cmd(p.Command()).Final() == 'm':
+			style.apply(p.Params())
+		}
+	}
+	return append(lines, line)
+}
+
+// snapshotTheme holds the default colors and fonts of a snapshot.
+type snapshotTheme struct {
+	fg, bg color.Color
+	faces  [4]font.Face // regular, bold, italic and bold italic
+}
+
+// newSnapshotTheme returns the theme to draw snapshots of content rendered
+// with the given glamour style in.
+func newSnapshotTheme(style string) (snapshotTheme, error) {
+	var t snapshotTheme
+	t.fg, t.bg = snapshotColors(style)
+
+	for i, ttf := range [][]byte{gomono.TTF, gomonobold.TTF, gomonoitalic.TTF, gomonobolditalic.TTF} {
+		f, err := opentype.Parse(ttf)
+		if err != nil {
+			return t, fmt.Errorf("unable to parse font: %w", err)
+		}
+		t.faces[i], err = opentype.NewFace(f, &opentype.FaceOptions{Size: snapshotFontSize, DPI: 72, Hinting: font.HintingFull})
+		if err != nil {
+			return t, fmt.Errorf("unable to load font: %w", err)
+		}
+	}
+	return t, nil
+}
+
+// snapshotColors returns the default foreground and background colors of
+// content rendered with the given glamour style.
+func snapshotColors(style string) (fg, bg color.Color) {
+	fg, bg = ansi.IndexedColor(252), ansi.IndexedColor(234)
+	if style == styles.LightStyle {
+		fg, bg = ansi.IndexedColor(234), ansi.IndexedColor(255)
+	}
+	if styleConfig, err := utils.StyleConfig(style); err == nil {
+		if c := parseStyleColor(styleConfig.Document.Color); c != nil {
+			fg = c
+		}
+		if c := parseStyleColor(styleConfig.Document.BackgroundColor); c != nil {
+			bg = c
+		}
+	}
+	return fg, bg
+}
+
+// parseStyleColor parses a color of a glamour style, either an ANSI 256
+// color number or a hex color. It returns nil for anything else.
+func parseStyleColor(s *string) color.Color {
+	if s == nil {
+		return nil
+	}
+	if n, err := strconv.ParseUint(*s, 10, 8); err == nil {
+		return ansi.IndexedColor(n)
+	}
+	hex := strings.TrimPrefix(*s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if n, err := strconv.ParseUint(hex, 16, 32); err == nil && len(hex) == 6 && strings.HasPrefix(*s, "#") {
+		return ansi.TrueColor(n)
+	}
+	return nil
+}
+
+// drawSnapshot draws ANSI styled text as it would show in a terminal at
+// least width cells wide, with a margin around it.
+func drawSnapshot(s string, width int, theme snapshotTheme) goimage.Image {
+	lines := parseStyledLines(strings.TrimRight(s, "\n"))
+	for _, line := range lines {
+		var w int
+		for _, c := range line {
+			w += c.width
+		}
+		width = max(width, w)
+	}
+
+	metrics := theme.faces[0].Metrics()
+	advance, _ := theme.faces[0].GlyphAdvance('M')
+	cellWidth, cellHeight := advance.Ceil(), metrics.Height.Ceil()
+	marginX, marginY := 2*cellWidth, cellHeight
+
+	img := goimage.NewRGBA(goimage.Rect(0, 0, width*cellWidth+2*marginX, len(lines)*cellHeight+2*marginY))
+	draw.Draw(img, img.Bounds(), goimage.NewUniform(theme.bg), goimage.Point{}, draw.Src)
+
+	for row, line := range lines {
+		col := 0
+		top := marginY + row*cellHeight
+		for _, c := range line {
+			fg, bg := theme.fg, theme.bg
+			if c.style.fg != nil {
+				fg = c.style.fg
+			}
+			if c.style.bg != nil {
+				bg = c.style.bg
+			}
+			if c.style.reverse {
+				fg, bg = bg, fg
+			}
+
+			x := marginX + col*cellWidth
+			cell := goimage.Rect(x, top, x+c.width*cellWidth, top+cellHeight)
+			if bg != theme.bg {
+				draw.Draw(img, cell, goimage.NewUniform(bg), goimage.Point{}, draw.Src)
+			}
+
+			face := 0
+			if c.style.bold {
+				face++
+			}
+			if c.style.italic {
+				face += 2
+			}
+			d := font.Drawer{
+				Dst:  img,
+				Src:  goimage.NewUniform(fg),
+				Face: theme.faces[face],
+				Dot:  fixed.P(x, top+metrics.Ascent.Ceil()),
+			}
+			d.DrawString(c.text)
+
+			if c.style.underline {
+				y := top + metrics.Ascent.Ceil() + 2
+				draw.Draw(img, goimage.Rect(cell.Min.X, y, cell.Max.X, y+1), goimage.NewUniform(fg), goimage.Point{}, draw.Src)
+			}
+			col += c.width
+		}
+	}
+
+	return img
+}
+
+// slideImagePath returns where to save the given slide, numbered from 1. By
+// default, that's next to the document.
+func (m pagerModel) slideImagePath(n int) string {
+	note := utils.UncompressedName(m.currentDocument.Note)
+	name := strings.TrimSuffix(filepath.Base(note), filepath.Ext(note))
+	if name == "" || name == "." {
+		name = "slide"
+	}
+	file := fmt.Sprintf("%s-slide-%d.png", name, n)
+
+	path := utils.ExpandPath(m.common.cfg.SlideImagePath)
+	switch {
+	case path == "" && m.currentDocument.localPath != "":
+		return filepath.Join(m.localDir(), file)
+	case path == "":
+		return filepath.Join(m.common.cwd, file)
+	case strings.Contains(path, slideNumberPlaceholder):
+		return strings.ReplaceAll(path, slideNumberPlaceholder, strconv.Itoa(n))
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return filepath.Join(path, file)
+	}
+	return path
+}
+
+// exportSlide saves the current slide as a PNG image, rendered at
+// SlideImageWidth with the current style. When the image can't be drawn,
+// the slide is saved as text instead.
+func (m pagerModel) exportSlide() tea.Cmd {
+	if !m.slideMode || len(m.slides) == 0 {
+		return m.showStatusMessage(pagerStatusMessage{"Not a slide deck", true})
+	}
+
+	slide := m.currentSlide
+	md := m.slides[slide]
+	path := m.slideImagePath(slide + 1)
+
+	// Render the slide alone, at the width of the image and without the
+	// line number gutter
+	width := max(1, m.common.cfg.SlideImageWidth)
+	common := *m.common
+	common.cfg.ShowLineNumbers = false
+	common.cfg.ShowLineNumbersProse = false
+	common.cfg.OverflowWidth = false
+	common.cfg.InlineImages = false
+	common.cfg.GlamourMaxWidth = uint(width) //nolint:gosec
+	r := m
+	r.common = &common
+	r.lineNumbers = nil
+	r.noWrap = false
+	r.viewport.Width = width
+
+	return func() tea.Msg {
+		rendered, err := glamourRender(r, md)
+		if err != nil {
+			return slideExportedMsg{slide: slide + 1, err: err}
+		}
+
+		var buf bytes.Buffer
+		theme, err := newSnapshotTheme(common.cfg.GlamourStyle)
+		if err == nil {
+			err = png.Encode(&buf, drawSnapshot(rendered, width, theme))
+		}
+		asText := err != nil
+		if asText {
+			log.Warn("unable to draw slide image, saving it as text", "error", err)
+			path = strings.TrimSuffix(path, filepath.Ext(path)) + ".txt"
+			buf.Reset()
+			for _, line := range strings.Split(ansi.Strip(rendered), "\n") {
+				buf.WriteString(strings.TrimRight(line, " ") + "\n")
+			}
+		}
+
+		if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil { //nolint:gosec
+			return slideExportedMsg{slide: slide + 1, path: path, err: err}
+		}
+		return slideExportedMsg{slide: slide + 1, path: path, asText: asText}
+	}
+}
+
+// handleSlideExported reports where a slide was saved.
+func (m *pagerModel) handleSlideExported(msg slideExportedMsg) tea.Cmd {
+	if msg.err != nil {
+		log.Error("unable to export slide", "slide", msg.slide, "error", msg.err)
+		return m.showStatusMessage(pagerStatusMessage{"Unable to save slide: " + msg.err.Error(), true})
+	}
+	log.Info("exported slide", "slide", msg.slide, "file", msg.path)
+	if msg.asText {
+		return m.showStatusMessage(pagerStatusMessage{
+			message: fmt.Sprintf("Images unavailable, saved slide %d as text to %s", msg.slide, msg.path),
+		})
+	}
+	return m.showStatusMessage(pagerStatusMessage{message: fmt.Sprintf("Saved slide %d to %s", msg.slide, msg.path)})
+}
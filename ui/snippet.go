@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+// snippet formats the given 1-based lines of a file, and the context lines
+// around them, as a fenced code block headed by a permalink-style reference
+// to them, like notes.md#L10-L16, ready to paste into a comment. It returns
+// the snippet and the range of lines it holds.
+func snippet(name, source string, line, context int) (s string, from, to int) {
+	lines := strings.Split(strings.TrimSuffix(source, "\n"), "\n")
+	from = max(1, line-context)
+	to = min(len(lines), line+context)
+	code := strings.Join(lines[from-1:to], "\n")
+
+	// The fence has to be longer than any in the code
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	name = utils.UncompressedName(name)
+	lang := strings.TrimPrefix(filepath.Ext(name), ".")
+	if utils.IsMarkdownFile(name) {
+		lang = "markdown"
+	}
+
+	var b strings.Builder
+	if name != "" {
+		fmt.Fprintf(&b, "%s#L%d-L%d\n\n", filepath.Base(name), from, to)
+	}
+	fmt.Fprintf(&b, "%s%s\n%s\n%s\n", fence, lang, code, fence)
+	return b.String(), from, to
+}
+
+// copySnippet copies the line being read, with SnippetContext lines of
+// context around it, as a fenced code block.
+func (m *pagerModel) copySnippet() tea.Cmd {
+	if m.currentDocument.source == "" {
+		return m.showStatusMessage(pagerStatusMessage{"Nothing to copy", true})
+	}
+
+	line, _ := m.sourcePosition()
+	s, from, to := snippet(m.currentDocument.Note, m.currentDocument.source, line, max(0, m.common.cfg.SnippetContext))
+	return m.copyToClipboard(s, fmt.Sprintf("Copied lines %d–%d", from, to))
+}
@@ -0,0 +1,104 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Style of the lines around the paragraph in the spotlight.
+var spotlightDimFg = lipgloss.NewStyle().Foreground(midGray).Render
+
+// toggleSpotlight turns the spotlight reading mode on or off. While it's on,
+// everything but the paragraph in the middle of the viewport is dimmed.
+func (m *pagerModel) toggleSpotlight() tea.Cmd {
+	if m.slideMode || !utils.IsMarkdownFile(m.currentDocument.Note) {
+		return nil
+	}
+	m.spotlight = !m.spotlight
+	m.spotlightStarts = nil
+	cmd := m.followSpotlight()
+
+	message := "Spotlight: off"
+	if m.spotlight {
+		message = "Spotlight: on"
+	}
+	return tea.Batch(cmd, m.showStatusMessage(pagerStatusMessage{message: message}))
+}
+
+// followSpotlight moves the spotlight to the paragraph in the middle of the
+// viewport, redrawing the content if it's moved. It's called after every
+// update, since there are many ways to scroll.
+func (m *pagerModel) followSpotlight() tea.Cmd {
+	spotlit := m.spotlightRange()
+	if spotlit == m.spotlit {
+		return nil
+	}
+	m.spotlit = spotlit
+	m.viewport.SetContent(m.displayedContent())
+	m.setXOffset(m.xOffset)
+	if m.viewport.HighPerformanceRendering {
+		return viewport.Sync(m.viewport)
+	}
+	return nil
+}
+
+// spotlightRange returns the range of rendered lines of the paragraph in the
+// middle of the viewport, or an empty range when there's no spotlight.
+func (m *pagerModel) spotlightRange() [2]int {
+	if !m.spotlight || m.renderedContent == "" {
+		return [2]int{}
+	}
+
+	lines := m.renderedLines()
+	if m.spotlightStarts == nil {
+		paragraphs := parseParagraphs(m.currentMarkdown())
+		texts := make([]string, len(paragraphs))
+		for i, p := range paragraphs {
+			texts[i] = p.text
+		}
+		m.spotlightStarts = []int{}
+		for _, line := range findTextLines(texts, lines) {
+			if line >= 0 {
+				m.spotlightStarts = append(m.spotlightStarts, line)
+			}
+		}
+	}
+
+	center := min(m.viewport.YOffset+m.viewport.Height/2, len(lines)-1)
+	start, end := -1, len(lines)
+	for _, line := range m.spotlightStarts {
+		if line > center {
+			end = line
+			break
+		}
+		start = line
+	}
+	if start < 0 {
+		return [2]int{}
+	}
+	// Leave out the blank lines up to the next paragraph
+	for end > start+1 && strings.TrimSpace(ansi.Strip(lines[end-1])) == "" {
+		end--
+	}
+	return [2]int{start, end}
+}
+
+// dimOutsideSpotlight dims the lines of rendered content around the
+// paragraph in the spotlight.
+func (m pagerModel) dimOutsideSpotlight(content string) string {
+	if m.spotlit == [2]int{} {
+		return content
+	}
+	lines := strings.Split(content, "\n")
+	for i, l := range lines {
+		if i < m.spotlit[0] || i >= m.spotlit[1] {
+			lines[i] = spotlightDimFg(ansi.Strip(l))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
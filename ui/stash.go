@@ -3,7 +3,6 @@ package ui
 import (
 	"errors"
 	"fmt"
-	"os"
 	"sort"
 	"strings"
 	"time"
@@ -12,6 +11,7 @@ import (
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/log"
 	"github.com/muesli/reflow/ansi"
@@ -519,12 +519,12 @@ func (m *stashModel) handleDocumentBrowsing(msg tea.Msg) tea.Cmd {
 		case "e":
 			md := m.selectedMarkdown()
 
-			// In case no file is available
-			if md == nil {
+			// In case no file is available, or it can't be written back
+			if md == nil || utils.IsCompressed(md.localPath) {
 				return nil
 			}
 
-			return openEditor(md.localPath, 0)
+			return openEditor(md.localPath, 1, 1)
 
 		// Open document
 		case keyEnter:
@@ -855,12 +855,13 @@ func loadLocalMarkdown(md *markdown) tea.Cmd {
 			return errMsg{errors.New("could not load file: missing path")}
 		}
 
-		data, err := os.ReadFile(md.localPath)
+		data, err := utils.ReadFile(md.localPath)
 		if err != nil {
 			log.Debug("error reading local file", "error", err)
 			return errMsg{err}
 		}
-		md.Body = string(data)
+		md.source = string(data)
+		md.Body = md.source
 		return fetchedMarkdownMsg(md)
 	}
 }
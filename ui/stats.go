@@ -0,0 +1,132 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Average adult silent reading speed, in words per minute, unless
+// Config.ReadingWPM says otherwise.
+const defaultReadingWPM = 200
+
+var (
+	statsImagePattern = regexp.MustCompile(`!\[[^\]]*\]\([^)]*\)`)
+	statsLinkPattern  = regexp.MustCompile(`\[[^\]]*\]\([^)]*\)`)
+	statsTaskPattern  = regexp.MustCompile(`^\s*[-*+]\s+\[([ xX])\]`)
+
+	statsViewStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(fuchsia).
+			Padding(1, 3)
+)
+
+// documentStats holds some figures about a markdown document, as shown in
+// the stats overlay.
+type documentStats struct {
+	headings   int
+	words      int
+	chars      int
+	codeBlocks int
+	links      int
+	images     int
+	tasks      int
+	tasksDone  int
+}
+
+// newDocumentStats computes statistics for the given markdown source. Fenced
+// code blocks are counted, but their contents don't contribute to any of the
+// other figures.
+func newDocumentStats(body string) documentStats {
+	var (
+		s       documentStats
+		inFence bool
+	)
+
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			if !inFence {
+				s.codeBlocks++
+			}
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		if task := statsTaskPattern.FindStringSubmatch(line); task != nil {
+			s.tasks++
+			if task[1] != " " {
+				s.tasksDone++
+			}
+		}
+
+		images := len(statsImagePattern.FindAllString(line, -1))
+		s.images += images
+		s.links += len(statsLinkPattern.FindAllString(line, -1)) - images
+		s.words += len(strings.Fields(line))
+		s.chars += utf8.RuneCountInString(line)
+	}
+
+	s.headings = len(parseHeadings(body))
+
+	return s
+}
+
+// readingTime returns the estimated time to read the document at the given
+// speed, in words per minute, in minutes.
+func (s documentStats) readingTime(wpm int) int {
+	if wpm <= 0 {
+		wpm = defaultReadingWPM
+	}
+	return int(math.Ceil(float64(s.words) / float64(wpm)))
+}
+
+// note returns the word and character counts and reading time, for the
+// status bar.
+func (s documentStats) note(wpm int) string {
+	return fmt.Sprintf("%s words · %s chars · ~%d min read",
+		formatCount(s.words), formatCount(s.chars), s.readingTime(wpm))
+}
+
+// formatCount formats a number with thousands separators, like 12,345.
+func formatCount(n int) string {
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+func (s documentStats) view(wpm int) string {
+	tasks := "none"
+	if s.tasks > 0 {
+		tasks = fmt.Sprintf("%d/%d done", s.tasksDone, s.tasks)
+	}
+
+	rows := [][2]string{
+		{"Headings", fmt.Sprint(s.headings)},
+		{"Words", fmt.Sprint(s.words)},
+		{"Code blocks", fmt.Sprint(s.codeBlocks)},
+		{"Links", fmt.Sprint(s.links)},
+		{"Images", fmt.Sprint(s.images)},
+		{"Tasks", tasks},
+		{"Reading time", fmt.Sprintf("~%d min", s.readingTime(wpm))},
+	}
+
+	var b strings.Builder
+	b.WriteString(fuchsiaFg("Document Stats") + "\n")
+	for _, r := range rows {
+		fmt.Fprintf(&b, "\n%s %s", grayFg(fmt.Sprintf("%-14s", r[0])), r[1])
+	}
+
+	return statsViewStyle.Render(b.String())
+}
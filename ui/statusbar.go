@@ -0,0 +1,203 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+)
+
+// Values of Config.StatusBarNote, the default being to show the note on the
+// left.
+const (
+	statusBarNoteCenter     = "center"
+	statusBarNoteBreadcrumb = "breadcrumb"
+)
+
+// Separator between the headings of a breadcrumb.
+const breadcrumbSeparator = " › "
+
+// Name of the status bar segment holding the note, which takes up the room
+// the other segments leave.
+const statusBarNoteSegment = "note"
+
+// Segments of the status bar unless configured otherwise.
+var defaultStatusBarSegments = []string{"logo", statusBarNoteSegment, "percent", "help"}
+
+// statusBarSegment renders a segment of the status bar, styled for the
+// status message if one's showing, along with its printable width.
+type statusBarSegment func(m pagerModel, showStatusMessage bool) (view string, width int)
+
+// statusBarSegments are the segments of the status bar, but for the note,
+// by name.
+var statusBarSegments = map[string]statusBarSegment{
+	"logo": func(pagerModel, bool) (string, int) {
+		logo := glowLogoView()
+		return logo, stringWidth(logo)
+	},
+	"percent": func(m pagerModel, showStatusMessage bool) (string, int) {
+		percent := fmt.Sprintf(" %3d%% ", m.scrollPercent())
+		if showStatusMessage {
+			return statusBarMessageScrollPosStyle(percent), stringWidth(percent)
+		}
+		return statusBarScrollPosStyle(percent), stringWidth(percent)
+	},
+	"help": func(_ pagerModel, showStatusMessage bool) (string, int) {
+		const help = " ? Help "
+		if showStatusMessage {
+			return statusBarMessageHelpStyle(help), stringWidth(help)
+		}
+		return statusBarHelpStyle(help), stringWidth(help)
+	},
+	"clock": func(_ pagerModel, showStatusMessage bool) (string, int) {
+		clock := " " + time.Now().Format("15:04") + " "
+		return styleStatusBar(showStatusMessage, clock), stringWidth(clock)
+	},
+	"filename": func(m pagerModel, showStatusMessage bool) (string, int) {
+		if m.currentDocument.localPath == "" {
+			return "", 0
+		}
+		name := truncateWidth(" "+filepath.Base(m.currentDocument.localPath)+" ", m.width()/3, ellipsis)
+		return styleStatusBar(showStatusMessage, name), stringWidth(name)
+	},
+}
+
+// parseStatusBarSegments returns the status bar segments with the given
+// names, leaving out, with a warning, those there are none by. No names
+// give the default segments.
+func parseStatusBarSegments(names []string) []string {
+	if len(names) == 0 {
+		return defaultStatusBarSegments
+	}
+	segments := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := statusBarSegments[name]; !ok && name != statusBarNoteSegment {
+			log.Warn("unknown status bar segment, leaving it out", "segment", name)
+			continue
+		}
+		segments = append(segments, name)
+	}
+	return segments
+}
+
+// styleStatusBar styles text of the status bar like the note, or like the
+// status message if one's showing.
+func styleStatusBar(showStatusMessage bool, s string) string {
+	if showStatusMessage {
+		return statusBarMessageStyle(s)
+	}
+	return statusBarNoteStyle(s)
+}
+
+// statusBarFill returns empty space of the status bar of the given width.
+func statusBarFill(showStatusMessage bool, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	return styleStatusBar(showStatusMessage, strings.Repeat(" ", width))
+}
+
+// clockTickMsg updates the clock in the status bar, on the minute.
+type clockTickMsg struct{}
+
+// showsClock returns whether the status bar has a clock to keep updated.
+func (m pagerModel) showsClock() bool {
+	return slices.Contains(m.statusBarSegments, "clock")
+}
+
+// tickClock waits for the clock to turn to the next minute.
+func tickClock() tea.Cmd {
+	next := time.Now().Truncate(time.Minute).Add(time.Minute)
+	return tea.Tick(time.Until(next), func(time.Time) tea.Msg {
+		return clockTickMsg{}
+	})
+}
+
+// scrollPercent returns how far the viewport is scrolled, from 0 to 100. It's
+// 0% and 100% at the very top and bottom, regardless of rounding.
+func (m pagerModel) scrollPercent() int {
+	switch {
+	case m.viewport.AtBottom():
+		return 100
+	case m.viewport.AtTop():
+		return 0
+	}
+	return int(math.RoundToEven(m.viewport.ScrollPercent() * 100))
+}
+
+// flashScrollPosition briefly shows which way and how far the viewport
+// jumped from the given offset, so that it's easier to keep track of where
+// a page jump landed. A status message already showing is left alone,
+// unless it's an earlier flash.
+func (m *pagerModel) flashScrollPosition(from int) tea.Cmd {
+	if m.viewport.YOffset == from || (m.state == pagerStateStatusMessage && !m.scrollFlash) {
+		return nil
+	}
+	arrow := "↓"
+	if m.viewport.YOffset < from {
+		arrow = "↑"
+	}
+	cmd := m.showStatusMessage(pagerStatusMessage{message: fmt.Sprintf("%s %d%%", arrow, m.scrollPercent())})
+	m.scrollFlash = true
+	return cmd
+}
+
+// usesNoteLayout returns whether the status bar note is laid out as per
+// Config.StatusBarNote, which is only the case while the note is all there
+// is to show.
+func (m pagerModel) usesNoteLayout() bool {
+	return m.state != pagerStateStatusMessage && !m.showsSlideIndicator()
+}
+
+// showsSlideIndicator returns whether the status bar shows the position in
+// the slide deck.
+func (m pagerModel) showsSlideIndicator() bool {
+	if len(m.slides) == 1 && m.common.cfg.SingleSlide == singleSlideHide {
+		return false
+	}
+	return m.slideMode && len(m.slides) > 0
+}
+
+// indexHeadings remembers where the headings of the document are rendered,
+// for the breadcrumb.
+func (m *pagerModel) indexHeadings() {
+	if m.common.cfg.StatusBarNote != statusBarNoteBreadcrumb {
+		return
+	}
+	m.headings = parseHeadings(m.currentMarkdown())
+	m.headingLines = findHeadingLines(m.headings, m.renderedLines())
+}
+
+// breadcrumb returns the headings of the section being read, from the
+// outermost one in, fitting within the given width. Outer headings are left
+// out as needed to make it fit.
+func (m pagerModel) breadcrumb(width int) string {
+	var crumbs []string
+	level := 7
+	for i := len(m.headings) - 1; i >= 0; i-- {
+		line := m.headingLines[i]
+		if line < 0 || line > m.viewport.YOffset || m.headings[i].level >= level {
+			continue
+		}
+		crumbs = append([]string{m.headings[i].plainText()}, crumbs...)
+		level = m.headings[i].level
+	}
+
+	for dropped := false; len(crumbs) > 0; dropped = true {
+		crumb := strings.Join(crumbs, breadcrumbSeparator)
+		if dropped {
+			crumb = ellipsis + breadcrumbSeparator + crumb
+		}
+		if stringWidth(crumb) <= width {
+			return crumb
+		}
+		crumbs = crumbs[1:]
+	}
+	return ""
+}
@@ -0,0 +1,121 @@
+package ui
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/log"
+)
+
+// Size, in bytes, of the parts large documents are rendered in.
+const renderPartSize = 64 * 1024
+
+// Counter identifying documents rendered in parts, so that parts of a
+// superseded render can be told apart.
+var renderStreams atomic.Int64
+
+// renderStream is a document being rendered in parts, so that the top of a
+// large document shows while the rest is still rendering. Each part is
+// delivered in a contentRenderedMsg of its own, and appended to what's
+// been rendered so far.
+type renderStream struct {
+	id          int64
+	parts       []string
+	next        int // index of the part to render next
+	lines       int // number of lines rendered so far
+	sourceLines int // number of lines of the whole document
+	figures     int // number of figures numbered so far
+}
+
+// renderPart describes the part of a document being rendered.
+type renderPart struct {
+	lineOffset   int  // lines rendered before this part
+	sourceLines  int  // of the whole document, for the line number gutter
+	figureOffset int  // figures numbered before this part
+	collapsed    bool // whether sections are already folded, see renderParts
+	last         bool // whether this is the end of the document
+}
+
+// renderedPart is a part of a document as rendered.
+type renderedPart struct {
+	content  string
+	graphics inlineImageGraphics // Kitty graphics showing its inline images
+	figures  int                 // number of figures numbered in it
+}
+
+// done returns whether all parts have been rendered.
+func (s renderStream) done() bool {
+	return s.next >= len(s.parts)
+}
+
+// renderParts splits a large markdown document into parts of at least
+// renderPartSize, at headings, for rendering one after the other. Code,
+// slides and documents smaller than StreamRenderThreshold aren't split up.
+// Folds and <details> sections are numbered across the whole document, so
+// the parts come with them collapsed already.
+func (m pagerModel) renderParts(md string) []string {
+	threshold := m.common.cfg.StreamRenderThreshold
+	if threshold <= 0 || len(md) < threshold || m.slideMode ||
+		!config.GlamourEnabled || !utils.IsMarkdownFile(m.currentDocument.Note) {
+		return nil
+	}
+	md = m.collapseSections(md)
+
+	lines := strings.Split(md, "\n")
+	offsets := make([]int, len(lines)+1) // of each line, in bytes
+	var defs []string
+	for i, line := range lines {
+		offsets[i+1] = offsets[i] + len(line) + 1
+		if linkDefPattern.MatchString(line) {
+			defs = append(defs, line)
+		}
+	}
+
+	var (
+		parts []string
+		start int
+	)
+	for _, h := range parseHeadings(md) {
+		if offsets[h.line]-offsets[start] < renderPartSize {
+			continue
+		}
+		parts = append(parts, strings.Join(lines[start:h.line], "\n"))
+		start = h.line
+	}
+	parts = append(parts, strings.Join(lines[start:], "\n"))
+
+	// Reference links can be defined anywhere in the document
+	if len(defs) > 0 && len(parts) > 1 {
+		for i := range parts {
+			parts[i] += "\n\n" + strings.Join(defs, "\n")
+		}
+	}
+	return parts
+}
+
+// renderNextPart renders the next part of a document being rendered in
+// parts.
+func renderNextPart(m pagerModel, s renderStream) tea.Cmd {
+	return func() tea.Msg {
+		start := time.Now()
+		md := s.parts[s.next]
+		s.next++
+
+		out, err := glamourRenderPart(m, md, renderPart{
+			lineOffset:   s.lines,
+			sourceLines:  s.sourceLines,
+			figureOffset: s.figures,
+			collapsed:    true,
+			last:         s.done(),
+		})
+		if err != nil {
+			log.Error("error rendering with Glamour", "error", err, "part", s.next)
+			return errMsg{err}
+		}
+		s.figures += out.figures
+		return contentRenderedMsg{content: out.content, graphics: out.graphics, duration: time.Since(start), stream: &s}
+	}
+}
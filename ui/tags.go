@@ -0,0 +1,230 @@
+package ui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+var errTagNotFound = errors.New("tag not found")
+
+// tagLocation is where a symbol is defined, according to a tags file.
+type tagLocation struct {
+	path string
+	line int // 1-based
+}
+
+// findTagsFile looks for a ctags index named "tags" in the given directory
+// and its parents.
+func findTagsFile(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	for {
+		path := filepath.Join(dir, "tags")
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// lookupTag finds the definition of symbol in the given tags file. Entries
+// look like this, where the address is either a line number or a search
+// pattern:
+//
+//	symbol<TAB>file<TAB>address;"<TAB>fields...
+func lookupTag(tagsPath, symbol string) (tagLocation, error) {
+	f, err := os.Open(tagsPath)
+	if err != nil {
+		return tagLocation{}, fmt.Errorf("unable to open tags file: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		name, rest, ok := strings.Cut(scanner.Text(), "\t")
+		if !ok || name != symbol {
+			continue
+		}
+		file, address, ok := strings.Cut(rest, "\t")
+		if !ok {
+			continue
+		}
+		address, _, _ = strings.Cut(address, ";\"")
+
+		path := file
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(tagsPath), file)
+		}
+		line, err := tagLine(path, address)
+		if err != nil {
+			return tagLocation{}, err
+		}
+		return tagLocation{path: path, line: line}, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return tagLocation{}, fmt.Errorf("unable to read tags file: %w", err)
+	}
+
+	return tagLocation{}, errTagNotFound
+}
+
+// tagLine resolves a tag address to a line number in the given file.
+func tagLine(path, address string) (int, error) {
+	if n, err := strconv.Atoi(address); err == nil {
+		return n, nil
+	}
+
+	// Search patterns are literal, anchored lines like /^func main() {$/
+	pattern := strings.Trim(address, "/?")
+	pattern = strings.ReplaceAll(pattern, `\/`, "/")
+	pattern = strings.ReplaceAll(pattern, `\?`, "?")
+	pattern = strings.ReplaceAll(pattern, `\\`, `\`)
+	pattern = strings.TrimPrefix(pattern, "^")
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("unable to read tagged file: %w", err)
+	}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if (anchored && line == pattern) || (!anchored && strings.HasPrefix(line, pattern)) {
+			return i + 1, nil
+		}
+	}
+	return 0, errTagNotFound
+}
+
+// identifierPattern matches the symbols tags files index.
+var identifierPattern = regexp.MustCompile(`[\p{L}_][\p{L}\p{N}_]*`)
+
+// cursorSymbols returns the symbols to look up the definition of, in order.
+// The pager has no cursor, so like when opening the editor, it goes by the
+// selected link, then the search match in view, then the words of the line
+// at the top of the viewport.
+func (m pagerModel) cursorSymbols() []string {
+	if l, ok := m.currentLink(); ok {
+		if symbol := identifierPattern.FindString(l.text); symbol != "" {
+			return []string{symbol}
+		}
+	}
+
+	md := m.currentMarkdown()
+	if m.searchActive() && m.searchIndex >= 0 && m.searchIndex < len(m.searchMatches) {
+		match := m.searchMatches[m.searchIndex]
+		if match.line >= m.viewport.YOffset && match.line < m.viewport.YOffset+m.viewport.Height && match.source <= len(md) {
+			start := strings.LastIndex(md[:match.source], "\n") + 1
+			end := len(md)
+			if i := strings.Index(md[start:], "\n"); i >= 0 {
+				end = start + i
+			}
+			for _, loc := range identifierPattern.FindAllStringIndex(md[start:end], -1) {
+				if start+loc[0] <= match.source && match.source < start+loc[1] {
+					return []string{md[start+loc[0] : start+loc[1]]}
+				}
+			}
+		}
+	}
+
+	rendered := m.renderedLines()
+	if len(rendered) == 0 {
+		return nil
+	}
+	sources := m.renderedLineMap(md, rendered)
+	lines := strings.Split(md, "\n")
+	if line := sources[min(m.viewport.YOffset, len(sources)-1)]; line < len(lines) {
+		return identifierPattern.FindAllString(lines[line], -1)
+	}
+	return nil
+}
+
+// jumpToTag jumps to the definition of the symbol under the cursor, trying
+// each of the words on the top line in turn when there's no better guess.
+func (m *pagerModel) jumpToTag() tea.Cmd {
+	if utils.IsMarkdownFile(m.currentDocument.Note) || m.currentDocument.localPath == "" {
+		return nil
+	}
+	symbols := m.cursorSymbols()
+	if len(symbols) == 0 {
+		return m.showStatusMessage(pagerStatusMessage{"No symbol to jump to the definition of", false})
+	}
+
+	tagsPath, ok := findTagsFile(m.localDir())
+	if !ok {
+		return m.showStatusMessage(pagerStatusMessage{"No tags file found", true})
+	}
+	for _, symbol := range symbols {
+		loc, err := lookupTag(tagsPath, symbol)
+		if errors.Is(err, errTagNotFound) {
+			continue
+		}
+		if err != nil {
+			return m.showStatusMessage(pagerStatusMessage{err.Error(), true})
+		}
+		m.recordJump()
+		return m.openFileAt(loc.path, loc.line-1)
+	}
+
+	if len(symbols) == 1 {
+		return m.showStatusMessage(pagerStatusMessage{"Tag not found: " + symbols[0], true})
+	}
+	return m.showStatusMessage(pagerStatusMessage{"No tags found for the top line", true})
+}
+
+// openFileAt opens the given file in the pager, scrolled to the given line
+// of rendered output, or scrolls there if it's already open.
+func (m *pagerModel) openFileAt(path string, offset int) tea.Cmd {
+	if sameFile(path, m.currentDocument.localPath) {
+		return m.scrollTo(offset)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return m.showStatusMessage(pagerStatusMessage{fmt.Sprintf("Unable to open %s", path), true})
+	}
+
+	m.unwatchFile()
+	m.clearSearch()
+	m.slides = nil
+	m.slideMode = false
+	m.currentSlide = 0
+	m.pendingYOffset = &offset
+
+	cwd, _ := os.Getwd()
+	m.currentDocument = markdown{
+		localPath: path,
+		Note:      stripAbsolutePath(path, cwd),
+		Modtime:   info.ModTime(),
+	}
+	return loadLocalMarkdown(&m.currentDocument)
+}
+
+// sameFile returns whether two paths refer to the same file.
+func sameFile(a, b string) bool {
+	ia, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	ib, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(ia, ib)
+}
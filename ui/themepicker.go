@@ -0,0 +1,268 @@
+package ui
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/styles"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/x/ansi"
+	gap "github.com/muesli/go-app-paths"
+)
+
+// Markdown each style is previewed with in the style picker.
+const stylePreviewMarkdown = "# Heading\n\n" +
+	"Some *emphasis*, **bold** and `code`.\n\n" +
+	"- A list item\n- Another one\n\n" +
+	"> A quote\n\n" +
+	"```go\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n```\n"
+
+// pickableStyles returns the styles offered by the style picker, and
+// cycled through with s: the built-in ones, the style files in the styles
+// directory of glow's configuration and, if it's a style file of its own,
+// the current one.
+func pickableStyles(current string) []string {
+	var names []string
+	for name := range styles.DefaultStyles {
+		// No different from ascii
+		if name != styles.NoTTYStyle {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	names = append(names, customStyles()...)
+	if !slices.Contains(names, current) {
+		names = append([]string{current}, names...)
+	}
+	return names
+}
+
+// customStyles returns the style files in the styles directories of glow's
+// configuration.
+func customStyles() []string {
+	dirs, err := gap.NewScope(gap.User, "glow").ConfigDirs()
+	if err != nil {
+		log.Debug("unable to find the configuration directories", "error", err)
+		return nil
+	}
+	var files []string
+	for _, dir := range dirs {
+		matches, _ := filepath.Glob(filepath.Join(dir, "styles", "*.json"))
+		files = append(files, matches...)
+	}
+	return files
+}
+
+// styleName returns how a style is shown in the picker: style files by
+// their file name.
+func styleName(style string) string {
+	if _, ok := styles.DefaultStyles[style]; ok {
+		return style
+	}
+	return filepath.Base(style)
+}
+
+// renderStylePreview renders the preview markdown in the given style, at
+// the given width.
+func renderStylePreview(style string, width int) string {
+	r, err := glamour.NewTermRenderer(utils.GlamourStyle(style, false), glamour.WithWordWrap(width))
+	if err != nil {
+		log.Warn("unable to preview style", "style", style, "error", err)
+		return redFg("Unable to preview " + styleName(style))
+	}
+	out, err := r.Render(stylePreviewMarkdown)
+	if err != nil {
+		log.Warn("unable to preview style", "style", style, "error", err)
+		return redFg("Unable to preview " + styleName(style))
+	}
+	return strings.Trim(out, "\n")
+}
+
+// openStylePicker opens the style picker, with the current style selected.
+func (m *pagerModel) openStylePicker() tea.Cmd {
+	if !config.GlamourEnabled {
+		return m.showStatusMessage(pagerStatusMessage{"Styles need Glamour", true})
+	}
+
+	m.styleChoices = pickableStyles(m.common.cfg.GlamourStyle)
+	m.styleChoice = slices.Index(m.styleChoices, m.common.cfg.GlamourStyle)
+	_, width := m.stylePickerSize()
+	m.stylePreviews = make([]string, len(m.styleChoices))
+	for i, style := range m.styleChoices {
+		m.stylePreviews[i] = renderStylePreview(style, width)
+	}
+
+	m.pickingStyle = true
+	if m.viewport.HighPerformanceRendering {
+		return tea.ClearScrollArea //nolint:staticcheck
+	}
+	return nil
+}
+
+// closeStylePicker closes the style picker, leaving the style as it is.
+func (m *pagerModel) closeStylePicker() tea.Cmd {
+	m.pickingStyle = false
+	m.styleChoices = nil
+	m.stylePreviews = nil
+	if m.viewport.HighPerformanceRendering {
+		return viewport.Sync(m.viewport)
+	}
+	return nil
+}
+
+// handleStylePickerInput handles key presses while the style picker is
+// open.
+func (m pagerModel) handleStylePickerInput(msg tea.KeyMsg) (pagerModel, tea.Cmd) {
+	switch msg.String() {
+	case keyEsc, "q", "ctrl+t":
+		return m, m.closeStylePicker()
+
+	case "k", "up":
+		m.styleChoice = max(0, m.styleChoice-1)
+
+	case "j", "down":
+		m.styleChoice = min(len(m.styleChoices)-1, m.styleChoice+1)
+
+	case "g", "home":
+		m.styleChoice = 0
+
+	case "G", "end":
+		m.styleChoice = len(m.styleChoices) - 1
+
+	case keyEnter:
+		style := m.styleChoices[m.styleChoice]
+		cmd := m.closeStylePicker()
+		return m, tea.Batch(cmd, m.setStyle(style))
+	}
+
+	return m, nil
+}
+
+// setStyle renders the document in the given glamour style for the rest of
+// the session.
+func (m *pagerModel) setStyle(style string) tea.Cmd {
+	if style == m.common.cfg.GlamourStyle {
+		return nil
+	}
+	m.common.cfg.GlamourStyle = style
+	applyChromeColors(m.common.cfg.ChromeColors, style)
+
+	percent := m.viewport.ScrollPercent()
+	m.restoreScroll = &percent
+	message := "Style: " + styleName(style)
+	if m.common.cfg.CodeStyle != "" {
+		message += " (code: " + m.common.cfg.CodeStyle + ")"
+	}
+	return tea.Batch(
+		renderWithGlamour(*m, m.currentMarkdown()),
+		m.showStatusMessage(pagerStatusMessage{message: message}),
+	)
+}
+
+// cycleStyle renders the document in the style after the current one, of
+// those the style picker offers, going back to the first after the last.
+func (m *pagerModel) cycleStyle() tea.Cmd {
+	if !config.GlamourEnabled {
+		return m.showStatusMessage(pagerStatusMessage{"Styles need Glamour", true})
+	}
+	names := pickableStyles(m.common.cfg.GlamourStyle)
+	i := slices.Index(names, m.common.cfg.GlamourStyle)
+	return m.setStyle(names[(i+1)%len(names)])
+}
+
+// styleReloadedMsg reports the configured style loaded afresh.
+type styleReloadedMsg struct {
+	style string
+	err   error
+}
+
+// reloadStyle loads the configured style afresh, picking up changes made to
+// the configuration or to a custom style's file since starting. Reading
+// them happens in the background, the document being rendered in the style
+// once it's loaded.
+func (m pagerModel) reloadStyle() tea.Cmd {
+	style, load := m.common.cfg.GlamourStyle, m.common.cfg.LoadStyle
+	return func() tea.Msg {
+		if load != nil {
+			var err error
+			if style, err = load(); err != nil {
+				return styleReloadedMsg{err: err}
+			}
+		}
+		if _, err := utils.StyleConfig(style); err != nil {
+			return styleReloadedMsg{style: style, err: err}
+		}
+		return styleReloadedMsg{style: style}
+	}
+}
+
+// handleStyleReloaded renders the document in the style loaded afresh.
+func (m *pagerModel) handleStyleReloaded(msg styleReloadedMsg) tea.Cmd {
+	if msg.err != nil {
+		log.Error("unable to reload style", "style", msg.style, "error", msg.err)
+		return m.showStatusMessage(pagerStatusMessage{"Unable to reload style: " + msg.err.Error(), true})
+	}
+
+	m.common.cfg.GlamourStyle = msg.style
+	applyChromeColors(m.common.cfg.ChromeColors, msg.style)
+	m.stylePreviews = nil
+
+	percent := m.viewport.ScrollPercent()
+	m.restoreScroll = &percent
+	return tea.Batch(
+		renderWithGlamour(*m, m.currentMarkdown()),
+		m.showStatusMessage(pagerStatusMessage{message: "Reloaded style: " + styleName(msg.style)}),
+	)
+}
+
+// stylePickerSize returns how wide the list of styles and their preview are
+// in the style picker.
+func (m pagerModel) stylePickerSize() (list, preview int) {
+	for _, style := range m.styleChoices {
+		list = max(list, stringWidth(styleName(style)))
+	}
+	list += 2 // room for the selection marker
+	width := m.viewport.Width - tocViewStyle.GetHorizontalFrameSize() - list - 2
+	return list, max(1, min(width, 60))
+}
+
+// stylePickerView renders the style picker: the styles to pick from and a
+// preview of the selected one.
+func (m pagerModel) stylePickerView() string {
+	const chrome = 4 // border, title and the blank line below it
+
+	height := max(1, m.viewport.Height-chrome)
+	listWidth, previewWidth := m.stylePickerSize()
+
+	var list strings.Builder
+	for i, style := range m.styleChoices {
+		entry := "  " + styleName(style)
+		if i == m.styleChoice {
+			entry = fuchsiaFg("› " + styleName(style))
+		}
+		if i > 0 {
+			list.WriteString("\n")
+		}
+		list.WriteString(entry)
+	}
+
+	preview := strings.Split(m.stylePreviews[m.styleChoice], "\n")
+	if len(preview) > height {
+		preview = preview[:height]
+	}
+	for i, line := range preview {
+		preview[i] = ansi.Truncate(line, previewWidth, "")
+	}
+
+	body := lipgloss.JoinHorizontal(lipgloss.Top,
+		lipgloss.NewStyle().Width(listWidth+2).Render(list.String()),
+		strings.Join(preview, "\n"),
+	)
+	return tocViewStyle.Render(fuchsiaFg("Styles") + "\n\n" + body)
+}
@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// presentationTickMsg is sent every second while presenting, to update the
+// elapsed time in the status bar.
+type presentationTickMsg struct{ gen int }
+
+// presentationTimer measures how long a slide deck has been presented for.
+// It's paused while the deck isn't shown.
+type presentationTimer struct {
+	deck    string        // path or name of the deck being timed
+	elapsed time.Duration // before the timer was last started
+	started time.Time     // zero while paused
+	gen     int           // ticks of an earlier run are ignored
+}
+
+func (t presentationTimer) running() bool {
+	return !t.started.IsZero()
+}
+
+// total returns the time presented so far.
+func (t presentationTimer) total() time.Duration {
+	if t.running() {
+		return t.elapsed + time.Since(t.started)
+	}
+	return t.elapsed
+}
+
+// start starts or resumes timing the given deck, from zero if it's a
+// different deck than last time.
+func (t *presentationTimer) start(deck string) tea.Cmd {
+	if t.running() {
+		return nil
+	}
+	if deck != t.deck {
+		t.deck = deck
+		t.elapsed = 0
+	}
+	t.started = time.Now()
+	t.gen++
+	return t.tick()
+}
+
+// pause stops the timer, keeping the time presented so far.
+func (t *presentationTimer) pause() {
+	if t.running() {
+		t.elapsed = t.total()
+		t.started = time.Time{}
+	}
+}
+
+// reset sets the timer back to zero, leaving it running if it was.
+func (t *presentationTimer) reset() {
+	t.elapsed = 0
+	if t.running() {
+		t.started = time.Now()
+	}
+}
+
+func (t presentationTimer) tick() tea.Cmd {
+	gen := t.gen
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return presentationTickMsg{gen}
+	})
+}
+
+// view renders the time presented as mm:ss, padded so that the status bar
+// doesn't shift as the minutes go up.
+func (t presentationTimer) view() string {
+	d := t.total().Truncate(time.Second)
+	return fmt.Sprintf("%6s", fmt.Sprintf("%02d:%02d", int(d.Minutes()), int(d.Seconds())%60))
+}
+
+// startPresentationTimer starts timing the presentation of the current deck.
+func (m *pagerModel) startPresentationTimer() tea.Cmd {
+	deck := m.currentDocument.localPath
+	if deck == "" {
+		deck = m.currentDocument.Note
+	}
+	return m.timer.start(deck)
+}
+
+// handlePresentationTick keeps the timer ticking while slides are shown.
+func (m *pagerModel) handlePresentationTick(msg presentationTickMsg) tea.Cmd {
+	if msg.gen != m.timer.gen || !m.timer.running() {
+		return nil // paused, or restarted since
+	}
+	if !m.slideMode {
+		m.timer.pause()
+		return nil
+	}
+	return m.timer.tick()
+}
@@ -0,0 +1,202 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+var tocViewStyle = lipgloss.NewStyle().
+	Border(lipgloss.RoundedBorder()).
+	BorderForeground(fuchsia).
+	Padding(0, 2)
+
+// tocEntry is a heading listed in the table of contents.
+type tocEntry struct {
+	heading
+	renderedLine int // line the heading is rendered on, -1 if not found
+}
+
+// buildTOC returns the table of contents of the current markdown, with the
+// rendered line of each heading.
+func (m pagerModel) buildTOC() []tocEntry {
+	headings := parseHeadings(m.currentMarkdown())
+	lines := findHeadingLines(headings, m.renderedLines())
+
+	toc := make([]tocEntry, len(headings))
+	for i, h := range headings {
+		toc[i] = tocEntry{h, lines[i]}
+	}
+	return toc
+}
+
+// nextHeading scrolls to the next heading below the top of the viewport or,
+// going backward, the previous one above it. Slides leave [ and ] be.
+func (m *pagerModel) nextHeading(backward bool) tea.Cmd {
+	if m.slideMode || !utils.IsMarkdownFile(m.currentDocument.Note) {
+		return nil
+	}
+
+	target := -1
+	for _, e := range m.buildTOC() {
+		if e.renderedLine < 0 {
+			continue
+		}
+		if backward && e.renderedLine < m.viewport.YOffset {
+			target = e.renderedLine
+		}
+		if !backward && e.renderedLine > m.viewport.YOffset {
+			target = e.renderedLine
+			break
+		}
+	}
+	// Headings below the last page can't be scrolled to the top
+	if target < 0 || !backward && m.viewport.AtBottom() {
+		message := "Last heading"
+		if backward {
+			message = "First heading"
+		}
+		return m.showStatusMessage(pagerStatusMessage{message: message})
+	}
+
+	return m.scrollTo(target)
+}
+
+// openTOC opens the table of contents, with the section being read
+// selected.
+func (m *pagerModel) openTOC() tea.Cmd {
+	if !utils.IsMarkdownFile(m.currentDocument.Note) {
+		return nil
+	}
+	m.toc = m.buildTOC()
+	if len(m.toc) == 0 {
+		return m.showStatusMessage(pagerStatusMessage{"No headings", true})
+	}
+
+	m.showTOC = true
+	m.tocOrigin = m.viewport.YOffset
+	m.tocIndex = 0
+	for i, e := range m.toc {
+		if e.renderedLine >= 0 && e.renderedLine <= m.viewport.YOffset {
+			m.tocIndex = i
+		}
+	}
+
+	if m.viewport.HighPerformanceRendering {
+		return tea.ClearScrollArea //nolint:staticcheck
+	}
+	return nil
+}
+
+// closeTOC closes the table of contents.
+func (m *pagerModel) closeTOC() tea.Cmd {
+	m.showTOC = false
+	m.toc = nil
+	if m.viewport.HighPerformanceRendering {
+		return viewport.Sync(m.viewport)
+	}
+	return nil
+}
+
+// handleTOCInput handles key presses while the table of contents is open.
+// The document scrolls to the selected heading as a preview, and back to
+// where it was unless the heading is chosen.
+func (m pagerModel) handleTOCInput(msg tea.KeyMsg) (pagerModel, tea.Cmd) {
+	switch msg.String() {
+	case keyEsc, "q", "t":
+		m.viewport.SetYOffset(m.tocOrigin)
+		return m, m.closeTOC()
+
+	case "k", "up":
+		m.tocIndex = max(0, m.tocIndex-1)
+
+	case "j", "down":
+		m.tocIndex = min(len(m.toc)-1, m.tocIndex+1)
+
+	case "g", "home":
+		m.tocIndex = 0
+
+	case "G", "end":
+		m.tocIndex = len(m.toc) - 1
+
+	case keyEnter:
+		// Jump from where the table of contents was opened, so that's
+		// where jumping back returns to
+		line := m.toc[m.tocIndex].renderedLine
+		m.viewport.SetYOffset(m.tocOrigin)
+		cmd := m.closeTOC()
+		if line < 0 {
+			return m, cmd
+		}
+		return m, tea.Batch(cmd, m.jumpTo(line))
+
+	default:
+		return m, nil
+	}
+
+	if line := m.toc[m.tocIndex].renderedLine; line >= 0 {
+		m.viewport.SetYOffset(line)
+	}
+	return m, nil
+}
+
+// tocView renders the table of contents, scrolled to keep the selected
+// heading in view. It takes up to two thirds of the viewport's width, so the
+// document shows beside it.
+func (m pagerModel) tocView() string {
+	const chrome = 4 // border, title and the blank line below it
+
+	height := max(1, min(len(m.toc), m.viewport.Height-chrome))
+	top := max(0, min(m.tocIndex-height/2, len(m.toc)-height))
+
+	// Indent relative to the highest level heading in the document
+	minLevel := 6
+	for _, e := range m.toc {
+		minLevel = min(minLevel, e.level)
+	}
+
+	width := stringWidth("Contents")
+	for _, e := range m.toc {
+		width = max(width, 2*(e.level-minLevel)+stringWidth(e.plainText()))
+	}
+	width = max(1, min(width, m.viewport.Width*2/3-tocViewStyle.GetHorizontalFrameSize()))
+
+	var b strings.Builder
+	b.WriteString(fuchsiaFg("Contents") + "\n")
+	for i, e := range m.toc[top : top+height] {
+		entry := strings.Repeat("  ", e.level-minLevel) + e.plainText()
+		entry = truncateWidth(entry, width, "…")
+		if top+i == m.tocIndex {
+			entry = fuchsiaFg(entry)
+		}
+		b.WriteString("\n" + entry)
+	}
+
+	return tocViewStyle.Render(b.String())
+}
+
+// tocOverlayView renders the table of contents over the right of the
+// viewport, leaving the document visible beside it.
+func (m pagerModel) tocOverlayView() string {
+	// The document is drawn here even with high performance rendering, as
+	// the scroll area is cleared while the table of contents is open
+	vp := m.viewport
+	vp.HighPerformanceRendering = false
+	lines := strings.Split(vp.View(), "\n")
+
+	box := m.tocView()
+	left := max(0, m.viewport.Width-lipgloss.Width(box))
+	top := max(0, (len(lines)-lipgloss.Height(box))/2)
+	for i, row := range strings.Split(box, "\n") {
+		if top+i >= len(lines) {
+			break
+		}
+		line := ansi.Truncate(lines[top+i], left, "")
+		lines[top+i] = line + strings.Repeat(" ", max(0, left-ansi.StringWidth(line))) + row
+	}
+	return strings.Join(lines, "\n")
+}
@@ -5,15 +5,18 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/glamour/styles"
 	"github.com/charmbracelet/glow/v2/utils"
 	"github.com/charmbracelet/log"
 	"github.com/muesli/gitcha"
 	te "github.com/muesli/termenv"
+	"golang.org/x/term"
 )
 
 const (
@@ -25,12 +28,13 @@ var (
 	config Config
 
 	markdownExtensions = []string{
-		"*.md", "*.mdown", "*.mkdn", "*.mkd", "*.markdown",
+		"*.md", "*.mdown", "*.mkdn", "*.mkd", "*.markdown", "*.md.gz",
 	}
 )
 
-// NewProgram returns a new Tea program.
-func NewProgram(cfg Config, content string) *tea.Program {
+// NewProgram returns a new Tea program, with the given options on top of
+// its own, and a function to clean up after it once it's done running.
+func NewProgram(cfg Config, content string, opts ...tea.ProgramOption) (*tea.Program, func()) {
 	log.Debug(
 		"Starting glow",
 		"high_perf_pager",
@@ -40,12 +44,23 @@ func NewProgram(cfg Config, content string) *tea.Program {
 	)
 
 	config = cfg
-	opts := []tea.ProgramOption{tea.WithAltScreen()}
+	opts = append([]tea.ProgramOption{tea.WithAltScreen()}, opts...)
 	if cfg.EnableMouse {
 		opts = append(opts, tea.WithMouseCellMotion())
 	}
 	m := newModel(cfg, content)
-	return tea.NewProgram(m, opts...)
+	p := tea.NewProgram(m, opts...)
+
+	stop := func() {}
+	if cfg.ControlSocket != "" {
+		if stopControl, err := listenForControl(cfg.ControlSocket, p); err != nil {
+			log.Error("unable to start control server", "error", err)
+		} else {
+			stop = stopControl
+		}
+	}
+
+	return p, stop
 }
 
 type errMsg struct{ err error }
@@ -95,6 +110,23 @@ type commonModel struct {
 	cwd    string
 	width  int
 	height int
+
+	// Whether glow was launched with a single document, rather than a
+	// directory to browse
+	singleDocument bool
+}
+
+// escQuits returns whether esc quits from the pager, rather than returning
+// to the file listing.
+func (c commonModel) escQuits() bool {
+	switch c.cfg.QuitKeyBehavior {
+	case quitKeyQuit:
+		return true
+	case quitKeyBack:
+		return false
+	default:
+		return c.singleDocument
+	}
 }
 
 type model struct {
@@ -130,8 +162,18 @@ func (m *model) unloadDocument() []tea.Cmd {
 	return batch
 }
 
+// quit quits glow, saving the reading position of the document being read
+// first, if there is one.
+func (m model) quit() tea.Cmd {
+	if m.state == stateShowDocument {
+		m.pager.savePosition()
+	}
+	return tea.Quit
+}
+
 func newModel(cfg Config, content string) tea.Model {
 	initSections()
+	setEmojiWidth(cfg.EmojiWidth)
 
 	if cfg.GlamourStyle == styles.AutoStyle {
 		if te.HasDarkBackground() {
@@ -141,6 +183,8 @@ func newModel(cfg Config, content string) tea.Model {
 		}
 	}
 
+	applyChromeColors(cfg.ChromeColors, cfg.GlamourStyle)
+
 	common := commonModel{
 		cfg: cfg,
 	}
@@ -152,10 +196,19 @@ func newModel(cfg Config, content string) tea.Model {
 		stash:  newStashModel(&common),
 	}
 
+	// Some terminals are slow to report their size, so make an educated
+	// guess until the first tea.WindowSizeMsg arrives.
+	if w, h := initialSize(); w > 0 && h > 0 {
+		m.stash.setSize(w, h)
+		m.pager.setSize(w, h)
+	}
+
 	path := cfg.Path
 	if path == "" && content != "" {
 		m.state = stateShowDocument
-		m.pager.currentDocument = markdown{Body: content}
+		m.common.singleDocument = true
+		m.pager.currentDocument = markdown{Body: content, source: content, lineOffset: cfg.LineNumberOffset}
+		m.pager.stats = newDocumentStats(content)
 		return m
 	}
 
@@ -173,6 +226,7 @@ func newModel(cfg Config, content string) tea.Model {
 	} else {
 		cwd, _ := os.Getwd()
 		m.state = stateShowDocument
+		m.common.singleDocument = true
 		m.pager.currentDocument = markdown{
 			localPath: path,
 			Note:      stripAbsolutePath(path, cwd),
@@ -185,6 +239,9 @@ func newModel(cfg Config, content string) tea.Model {
 
 func (m model) Init() tea.Cmd {
 	cmds := []tea.Cmd{m.stash.spinner.Tick}
+	if m.pager.showsClock() {
+		cmds = append(cmds, tickClock())
+	}
 
 	switch m.state {
 	case stateShowStash:
@@ -210,8 +267,25 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		// Let the pager handle all keys while it's showing an overlay
+		if m.state == stateShowDocument && m.pager.capturesKeys() && msg.String() != "ctrl+c" {
+			newPagerModel, cmd := m.pager.update(msg)
+			m.pager = newPagerModel
+			return m, tea.Batch(cmd, m.pager.followSpotlight())
+		}
+
 		switch msg.String() {
 		case "esc":
+			// Esc clears an active search or leaves fullscreen before
+			// leaving the document
+			if m.state == stateShowDocument && m.pager.handlesEsc() {
+				break
+			}
+			// There's no file listing to go back to when launched with
+			// a single document
+			if m.state == stateShowDocument && m.common.escQuits() {
+				return m, m.quit()
+			}
 			if m.state == stateShowDocument || m.stash.viewState == stashStateLoadingDocument {
 				batch := m.unloadDocument()
 				return m, tea.Batch(batch...)
@@ -231,6 +305,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "q":
 			var cmd tea.Cmd
 
+			// The pager quits with whichever key is bound to quitting
+			if m.state == stateShowDocument {
+				break
+			}
+
 			switch m.state { //nolint:exhaustive
 			case stateShowStash:
 				// pass through all keys if we're editing the filter
@@ -240,9 +319,23 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 
-			return m, tea.Quit
+			return m, m.quit()
 
 		case "left", "h", "delete":
+			// h and, outside of slides, left scroll content that's wider
+			// than the screen instead, and left goes to the previous slide
+			if m.state == stateShowDocument && m.pager.keys.rebound(msg) {
+				break
+			}
+			if m.state == stateShowDocument && m.pager.slideMode && key.Matches(msg, m.pager.keys.PrevSlide) {
+				break
+			}
+			if m.state == stateShowDocument && msg.String() == "h" && m.pager.scrollsHorizontally() {
+				break
+			}
+			if m.state == stateShowDocument && msg.String() == "left" && m.pager.arrowsScrollHorizontally() {
+				break
+			}
 			if m.state == stateShowDocument {
 				cmds = append(cmds, m.unloadDocument()...)
 				return m, tea.Batch(cmds...)
@@ -253,16 +346,22 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// Ctrl+C always quits no matter where in the application you are.
 		case "ctrl+c":
-			return m, tea.Quit
+			return m, m.quit()
 		}
 
 	// Window size is received when starting up and on every resize
 	case tea.WindowSizeMsg:
+		resized := msg.Height != m.common.height
 		m.common.width = msg.Width
 		m.common.height = msg.Height
 		m.stash.setSize(msg.Width, msg.Height)
 		m.pager.setSize(msg.Width, msg.Height)
 
+		// Slide pages are as tall as the viewport
+		if resized && m.state == stateShowDocument && m.pager.paginatesSlides() {
+			cmds = append(cmds, renderWithGlamour(m.pager, m.pager.currentMarkdown()))
+		}
+
 	case initLocalFileSearchMsg:
 		m.localFileFinder = msg.ch
 		m.common.cwd = msg.cwd
@@ -271,13 +370,29 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case fetchedMarkdownMsg:
 		// We've loaded a markdown file's contents for rendering
 		m.pager.currentDocument = *msg
-		body := string(utils.RemoveFrontmatter([]byte(msg.Body)))
+
+		// Folds are keyed by source line, which a reload may shift
+		m.pager.folded = nil
+
+		// The line number offset can be given on the command line for the
+		// document glow was launched with, or in the front matter
+		if msg.localPath == m.common.cfg.Path {
+			m.pager.currentDocument.lineOffset = m.common.cfg.LineNumberOffset
+		}
+		if utils.IsMarkdownFile(msg.localPath) {
+			if meta := parseDocumentMeta(msg.Body); meta.LineOffset != 0 {
+				m.pager.currentDocument.lineOffset = meta.LineOffset
+			}
+		}
 
 		// Update the document body to have frontmatter removed before parsing
+		body := m.pager.documentBody()
 		m.pager.currentDocument.Body = body
+		m.pager.stats = newDocumentStats(body)
 
 		// Parse slides to check if we should enter slide mode
 		m.pager.parseSlides()
+		cmds = append(cmds, recordRecentDocument(msg.localPath, m.common.cfg.RecentDocuments))
 
 		// Render the first slide if in slide mode, otherwise render full content
 		if m.pager.slideMode && len(m.pager.slides) > 0 {
@@ -289,6 +404,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case contentRenderedMsg:
 		m.state = stateShowDocument
 
+	case clockTickMsg:
+		return m, tickClock()
+
 	case localFileSearchFinished:
 		// Always pass these messages to the stash so we can keep it updated
 		// about network activity, even if the user isn't currently viewing
@@ -326,7 +444,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case stateShowDocument:
 		newPagerModel, cmd := m.pager.update(msg)
 		m.pager = newPagerModel
-		cmds = append(cmds, cmd)
+		cmds = append(cmds, cmd, m.pager.followSpotlight())
 	}
 
 	return m, tea.Batch(cmds...)
@@ -339,7 +457,9 @@ func (m model) View() string {
 
 	switch m.state { //nolint:exhaustive
 	case stateShowDocument:
-		return m.pager.View()
+		// Kitty graphics take no room, and are written out like any other
+		// part of the view so as not to get in the way of drawing it
+		return m.pager.kittyGraphics + m.pager.View()
 	default:
 		return m.stash.view()
 	}
@@ -439,6 +559,38 @@ func localFileToMarkdown(cwd string, res gitcha.SearchResult) *markdown {
 	}
 }
 
+// initialSize returns the terminal dimensions as advertised by the COLUMNS
+// and LINES environment variables, falling back to querying the terminal.
+// Zero values are returned if the size can't be determined.
+func initialSize() (width, height int) {
+	width = envSize("COLUMNS")
+	height = envSize("LINES")
+	if width > 0 && height > 0 {
+		return width, height
+	}
+
+	w, h, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		log.Debug("unable to query terminal size", "error", err)
+		return 0, 0
+	}
+	return w, h
+}
+
+// envSize parses a positive dimension from the given environment variable.
+func envSize(key string) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil || n <= 0 {
+		log.Debug("ignoring invalid terminal size", "env", key, "value", v)
+		return 0
+	}
+	return n
+}
+
 func stripAbsolutePath(fullPath, cwd string) string {
 	fp, _ := filepath.EvalSymlinks(fullPath)
 	cp, _ := filepath.EvalSymlinks(cwd)
@@ -0,0 +1,173 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestEscFromPager(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "README.md")
+	if err := os.WriteFile(path, []byte("# Hello\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		content  string
+		behavior string
+		wantQuit bool
+	}{
+		{name: "single file", path: path, wantQuit: true},
+		{name: "stdin", content: "# Hello\n", wantQuit: true},
+		{name: "browsing", path: dir, wantQuit: false},
+		{name: "single file, back", path: path, behavior: quitKeyBack, wantQuit: false},
+		{name: "browsing, quit", path: dir, behavior: quitKeyQuit, wantQuit: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{Path: tt.path, QuitKeyBehavior: tt.behavior}
+			m := newModel(cfg, tt.content).(model)
+			if m.state == stateShowStash {
+				// Open a document from the file listing
+				m.state = stateShowDocument
+				m.pager.currentDocument = markdown{localPath: path, Note: "README.md"}
+			}
+
+			updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+			quit := false
+			if cmd != nil {
+				_, quit = cmd().(tea.QuitMsg)
+			}
+			if quit != tt.wantQuit {
+				t.Errorf("expected quit to be %v, got %v", tt.wantQuit, quit)
+			}
+			if !tt.wantQuit && updated.(model).state != stateShowStash {
+				t.Errorf("expected to return to the file listing, got state %v", updated.(model).state)
+			}
+		})
+	}
+}
+
+func TestBackKeys(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "README.md")
+	if err := os.WriteFile(path, []byte("# Hello\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		key       tea.KeyMsg
+		slideMode bool
+		wantBack  bool
+	}{
+		{name: "h", key: tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("h")}, wantBack: true},
+		{name: "left", key: tea.KeyMsg{Type: tea.KeyLeft}, wantBack: true},
+		{name: "left in slides", key: tea.KeyMsg{Type: tea.KeyLeft}, slideMode: true, wantBack: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newModel(Config{Path: dir}, "").(model)
+			m.state = stateShowDocument
+			m.pager.currentDocument = markdown{localPath: path, Note: "README.md"}
+			m.pager.slideMode = tt.slideMode
+			m.pager.slides = []string{"# One", "# Two"}
+			m.pager.currentSlide = 1
+
+			updated, _ := m.Update(tt.key)
+			if back := updated.(model).state == stateShowStash; back != tt.wantBack {
+				t.Errorf("expected going back to be %v, got %v", tt.wantBack, back)
+			}
+		})
+	}
+}
+
+func TestRenderedDocument(t *testing.T) {
+	m := newModel(Config{}, "").(model)
+	if doc := RenderedDocument(m); doc != "" {
+		t.Errorf("expected nothing without a document opened, got %q", doc)
+	}
+
+	m.pager.setContent("\x1b[1mHello\x1b[0m\n\n")
+	m.pager.unload()
+	if doc, want := RenderedDocument(m), "\x1b[1mHello\x1b[0m\x1b[m\n"; doc != want {
+		t.Errorf("expected the document last read, with the style reset, got %q", doc)
+	}
+
+	// The line number gutter and inline images are the pager's, not the
+	// document's
+	config = Config{GlamourEnabled: true}
+	t.Cleanup(func() { config = Config{} })
+	m.pager.common.cfg.ShowLineNumbersProse = true
+	m.pager.currentDocument.Note = "doc.md"
+	m.pager.setContent("\n\n")
+	gutter := func(n int) string { return fmt.Sprintf("%*d", m.pager.lineNumberWidth, n) }
+	m.pager.setContent(gutter(1) + "  Picture\n" + gutter(2) + "  " + kittyPlaceholderRow(1, 0, 3) + "\n" + gutter(3) + "  Text")
+	if doc, want := RenderedDocument(m), "  Picture\n  Text\x1b[m\n"; doc != want {
+		t.Errorf("expected the document alone, got %q", doc)
+	}
+}
+
+func TestControlSocketRemoved(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "glow.sock")
+	stop, err := listenForControl("unix:"+path, tea.NewProgram(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected the control socket to be created: %v", err)
+	}
+
+	stop()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the control socket to be removed, got %v", err)
+	}
+	stop()
+}
+
+func TestControlHandler(t *testing.T) {
+	// A program that's done running, so that commands are dropped
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	h := controlHandler(tea.NewProgram(nil, tea.WithContext(ctx)), &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 7777})
+
+	tests := []struct {
+		name   string
+		host   string
+		origin string
+		want   int
+	}{
+		{name: "localhost", host: "localhost:7777", want: http.StatusNoContent},
+		{name: "loopback", host: "127.0.0.1:7777", want: http.StatusNoContent},
+		{name: "ipv6 loopback", host: "[::1]:7777", want: http.StatusNoContent},
+		{name: "rebound name", host: "attacker.example:7777", want: http.StatusForbidden},
+		{name: "other port", host: "localhost:8080", want: http.StatusForbidden},
+		{name: "browser", host: "localhost:7777", origin: "https://attacker.example", want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/next", nil)
+			r.Host = tt.host
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("expected status %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
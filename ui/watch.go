@@ -0,0 +1,253 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+	"github.com/fsnotify/fsnotify"
+)
+
+// Events buffered for each watch, past which they're dropped rather than
+// hold up the others.
+const watchEventBuffer = 64
+
+// watchFanout reads the events of a watcher, as only one goroutine should,
+// so that none is taken by a watch that's been replaced, and hands them to
+// the watches subscribed to them.
+type watchFanout struct {
+	watcher *fsnotify.Watcher
+
+	mu   sync.Mutex
+	subs map[chan fsnotify.Event]struct{}
+}
+
+// newWatchFanout starts reading the watcher's events.
+func newWatchFanout(watcher *fsnotify.Watcher) *watchFanout {
+	f := &watchFanout{watcher: watcher, subs: map[chan fsnotify.Event]struct{}{}}
+	go f.run()
+	return f
+}
+
+func (f *watchFanout) run() {
+	for {
+		select {
+		case event, ok := <-f.watcher.Events:
+			if !ok {
+				return
+			}
+			f.mu.Lock()
+			for ch := range f.subs {
+				select {
+				case ch <- event:
+				default:
+					log.Debug("fsnotify event dropped", "file", event.Name, "event", event.Op)
+				}
+			}
+			f.mu.Unlock()
+		case err, ok := <-f.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Debug("fsnotify error", "error", err)
+		}
+	}
+}
+
+// subscribe returns a channel the watcher's events are sent on from now on.
+func (f *watchFanout) subscribe() chan fsnotify.Event {
+	ch := make(chan fsnotify.Event, watchEventBuffer)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.subs[ch] = struct{}{}
+	return ch
+}
+
+// unsubscribe stops sending events on the given channel.
+func (f *watchFanout) unsubscribe(ch chan fsnotify.Event) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.subs, ch)
+}
+
+// fileWatch watches the current document's file for changes. The file
+// itself is watched, so that changes to others in its directory don't
+// have to be sifted through, unless it's been moved away or removed, as
+// editors saving by renaming a new file over the old one do. Then the
+// directory is watched until the file is back.
+type fileWatch struct {
+	fanout  *watchFanout
+	watcher *fsnotify.Watcher
+	events  chan fsnotify.Event // of the watcher, from the fanout
+	path    string
+	done    chan struct{} // closed once the file is no longer watched
+
+	mu      sync.Mutex // guards the fields below
+	target  string     // what's being watched: the file or its directory
+	stopped bool
+}
+
+// newFileWatch returns a watch of the given file, which start starts.
+func newFileWatch(fanout *watchFanout, path string) *fileWatch {
+	return &fileWatch{
+		fanout:  fanout,
+		watcher: fanout.watcher,
+		events:  fanout.subscribe(),
+		path:    filepath.Clean(path),
+		done:    make(chan struct{}),
+	}
+}
+
+// watch moves the watch to the given file or directory.
+func (w *fileWatch) watch(target string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stopped {
+		return nil
+	}
+	if err := w.watcher.Add(target); err != nil {
+		return fmt.Errorf("unable to watch %s: %w", target, err)
+	}
+	if w.target != "" && w.target != target {
+		w.remove()
+	}
+	w.target = target
+	log.Info("fsnotify watching", "target", target)
+	return nil
+}
+
+// stop stops watching the file, letting wait return.
+func (w *fileWatch) stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stopped {
+		return
+	}
+	w.stopped = true
+	close(w.done)
+	w.fanout.unsubscribe(w.events)
+	w.remove()
+	w.target = ""
+}
+
+// remove removes what's being watched from the watcher. A file that's gone
+// has already been removed.
+func (w *fileWatch) remove() {
+	err := w.watcher.Remove(w.target)
+	switch {
+	case err == nil:
+		log.Debug("fsnotify unwatched", "target", w.target)
+	case errors.Is(err, fsnotify.ErrNonExistentWatch):
+	default:
+		log.Error("fsnotify fail to unwatch", "target", w.target, "error", err)
+	}
+}
+
+// onDir returns whether the directory is watched, for the file to be back.
+func (w *fileWatch) onDir() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.target != w.path
+}
+
+// wait waits for the file to change and reports it, once events stop
+// coming in for the given time, as editors can save a file in several
+// writes.
+func (w *fileWatch) wait(debounce time.Duration) tea.Msg {
+	if err := w.watch(w.path); err != nil {
+		log.Error("error adding file to fsnotify watcher", "error", err)
+		return watchFailedMsg{}
+	}
+
+	var (
+		timer   *time.Timer
+		settled <-chan time.Time // nil until the file changes
+	)
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case <-w.done:
+			return nil
+		case event := <-w.events:
+			if filepath.Clean(event.Name) != w.path {
+				continue
+			}
+			log.Debug("fsnotify event", "file", event.Name, "event", event.Op)
+
+			switch {
+			case event.Has(fsnotify.Rename), event.Has(fsnotify.Remove):
+				// A file renamed over this one is there already, so it's
+				// watched in its place. Otherwise, wait for one to be.
+				if err := w.watch(w.path); err != nil {
+					if err := w.watch(filepath.Dir(w.path)); err != nil {
+						log.Error("error adding dir to fsnotify watcher", "error", err)
+						return watchFailedMsg{}
+					}
+					continue
+				}
+			case event.Has(fsnotify.Create):
+				if w.onDir() {
+					if err := w.watch(w.path); err != nil {
+						log.Error("error adding file to fsnotify watcher", "error", err)
+						return watchFailedMsg{}
+					}
+				}
+			case !event.Has(fsnotify.Write):
+				continue
+			}
+
+			if debounce <= 0 {
+				return fileChangedMsg{}
+			}
+			if timer == nil {
+				timer = time.NewTimer(debounce)
+			} else {
+				timer.Reset(debounce)
+			}
+			settled = timer.C
+		case <-settled:
+			return fileChangedMsg{}
+		}
+	}
+}
+
+// rewatchFile watches the file for changes, in place of any watch of it
+// already, which would otherwise report some of them.
+func (m *pagerModel) rewatchFile() tea.Cmd {
+	m.stopWatching()
+	if m.currentDocument.localPath == "" {
+		return nil
+	}
+	if m.watcher == nil {
+		return func() tea.Msg { return watchFailedMsg{} }
+	}
+
+	w := newFileWatch(m.watcher, m.currentDocument.localPath)
+	m.watch = w
+	debounce := m.common.cfg.ReloadDebounce
+	return func() tea.Msg {
+		return w.wait(debounce)
+	}
+}
+
+// stopWatching stops watching the file, if it's being watched.
+func (m *pagerModel) stopWatching() {
+	if m.watch != nil {
+		m.watch.stop()
+		m.watch = nil
+	}
+}
+
+func (m *pagerModel) unwatchFile() {
+	m.stopPolling()
+	m.stopWatching()
+}
@@ -2,11 +2,17 @@
 package utils
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
 
+	chromastyles "github.com/alecthomas/chroma/v2/styles"
 	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/glamour/ansi"
 	"github.com/charmbracelet/glamour/styles"
@@ -22,6 +28,16 @@ func RemoveFrontmatter(content []byte) []byte {
 	return content
 }
 
+// Frontmatter returns the front matter header of a markdown file, without
+// its delimiters, or nil if there is none.
+func Frontmatter(content []byte) []byte {
+	if frontmatterBoundaries := detectFrontmatter(content); frontmatterBoundaries[0] == 0 {
+		header := content[:frontmatterBoundaries[1]]
+		return yamlPattern.ReplaceAll(header, nil)
+	}
+	return nil
+}
+
 var yamlPattern = regexp.MustCompile(`(?m)^---\r?\n(\s*\r?\n)?`)
 
 func detectFrontmatter(c []byte) []int {
@@ -50,8 +66,9 @@ var markdownExtensions = []string{
 }
 
 // IsMarkdownFile returns whether the filename has a markdown extension.
+// Compressed files go by the extension inside theirs.
 func IsMarkdownFile(filename string) bool {
-	ext := filepath.Ext(filename)
+	ext := filepath.Ext(UncompressedName(filename))
 
 	if ext == "" {
 		// By default, assume it's a markdown file.
@@ -69,6 +86,48 @@ func IsMarkdownFile(filename string) bool {
 	return false
 }
 
+// IsCompressed returns whether the file is gzip-compressed, going by its
+// extension.
+func IsCompressed(filename string) bool {
+	return strings.EqualFold(filepath.Ext(filename), ".gz")
+}
+
+// UncompressedName returns the name of a compressed file without its .gz
+// extension. Other names are returned as they are.
+func UncompressedName(filename string) string {
+	if IsCompressed(filename) {
+		return filename[:len(filename)-len(".gz")]
+	}
+	return filename
+}
+
+// Decompress decompresses gzip-compressed data.
+func Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decompress: %w", err)
+	}
+	defer r.Close() //nolint:errcheck
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decompress: %w", err)
+	}
+	return out, nil
+}
+
+// ReadFile reads the named file, decompressing it if it's compressed.
+func ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read file: %w", err)
+	}
+	if IsCompressed(path) {
+		return Decompress(data)
+	}
+	return data, nil
+}
+
 // GlamourStyle returns a glamour.TermRendererOption based on the given style.
 func GlamourStyle(style string, isCode bool) glamour.TermRendererOption {
 	if !isCode {
@@ -111,3 +170,68 @@ func GlamourStyle(style string, isCode bool) glamour.TermRendererOption {
 
 	return glamour.WithStyles(styleConfig)
 }
+
+// StyleConfig returns the glamour style config for the given style, which
+// is either the name of a built-in style or the path to a JSON style file.
+func StyleConfig(style string) (ansi.StyleConfig, error) {
+	if style == styles.AutoStyle {
+		if lipgloss.HasDarkBackground() {
+			return styles.DarkStyleConfig, nil
+		}
+		return styles.LightStyleConfig, nil
+	}
+	if styleConfig, ok := styles.DefaultStyles[style]; ok {
+		return *styleConfig, nil
+	}
+
+	b, err := os.ReadFile(style)
+	if err != nil {
+		return ansi.StyleConfig{}, fmt.Errorf("unable to read style: %w", err)
+	}
+	var styleConfig ansi.StyleConfig
+	if err := json.Unmarshal(b, &styleConfig); err != nil {
+		return ansi.StyleConfig{}, fmt.Errorf("unable to parse style: %w", err)
+	}
+	return styleConfig, nil
+}
+
+// StyleOverrides are changes to the colors of a glamour style. Empty
+// fields leave the style as it is.
+type StyleOverrides struct {
+	// Colors of inline code. Fenced code blocks aren't affected.
+	InlineCodeForeground, InlineCodeBackground string
+
+	// Chroma theme code blocks are highlighted in, in place of the style's
+	// own code colors
+	CodeTheme string
+}
+
+// WithStyleOverrides returns a glamour.TermRendererOption for the given
+// style with the given overrides, taking the place of GlamourStyle.
+func WithStyleOverrides(style string, isCode bool, o StyleOverrides) (glamour.TermRendererOption, error) {
+	styleConfig, err := StyleConfig(style)
+	if err != nil {
+		return nil, err
+	}
+	if isCode {
+		var margin uint
+		styleConfig.CodeBlock.Margin = &margin
+	}
+	if o.InlineCodeForeground != "" {
+		styleConfig.Code.Color = &o.InlineCodeForeground
+	}
+	if o.InlineCodeBackground != "" {
+		styleConfig.Code.BackgroundColor = &o.InlineCodeBackground
+	}
+	if o.CodeTheme != "" {
+		styleConfig.CodeBlock.Chroma = nil
+		styleConfig.CodeBlock.Theme = o.CodeTheme
+	}
+	return glamour.WithStyles(styleConfig), nil
+}
+
+// CodeThemeExists returns whether there's a Chroma theme by the given name.
+func CodeThemeExists(theme string) bool {
+	_, ok := chromastyles.Registry[theme]
+	return ok
+}